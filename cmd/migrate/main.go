@@ -1,17 +1,30 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"seedgo/internal/db"
 	"seedgo/internal/global"
 	"seedgo/internal/model"
 	"seedgo/pkg"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
 )
 
 func main() {
+	tenantDB := flag.Int64("tenant-db", 0, "register a dedicated database for the tenant, requires -dsn")
+	dsn := flag.String("dsn", "", "dedicated database dsn of -tenant-db")
+	flag.Parse()
+
 	// 初始化配置和数据库
 	global.InitConfig("config/local.yaml")
 	db.InitDB()
+	// 迁移需要处理所有租户的数据
+	global.DB = global.DB.WithContext(db.WithoutTenant(context.Background(), "database migration"))
 
 	// 自动迁移
 	// 这里列出所有需要迁移的 Model
@@ -55,22 +68,214 @@ func main() {
 		createDefaultSuperUser(defaultTenantID)
 	}
 
+	// 开通模板的内容版本原来存放在 version 列，和乐观锁版本共用，新增 content_version 列后复制过去
+	migrator := global.DB.Migrator()
+	copyContentVersion := migrator.HasTable(&model.TenantTemplate{}) && !migrator.HasColumn(&model.TenantTemplate{}, "content_version")
+
 	// 3. 迁移其他表
 	err := global.DB.AutoMigrate(
 		&model.Tenant{},
 		&model.User{},
 		&model.Role{},
 		&model.Permission{},
+		&model.Menu{},
+		&model.RolePermission{},
+		&model.UserRole{},
 		&model.OperationLog{},
+		&model.Policy{},
+		&model.AccessSnapshot{},
+		&model.Plan{},
+		&model.TenantDatabase{},
+		&model.TenantPurgeLog{},
+		&model.Setting{},
+		&model.TenantSetting{},
+		&model.TenantTemplate{},
+		&model.Dict{},
+		&model.DictItem{},
+		&model.ExportJob{},
 	)
 
 	if err != nil {
 		log.Fatalf("Migration failed: %v", err)
 	}
+	if copyContentVersion {
+		if err := global.DB.Exec("UPDATE tenant_template SET content_version = version").Error; err != nil {
+			log.Fatalf("Failed to migrate template content version: %v", err)
+		}
+	}
+
+	// 4. 从旧的菜单类型权限生成菜单并删除权限表的菜单列，补充内置配置项
+	// 复制菜单和删除旧列在同一个事务中，任意一步失败都不删除旧列，修复后重新执行即可
+	err = global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := seedMenus(tx); err != nil {
+			return err
+		}
+		return migrateLegacyPermissions(tx)
+	})
+	if err != nil {
+		log.Fatalf("Failed to migrate legacy menus: %v", err)
+	}
+	seedSettings()
+	seedDicts()
+
+	// 5. 库级隔离：登记租户数据库，迁移所有租户数据库
+	if *tenantDB > 0 {
+		registerTenantDB(model.ID(*tenantDB), *dsn)
+	}
+	migrateTenantDBs()
 
 	log.Println("Migration completed successfully")
 }
 
+// legacyPermission 权限表中旧的菜单列，复制到菜单表、并入 permission_urls 后删除
+type legacyPermission struct {
+	ID             model.ID
+	ParentID       *model.ID
+	Name           string
+	Path           string
+	Icon           string
+	PermissionCode string
+	Sort           *int
+	Visible        *bool
+	PermissionUrls string
+}
+
+func (legacyPermission) TableName() string {
+	return "permission"
+}
+
+// seedMenus 菜单表为空时，把类型为菜单的权限复制为菜单，并通过 PermissionCode 关联
+// 只有权限表还保留旧的菜单列时才需要复制
+func seedMenus(tx *gorm.DB) error {
+	if !tx.Migrator().HasColumn(&legacyPermission{}, "path") {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&model.Menu{}).Count(&count).Error; err != nil {
+		return fmt.Errorf("count menus: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	var permissions []*legacyPermission
+	if err := tx.Where("type = ?", model.PermissionTypeMenu).Order("parent_id").Order("sort").Order("id").Find(&permissions).Error; err != nil {
+		return fmt.Errorf("load menu permissions: %w", err)
+	}
+
+	// 权限 ID -> 菜单 ID，父菜单需要先创建
+	ids := make(map[model.ID]model.ID)
+	pending := permissions
+	for len(pending) > 0 {
+		var next []*legacyPermission
+		for _, p := range pending {
+			var parentID *model.ID
+			if p.ParentID != nil && *p.ParentID != 0 {
+				id, ok := ids[*p.ParentID]
+				if !ok {
+					next = append(next, p)
+					continue
+				}
+				parentID = &id
+			}
+			m := model.Menu{
+				ParentID:       parentID,
+				Name:           p.PermissionCode,
+				Title:          p.Name,
+				Path:           p.Path,
+				Icon:           p.Icon,
+				PermissionCode: p.PermissionCode,
+				Sort:           p.Sort,
+				Visible:        p.Visible,
+			}
+			if err := tx.Create(&m).Error; err != nil {
+				return fmt.Errorf("create menu %s: %w", p.Name, err)
+			}
+			ids[p.ID] = m.ID
+		}
+		// 父节点不是菜单类型时，剩余的作为根菜单处理
+		if len(next) == len(pending) {
+			for _, p := range next {
+				p.ParentID = nil
+			}
+		}
+		pending = next
+	}
+	log.Printf("Created %d menus from permissions", len(ids))
+	return nil
+}
+
+// migrateLegacyPermissions 旧的权限同时按 path 匹配接口，把 path 并入 permission_urls 后删除菜单列
+// MySQL 的 DROP COLUMN 会隐式提交事务，所以放在最后，前面的步骤失败时不会执行
+func migrateLegacyPermissions(tx *gorm.DB) error {
+	m := tx.Migrator()
+	if !m.HasColumn(&legacyPermission{}, "path") {
+		return nil
+	}
+
+	var permissions []*legacyPermission
+	if err := tx.Where("path <> ''").Find(&permissions).Error; err != nil {
+		return fmt.Errorf("load legacy permissions: %w", err)
+	}
+	for _, p := range permissions {
+		var urls []string
+		for _, u := range strings.Split(p.PermissionUrls, ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				urls = append(urls, u)
+			}
+		}
+		if slices.Contains(urls, p.Path) {
+			continue
+		}
+		urls = append([]string{p.Path}, urls...)
+		if err := tx.Model(p).Update("permission_urls", strings.Join(urls, ",")).Error; err != nil {
+			return fmt.Errorf("migrate permission %s: %w", p.PermissionCode, err)
+		}
+	}
+
+	for _, column := range []string{"path", "icon", "visible"} {
+		if m.HasColumn(&legacyPermission{}, column) {
+			if err := m.DropColumn(&legacyPermission{}, column); err != nil {
+				return fmt.Errorf("drop permission.%s: %w", column, err)
+			}
+		}
+	}
+	log.Printf("Migrated %d legacy permission paths", len(permissions))
+	return nil
+}
+
+// seedSettings 创建内置配置项，已存在的不覆盖
+func seedSettings() {
+	settings := []model.Setting{
+		{Key: "system.name", Name: "系统显示名称", Type: model.SettingTypeString, Value: `"Seedgo"`, Schema: `{"required":true,"max":50}`, Overridable: true, Public: true},
+		{Key: "system.logo", Name: "品牌 Logo", Type: model.SettingTypeString, Value: `""`, Schema: `{"max":500}`, Overridable: true, Public: true},
+	}
+	for _, item := range settings {
+		if err := global.DB.Where("`key` = ?", item.Key).FirstOrCreate(&item).Error; err != nil {
+			log.Printf("Failed to create setting %s: %v", item.Key, err)
+		}
+	}
+}
+
+// seedDicts 补充内置字典，导出时用于把状态等字段翻译为标签
+func seedDicts() {
+	dicts := []model.Dict{
+		{Code: "common_status", Name: "通用状态", Items: []*model.DictItem{
+			{Label: "启用", Value: "1", Sort: 1, Status: 1},
+			{Label: "停用", Value: "0", Sort: 2, Status: 1},
+		}},
+	}
+	for _, item := range dicts {
+		var count int64
+		if err := global.DB.Model(&model.Dict{}).Where("code = ?", item.Code).Count(&count).Error; err != nil || count > 0 {
+			continue
+		}
+		if err := global.DB.Create(&item).Error; err != nil {
+			log.Printf("Failed to create dict %s: %v", item.Code, err)
+		}
+	}
+}
+
 func createDefaultSuperUser(tenantID model.ID) {
 	var count int64
 	// 检查是否存在超级用户
@@ -103,3 +308,37 @@ func createDefaultSuperUser(tenantID model.ID) {
 		}
 	}
 }
+
+// registerTenantDB 登记或者更新租户的独立数据库，已有数据不会迁移过去
+func registerTenantDB(tenantID model.ID, dsn string) {
+	if dsn == "" {
+		log.Fatal("-dsn is required with -tenant-db")
+	}
+	row := model.TenantDatabase{TenantID: tenantID}
+	if err := global.DB.Where("tenant_id = ?", tenantID).Assign(model.TenantDatabase{Dsn: dsn}).FirstOrCreate(&row).Error; err != nil {
+		log.Fatalf("Failed to register database of tenant %d: %v", tenantID, err)
+	}
+	log.Printf("Registered database of tenant %d", tenantID)
+}
+
+// migrateTenantDBs 迁移登记了独立数据库的租户，租户库只包含租户数据表，共享表留在控制库
+func migrateTenantDBs() {
+	router := db.GetRouter()
+	if router == nil {
+		return
+	}
+	if err := router.Reload(); err != nil {
+		log.Fatalf("Failed to load tenant databases: %v", err)
+	}
+	models := append(append([]any{}, db.TenantModels...), db.TenantJoinModels...)
+	for _, id := range router.Tenants() {
+		tdb, err := router.Open(id)
+		if err != nil {
+			log.Fatalf("Failed to open database of tenant %d: %v", id, err)
+		}
+		if err := tdb.AutoMigrate(models...); err != nil {
+			log.Fatalf("Migration of tenant %d failed: %v", id, err)
+		}
+		log.Printf("Migrated database of tenant %d", id)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"seedgo/internal/db"
+	"seedgo/internal/global"
+	"seedgo/internal/modules/perms"
+	"seedgo/pkg/cache"
+	"strings"
+)
+
+// 权限树导入导出工具
+//
+//	go run ./cmd/permission export -o permissions.yaml
+//	go run ./cmd/permission import -f permissions.yaml -dry-run -prune
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	cmd := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	config := cmd.String("config", "config/local.yaml", "配置文件")
+	output := cmd.String("o", "", "导出文件，不指定时输出到标准输出")
+	format := cmd.String("format", "", "导出格式 yaml/json，不指定时根据文件后缀判断")
+	input := cmd.String("f", "", "导入文件")
+	dryRun := cmd.Bool("dry-run", false, "只显示差异，不写入数据库")
+	prune := cmd.Bool("prune", false, "删除文件中不存在的权限")
+	_ = cmd.Parse(os.Args[2:])
+
+	global.InitConfig(*config)
+	db.InitDB()
+	global.Cache = cache.Use(cache.NewMemoryCache())
+
+	svc := perms.GetService()
+	ctx := context.Background()
+
+	switch os.Args[1] {
+	case "export":
+		if *format == "" {
+			*format = strings.TrimPrefix(filepath.Ext(*output), ".")
+		}
+		file, err := svc.Export(ctx)
+		if err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+		data, err := perms.MarshalPermissionFile(file, *format)
+		if err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+		if *output == "" {
+			os.Stdout.Write(data)
+			return
+		}
+		if err := os.WriteFile(*output, data, 0644); err != nil {
+			log.Fatalf("Write file failed: %v", err)
+		}
+		log.Printf("Exported permissions to %s", *output)
+	case "import":
+		if *input == "" {
+			log.Fatal("Missing -f")
+		}
+		data, err := os.ReadFile(*input)
+		if err != nil {
+			log.Fatalf("Read file failed: %v", err)
+		}
+		file, err := perms.UnmarshalPermissionFile(data)
+		if err != nil {
+			log.Fatalf("Invalid permission file: %v", err)
+		}
+		diff, err := svc.Import(ctx, file, perms.ImportOptions{DryRun: *dryRun, Prune: *prune})
+		if err != nil {
+			log.Fatalf("Import failed: %v", err)
+		}
+		out, _ := json.MarshalIndent(diff, "", "  ")
+		fmt.Println(string(out))
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: permission export [-o file] [-format yaml|json]")
+	fmt.Fprintln(os.Stderr, "       permission import -f file [-dry-run] [-prune]")
+	os.Exit(2)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"seedgo/internal/db"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/tenant"
+	"seedgo/pkg/cache"
+)
+
+// 租户数据归档工具，数据量大时比接口更合适
+//
+//	go run ./cmd/tenant export -id 3 -o tenant-3.zip
+//	go run ./cmd/tenant import -f tenant-3.zip -name "Acme" -code acme -dry-run
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	cmd := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	config := cmd.String("config", "config/local.yaml", "配置文件")
+	id := cmd.Int64("id", 0, "导出的租户 ID")
+	output := cmd.String("o", "", "导出文件")
+	input := cmd.String("f", "", "导入文件")
+	name := cmd.String("name", "", "新租户名称，默认使用归档中的名称")
+	code := cmd.String("code", "", "新租户编码，默认使用归档中的编码")
+	dryRun := cmd.Bool("dry-run", false, "只校验，不写入数据库")
+	_ = cmd.Parse(os.Args[2:])
+
+	global.InitConfig(*config)
+	db.InitDB()
+	global.Cache = cache.Use(cache.NewMemoryCache())
+
+	svc := tenant.GetService()
+	ctx := context.Background()
+
+	switch os.Args[1] {
+	case "export":
+		if *id == 0 || *output == "" {
+			usage()
+		}
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("Create file failed: %v", err)
+		}
+		defer file.Close()
+		manifest, err := svc.Export(ctx, model.ID(*id), file)
+		if err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+		for _, t := range manifest.Tables {
+			log.Printf("%-20s %d rows", t.Name, t.Rows)
+		}
+		log.Printf("Exported tenant %d to %s", *id, *output)
+	case "import":
+		if *input == "" {
+			usage()
+		}
+		file, err := os.Open(*input)
+		if err != nil {
+			log.Fatalf("Open file failed: %v", err)
+		}
+		defer file.Close()
+		stat, err := file.Stat()
+		if err != nil {
+			log.Fatalf("Open file failed: %v", err)
+		}
+		report, err := svc.Import(ctx, file, stat.Size(), tenant.ArchiveImportOptions{DryRun: *dryRun, Name: *name, Code: *code})
+		if err != nil {
+			log.Fatalf("Import failed: %v", err)
+		}
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+		if len(report.Errors) > 0 {
+			os.Exit(1)
+		}
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: tenant export -id tenantId -o file")
+	fmt.Fprintln(os.Stderr, "       tenant import -f file [-name name] [-code code] [-dry-run]")
+	os.Exit(2)
+}
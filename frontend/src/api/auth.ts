@@ -56,3 +56,12 @@ export function changePassword(data: any) {
     data,
   })
 }
+
+// 转让租户主账号，password 为当前用户的密码
+export function transferOwnership(data: { userId: number | string; password: string }) {
+  return request({
+    url: '/common/user/transfer-ownership',
+    method: 'post',
+    data: { ...data, userId: Number(data.userId) },
+  })
+}
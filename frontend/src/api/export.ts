@@ -0,0 +1,80 @@
+import request from '@/utils/request'
+
+export type ExportFormat = 'csv' | 'xlsx'
+
+export interface ExportJob {
+  id: number
+  name: string
+  format: ExportFormat
+  status: 'pending' | 'running' | 'success' | 'failed'
+  total: number
+  rows: number
+  error?: string
+  createdAt: number
+  finishedAt?: number
+}
+
+function saveBlob(blob: Blob, filename: string) {
+  const link = document.createElement('a')
+  link.href = URL.createObjectURL(blob)
+  link.download = filename
+  link.click()
+  URL.revokeObjectURL(link.href)
+}
+
+function filenameOf(disposition: string | undefined, fallback: string) {
+  const match = disposition?.match(/filename="?([^";]+)"?/)
+  return match ? decodeURIComponent(match[1]) : fallback
+}
+
+// 导出列表，url 为列表接口（如 /system/users），params 为列表的查询条件，可以带 columns 选择列
+// 数据量大时后端转为后台任务，返回任务，完成后通过 downloadExportJob 下载
+export async function exportList(url: string, format: ExportFormat, params?: Record<string, any>): Promise<ExportJob | undefined> {
+  const response: any = await request({
+    url: `${url}/export/${format}`,
+    method: 'get',
+    params,
+    responseType: 'blob',
+    timeout: 0,
+  })
+  const blob: Blob = response.data
+  if (blob.type.includes('application/json')) {
+    const res = JSON.parse(await blob.text())
+    if (res.code !== 0) {
+      throw new Error(res.message)
+    }
+    return res.data as ExportJob
+  }
+  saveBlob(blob, filenameOf(response.headers['content-disposition'], `export.${format}`))
+}
+
+export function getExportJobs() {
+  return request({
+    url: '/common/exports',
+    method: 'get',
+  })
+}
+
+export function getExportJob(id: number) {
+  return request<ExportJob>({
+    url: `/common/exports/${id}`,
+    method: 'get',
+  })
+}
+
+export async function downloadExportJob(job: ExportJob) {
+  const response: any = await request({
+    url: `/common/exports/${job.id}/download`,
+    method: 'get',
+    responseType: 'blob',
+    timeout: 0,
+  })
+  saveBlob(response.data, `${job.name}-${job.id}.${job.format}`)
+}
+
+export function deleteExportJob(id: number) {
+  return request({
+    url: `/common/exports/${id}`,
+    method: 'delete',
+  })
+}
@@ -0,0 +1,77 @@
+import request from '@/utils/request'
+
+export type ImportFormat = 'csv' | 'xlsx'
+export type ImportMode = 'atomic' | 'best-effort'
+
+export interface ImportColumn {
+  key: string
+  title: string
+  required: boolean
+  dict?: string
+}
+
+export interface ImportRowResult {
+  row: number
+  success: boolean
+  id?: number
+  errors?: string[]
+  data: Record<string, string>
+}
+
+export interface ImportReport {
+  mode: ImportMode
+  dryRun: boolean
+  committed: boolean
+  total: number
+  succeeded: number
+  failed: number
+  mapping: Record<string, string>
+  ignored: string[]
+  rows: ImportRowResult[]
+}
+
+export interface ImportOptions {
+  mode?: ImportMode
+  dryRun?: boolean
+  // 表头 -> 字段名，值为空表示忽略该列
+  mapping?: Record<string, string>
+}
+
+// 下载导入模板，url 为列表接口（如 /system/users）
+export async function downloadImportTemplate(url: string, format: ImportFormat) {
+  const response: any = await request({
+    url: `${url}/import/template/${format}`,
+    method: 'get',
+    responseType: 'blob',
+  })
+  const link = document.createElement('a')
+  link.href = URL.createObjectURL(response.data)
+  link.download = `template.${format}`
+  link.click()
+  URL.revokeObjectURL(link.href)
+}
+
+export function getImportColumns(url: string) {
+  return request<ImportColumn[]>({
+    url: `${url}/import/columns`,
+    method: 'get',
+  })
+}
+
+// 上传导入，先用 dryRun 预览每行的结果，确认后再提交
+// atomic 模式有失败的行时接口返回错误，报告在错误响应的 data 中
+export function importList(url: string, file: File, options: ImportOptions = {}) {
+  const data = new FormData()
+  data.append('file', file)
+  data.append('mode', options.mode ?? 'atomic')
+  data.append('dryRun', String(!!options.dryRun))
+  if (options.mapping) {
+    data.append('mapping', JSON.stringify(options.mapping))
+  }
+  return request<ImportReport>({
+    url: `${url}/import/upload`,
+    method: 'post',
+    data,
+    timeout: 0,
+  })
+}
@@ -5,12 +5,9 @@ export interface Menu {
   parentId?: number | string
   name: string
   type: number // 1: Menu, 2: Button
-  path?: string
-  icon?: string
   permissionCode?: string
   permissionUrls?: string
   sort: number
-  visible: boolean
   children?: Menu[]
   createdAt: string
   updatedAt: string
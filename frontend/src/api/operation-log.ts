@@ -21,6 +21,11 @@ export interface OperationLog {
 export interface GetOperationLogsParams {
   page?: number
   pageSize?: number
+  // 游标分页，第一页传空字符串，之后传返回的 nextCursor
+  cursor?: string
+  count?: 'exact' | 'estimate' | 'none'
+  sortBy?: string
+  sortOrder?: 'asc' | 'desc'
   keyword?: string
   status?: number
   method?: string
@@ -30,7 +35,10 @@ export interface GetOperationLogsParams {
 
 export interface OperationLogListResult {
   items: OperationLog[]
+  // 不统计时为 -1
   total: number
+  nextCursor?: string
+  estimated?: boolean
 }
 
 export function getOperationLogs(params: GetOperationLogsParams) {
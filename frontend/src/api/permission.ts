@@ -6,9 +6,7 @@ export interface Permission {
   name: string
   permissionCode: string // This maps to permissionCode from backend
   type: number // 1: Menu, 2: Button
-  path?: string
-  icon?: string
-  visible?: number | boolean
+  permissionUrls?: string
   sort?: number
   children?: Permission[]
 }
@@ -20,3 +18,26 @@ export function getPermissionTree(params?: any) {
     params
   })
 }
+
+export interface RouteMenu {
+  path: string
+  name?: string
+  component?: string
+  redirect?: string
+  meta: {
+    title: string
+    icon?: string
+    hidden: boolean
+    keepAlive: boolean
+    link?: string
+    permissionCode?: string
+  }
+  children?: RouteMenu[]
+}
+
+export function getUserMenus() {
+  return request<RouteMenu[]>({
+    url: '/common/user/menus',
+    method: 'get'
+  })
+}
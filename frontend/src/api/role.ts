@@ -7,6 +7,8 @@ export interface Role {
   code?: string
   description?: string
   isSystem: number
+  // 乐观锁版本号，更新时原样提交
+  version?: number
   createdAt: string
   updatedAt: string
 }
@@ -0,0 +1,102 @@
+import request from '@/utils/request'
+
+export type SettingType = 'string' | 'int' | 'bool' | 'json'
+
+export interface Setting {
+  id: number | string
+  key: string
+  name: string
+  type: SettingType
+  value: string
+  schema?: string
+  overridable: boolean
+  public: boolean
+  description?: string
+}
+
+export interface SettingValue {
+  id: number | string
+  key: string
+  name: string
+  type: SettingType
+  schema?: string
+  description?: string
+  overridable: boolean
+  public: boolean
+  default: any
+  value: any
+  overridden: boolean
+}
+
+export function getSettings(params?: any) {
+  return request({
+    url: '/system/settings',
+    method: 'get',
+    params
+  })
+}
+
+export function createSetting(data: any) {
+  return request({
+    url: '/system/settings',
+    method: 'post',
+    data
+  })
+}
+
+export function updateSetting(id: number | string, data: any) {
+  return request({
+    url: `/system/settings/${id}`,
+    method: 'put',
+    data
+  })
+}
+
+export function deleteSetting(id: number | string) {
+  return request({
+    url: `/system/settings/${id}`,
+    method: 'delete'
+  })
+}
+
+// 配置项在租户下的生效值，超级用户可以通过 tenantId 指定租户
+export function getSettingValues(params?: { tenantId?: number | string }) {
+  return request<any, SettingValue[]>({
+    url: '/system/settings/values',
+    method: 'get',
+    params
+  })
+}
+
+export function setSettingValue(key: string, value: any, params?: { tenantId?: number | string }) {
+  return request({
+    url: `/system/settings/values/${key}`,
+    method: 'put',
+    data: { value },
+    params
+  })
+}
+
+export function resetSettingValue(key: string, params?: { tenantId?: number | string }) {
+  return request({
+    url: `/system/settings/values/${key}`,
+    method: 'delete',
+    params
+  })
+}
+
+// 当前租户生效的配置
+export function getCurrentSettings() {
+  return request<any, Record<string, any>>({
+    url: '/common/settings',
+    method: 'get'
+  })
+}
+
+// 公开配置，登录页使用
+export function getPublicSettings() {
+  return request<any, Record<string, any>>({
+    url: '/auth/settings',
+    method: 'get'
+  })
+}
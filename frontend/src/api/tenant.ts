@@ -44,3 +44,44 @@ export const deleteTenant = (id: string) => {
     method: 'delete'
   })
 }
+
+// 开通模板
+export const getTenantTemplates = (params?: any) => {
+  return request({
+    url: '/tenant/templates',
+    method: 'get',
+    params
+  })
+}
+
+export const createTenantTemplate = (data: any) => {
+  return request({
+    url: '/tenant/templates',
+    method: 'post',
+    data
+  })
+}
+
+export const updateTenantTemplate = (id: string, data: any) => {
+  return request({
+    url: `/tenant/templates/${id}`,
+    method: 'put',
+    data
+  })
+}
+
+export const deleteTenantTemplate = (id: string) => {
+  return request({
+    url: `/tenant/templates/${id}`,
+    method: 'delete'
+  })
+}
+
+// 重新应用模板，tenantIds 为空时应用到版本落后的租户
+export const applyTenantTemplate = (id: string, tenantIds?: string[]) => {
+  return request({
+    url: `/tenant/templates/${id}/apply`,
+    method: 'post',
+    data: { tenantIds }
+  })
+}
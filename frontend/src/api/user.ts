@@ -12,6 +12,8 @@ export interface User {
   avatar?: string
   role?: string
   roles?: { roleId: string }[]
+  // 乐观锁版本号，更新时原样提交
+  version?: number
   createdAt: string
   updatedAt: string
 }
@@ -21,6 +23,10 @@ export interface GetUsersParams {
   pageSize?: number
   keyword?: string
   tenantId?: number
+  // 逗号分隔的字段名，只返回这些字段
+  fields?: string
+  // 逗号分隔的关联：roles、tenant
+  expand?: string
 }
 
 export interface UserListResult {
@@ -73,3 +79,11 @@ export function batchDeleteUsers(ids: number[]) {
     data: { ids }
   })
 }
+
+export function batchUpdateUsers(ids: number[], fields: Partial<Pick<User, 'status'>>) {
+  return request({
+    url: '/system/users/batch-update',
+    method: 'post',
+    data: { ids, fields }
+  })
+}
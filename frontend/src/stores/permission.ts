@@ -1,6 +1,6 @@
 import {defineStore} from 'pinia'
 import {computed, ref} from 'vue'
-import {getPermissionTree, type Permission} from '@/api/permission'
+import {getPermissionTree, getUserMenus, type Permission, type RouteMenu} from '@/api/permission'
 import * as icons from 'lucide-vue-next'
 import {useAuthStore} from './auth'
 import {useRoute} from 'vue-router'
@@ -11,6 +11,7 @@ export const usePermissionStore = defineStore('permission', () => {
   const rawPermissions = ref<Permission[]>([])
   const permissions = ref<Set<string>>(new Set())
   const menuPaths = ref<Set<string>>(new Set())
+  const menuCodes = ref<Map<string, string>>(new Map())
   const isLoading = ref(false)
 
   // Resolve icon string to component
@@ -38,39 +39,19 @@ export const usePermissionStore = defineStore('permission', () => {
     return (icons as any)['Circle']
   }
 
-  // Recursive function to process tree for sidebar
-  const processMenuTree = (items: Permission[], parentId: string | number | null = null): any[] => {
+  // Recursive function to process menus for sidebar
+  const processMenuTree = (items: RouteMenu[]): any[] => {
     return items
-      .filter(item => {
-        // Only show visible menus
-        // Handle both number (1) and boolean (true)
-        const isVisible = item.visible === 1 || item.visible === true
-        if (item.type !== 1 || !isVisible) return false
-
-        // Filter by parentId
-        if (parentId === null) {
-          // Top level: parentId is 0, -1, null, undefined, or empty string
-          return !item.parentId || item.parentId === 0 || item.parentId === -1 || item.parentId === '0' || item.parentId === '-1'
-        }
-        
-        // Child level: parentId matches
-        return item.parentId == parentId
-      })
+      // Hidden menus are still accessible, but not shown in sidebar
+      .filter(item => !item.meta?.hidden)
       .map(item => {
         const menu: any = {
-          name: item.name,
-          href: item.path || '',
-          icon: resolveIcon(item.icon),
-        }
-
-        // Try to find children in the current list (Flat List pattern)
-        let children = processMenuTree(items, item.id)
-
-        // If no children found in flat list, check item.children (Tree pattern)
-        if (children.length === 0 && item.children && item.children.length > 0) {
-          children = processMenuTree(item.children, item.id)
+          name: item.meta?.title || item.name,
+          href: item.meta?.link || item.path || '',
+          icon: resolveIcon(item.meta?.icon),
         }
 
+        const children = processMenuTree(item.children || [])
         if (children.length > 0) {
           menu.children = children
         }
@@ -79,19 +60,31 @@ export const usePermissionStore = defineStore('permission', () => {
       })
   }
 
-  // Recursive function to extract all permission codes and paths
+  // Recursive function to extract all permission codes
   const extractPermissions = (items: Permission[]) => {
     items.forEach(item => {
       if (item.permissionCode) {
         permissions.value.add(item.permissionCode)
       }
+      if (item.children && item.children.length > 0) {
+        extractPermissions(item.children)
+      }
+    })
+  }
+
+  // Recursive function to extract menu paths and their permission codes
+  const extractMenuPaths = (items: RouteMenu[]) => {
+    items.forEach(item => {
       if (item.path) {
         // Ensure path starts with /
         const path = item.path.startsWith('/') ? item.path : '/' + item.path
         menuPaths.value.add(path)
+        if (item.meta?.permissionCode) {
+          menuCodes.value.set(path, item.meta.permissionCode)
+        }
       }
       if (item.children && item.children.length > 0) {
-        extractPermissions(item.children)
+        extractMenuPaths(item.children)
       }
     })
   }
@@ -100,21 +93,25 @@ export const usePermissionStore = defineStore('permission', () => {
     if (isLoading.value) return
     isLoading.value = true
     try {
-      const res = await getPermissionTree() as any
       // res is the array because request interceptor returns res.data
+      const [permRes, menuRes] = await Promise.all([getPermissionTree(), getUserMenus()]) as any[]
 
-      const items = Array.isArray(res) ? res : []
+      const items = Array.isArray(permRes) ? permRes : []
+      const menus = Array.isArray(menuRes) ? menuRes : []
       permissions.value.clear()
       menuPaths.value.clear()
+      menuCodes.value.clear()
       rawPermissions.value = items // Store raw tree
       extractPermissions(items)
-      menuTree.value = processMenuTree(items)
+      extractMenuPaths(menus)
+      menuTree.value = processMenuTree(menus)
 
     } catch (error) {
       menuTree.value = []
       rawPermissions.value = []
       permissions.value.clear()
       menuPaths.value.clear()
+      menuCodes.value.clear()
       throw error
     } finally {
       isLoading.value = false
@@ -128,24 +125,25 @@ export const usePermissionStore = defineStore('permission', () => {
   const getModulePermissions = (path: string): string[] => {
     const codes: string[] = []
 
-    const findNode = (nodes: Permission[], targetPath: string): Permission | undefined => {
-      for (const node of nodes) {
-        const nodePath = node.path?.startsWith('/') ? node.path : '/' + (node.path || '');
-        const target = targetPath.startsWith('/') ? targetPath : '/' + targetPath;
+    // Menu is linked to its page permission by permissionCode
+    const target = path.startsWith('/') ? path : '/' + path
+    const code = menuCodes.value.get(target) || menuCodes.value.get(target.replace(/\/$/, ''))
+    if (!code) return codes
 
-        // Exact match or match without trailing slash
-        if (node.type === 1 && (nodePath === target || nodePath === target + '/')) {
+    const findNode = (nodes: Permission[]): Permission | undefined => {
+      for (const node of nodes) {
+        if (node.permissionCode === code) {
           return node;
         }
         if (node.children) {
-          const found = findNode(node.children, targetPath);
+          const found = findNode(node.children);
           if (found) return found;
         }
       }
       return undefined;
     }
 
-    const node = findNode(rawPermissions.value, path)
+    const node = findNode(rawPermissions.value)
 
     if (node) {
       // If the menu node itself has a code, include it
@@ -169,6 +167,7 @@ export const usePermissionStore = defineStore('permission', () => {
     rawPermissions.value = []
     permissions.value.clear()
     menuPaths.value.clear()
+    menuCodes.value.clear()
   }
 
   return {
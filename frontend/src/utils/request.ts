@@ -23,6 +23,11 @@ service.interceptors.request.use(
     if (token) {
       config.headers.Authorization = `Bearer ${token}`
     }
+    // 超级用户选择的租户，后端按该租户过滤数据，其他用户忽略
+    const scopeTenant = localStorage.getItem('scopeTenant')
+    if (scopeTenant) {
+      config.headers['X-Scope-Tenant'] = scopeTenant
+    }
     return config
   },
   (error: AxiosError) => {
@@ -33,6 +38,10 @@ service.interceptors.request.use(
 // Response interceptor
 service.interceptors.response.use(
   (response: AxiosResponse<ApiResponse>) => {
+    // 文件下载返回完整响应，由调用方读取文件名和内容
+    if (response.config.responseType === 'blob') {
+      return response as any
+    }
     const res = response.data
     // Assuming 0 or 200 is success. Adjust based on actual backend agreement.
     // Common pattern: code 200/0 is success.
@@ -40,7 +49,8 @@ service.interceptors.response.use(
     if (res.code !== 200 && res.code !== 0 && res.code !== 201) {
       const errorMessage = res.msg || res.message || 'Error'
       showToast(errorMessage, { type: 'error' })
-      return Promise.reject(new Error(errorMessage))
+      // 附带业务码和数据，如乐观锁冲突（70003）时的当前记录、批量操作的逐条结果
+      return Promise.reject(Object.assign(new Error(errorMessage), {code: res.code, data: res.data}))
     }
     return res.data
   },
@@ -1,20 +1,30 @@
 package api
 
 import (
+	"net/http"
 	"seedgo/internal/middleware"
 	"seedgo/internal/modules/auth"
 	"seedgo/internal/modules/common"
 	"seedgo/internal/modules/dict"
+	"seedgo/internal/modules/export"
 	"seedgo/internal/modules/log"
+	"seedgo/internal/modules/menu"
 	"seedgo/internal/modules/perms"
+	"seedgo/internal/modules/policy"
+	"seedgo/internal/modules/provision"
+	"seedgo/internal/modules/quota"
+	"seedgo/internal/modules/report"
 	"seedgo/internal/modules/role"
+	"seedgo/internal/modules/setting"
 	"seedgo/internal/modules/tenant"
 	"seedgo/internal/modules/user"
+	"seedgo/internal/shared"
 
 	"github.com/gin-gonic/gin"
 )
 
-func InitRouter() *gin.Engine {
+// InitRouter 初始化路由，开启路径识别租户时由 TenantPath 去掉路径前缀
+func InitRouter() http.Handler {
 	r := gin.Default()
 	err := r.SetTrustedProxies(nil)
 	if err != nil {
@@ -22,30 +32,47 @@ func InitRouter() *gin.Engine {
 	}
 	r.Use(middleware.Cors())
 
-	g := r.Group("/api")
+	//创建前检查租户配额
+	shared.RegisterCreateHook(quota.GetService().CheckCreate)
+
+	g := r.Group("/api", middleware.TenantResolver())
 
 	//认证
 	auth.NewHandler().Use(g.Group("/auth"))
 
 	// 公共 (仅需登录)
 	common.NewHandler().Use(g.Group("/common", middleware.AuthMiddleware()))
+	//导出任务 (仅需登录，只能访问自己的任务)
+	export.NewHandler().Use(g.Group("/common/exports", middleware.AuthMiddleware()))
 
 	// 其他(登录+权限校验)
 	g.Use(middleware.AuthMiddleware(), middleware.OperationLogMiddleware(), middleware.PermissionsMiddleware())
 	{
 		//权限资源
 		perms.NewHandler().Use(g.Group("system/permissions"))
+		//菜单
+		menu.NewHandler().Use(g.Group("system/menus"))
 		//用户
 		user.NewHandler().Use(g.Group("system/users"))
 		//角色
 		role.NewHandler().Use(g.Group("system/roles"))
 		//租户
 		tenant.NewHandler().Use(g.Group("tenant/tenants"))
+		//套餐
+		quota.NewHandler().Use(g.Group("tenant/plans", middleware.SuperWrites()))
+		//开通模板
+		provision.NewHandler().Use(g.Group("tenant/templates", middleware.SuperWrites()))
 		//字典
 		dict.NewHandler().Use(g.Group("system/dicts"))
 		//操作日志
 		log.NewHandler().Use(g.Group("system/operation-logs"))
+		//访问策略
+		policy.NewHandler().Use(g.Group("system/policies"))
+		//权限审查
+		report.NewHandler().Use(g.Group("system/access-reviews"))
+		//系统配置
+		setting.NewHandler().Use(g.Group("system/settings"))
 	}
 
-	return r
+	return middleware.TenantPath(r)
 }
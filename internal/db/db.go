@@ -3,6 +3,7 @@ package db
 import (
 	"log"
 	"seedgo/internal/global"
+	"seedgo/internal/model"
 	"time"
 
 	"gorm.io/driver/mysql"
@@ -28,12 +29,17 @@ func InitDB() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	//使用插件
-	err = global.DB.Use(&TenantPlugin{})
-	if err != nil {
-		log.Fatalf("❌ Failed to register TenantPlugin: %v", err)
-	} else {
-		log.Println("👏 Database connected successfully with TenantPlugin")
+	LogBypass = global.Config.Tenant.LogBypass
+	if err := Setup(global.DB, global.Config.Tenant.Strict); err != nil {
+		log.Fatalf("❌ Failed to setup database: %v", err)
+	}
+	log.Println("👏 Database connected successfully with TenantPlugin")
+
+	if global.Config.Database.PerTenant {
+		if _, err := UseRouter(global.DB, func(dsn string) gorm.Dialector { return mysql.Open(dsn) }); err != nil {
+			log.Fatalf("❌ Failed to setup tenant database router: %v", err)
+		}
+		log.Println("Database-per-tenant mode enabled")
 	}
 
 	sqlDB, err := global.DB.DB()
@@ -49,3 +55,40 @@ func InitDB() {
 
 	log.Println("Database connected successfully")
 }
+
+// TenantModels 租户隔离的模型，注册后 Table、Raw、Exec 中出现这些表也会按租户校验
+var TenantModels = []any{
+	&model.User{},
+	&model.Role{},
+	&model.Policy{},
+	&model.OperationLog{},
+	&model.AccessSnapshot{},
+	&model.TenantSetting{},
+	&model.ExportJob{},
+}
+
+// Setup 注册租户插件、自定义关联表和角色分配有效期的过滤，strict 为 true 时开启严格的租户隔离
+func Setup(db *gorm.DB, strict bool) error {
+	//使用插件
+	plugin := &TenantPlugin{Strict: strict}
+	if err := db.Use(plugin); err != nil {
+		return err
+	}
+	if err := plugin.Register(db, TenantModels...); err != nil {
+		return err
+	}
+
+	// 自定义关联表需要在使用前注册
+	// 角色权限关联表带有 Effect 字段
+	if err := db.SetupJoinTable(&model.Role{}, "Permissions", &model.RolePermission{}); err != nil {
+		return err
+	}
+	// 用户角色关联表带有有效期字段
+	if err := db.SetupJoinTable(&model.User{}, "Roles", &model.UserRole{}); err != nil {
+		return err
+	}
+	if err := db.SetupJoinTable(&model.Role{}, "Users", &model.UserRole{}); err != nil {
+		return err
+	}
+	return db.Callback().Query().Before("gorm:query").Register("user_role:active", filterActiveRoles)
+}
@@ -1,19 +1,26 @@
-// Package global 全局多租户插件和delete_at过滤
+// Package db 全局多租户插件和delete_at过滤
 package db
 
 import (
-	"log"
 	"reflect"
-	"seedgo/internal/global"
+	"regexp"
 	"seedgo/internal/model"
-	"seedgo/internal/scope"
-	"time"
+	"sync"
 
-	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
-type TenantPlugin struct{}
+// TenantPlugin 多租户插件，按 context 中的租户过滤查询、更新、删除，创建时填充 TenantID
+// 租户来源见 TenantScope；Strict 为 true 时，租户隔离的模型在没有租户上下文时直接返回 ErrMissingTenant，
+// 否则保持旧的行为，不做过滤
+type TenantPlugin struct {
+	Strict bool
+
+	// tables 租户隔离的表名，用于识别 Table、Raw、Exec 这类没有模型的查询
+	tables sync.Map
+}
 
 func (p *TenantPlugin) Name() string { return "TenantPlugin" }
 
@@ -21,69 +28,82 @@ func (p *TenantPlugin) Initialize(db *gorm.DB) error {
 	// 覆盖查询、更新、删除
 	db.Callback().Query().Before("gorm:query").Register("tenant:filter", p.filter)
 	db.Callback().Delete().Before("gorm:delete").Register("tenant:filter", p.filter)
-
 	db.Callback().Update().Before("gorm:update").Register("tenant:filter", p.filter)
+	// Scan、Row 使用 Row 回调，同样需要过滤
+	db.Callback().Row().Before("gorm:row").Register("tenant:filter", p.filter)
 	db.Callback().Create().Before("gorm:create").Register("tenant:create", p.create)
+	// 原生 SQL 无法自动加条件，严格模式下要求有租户上下文
+	db.Callback().Raw().Before("gorm:raw").Register("tenant:raw", p.raw)
 
-	// 清除缓存
-	db.Callback().Delete().After("gorm:delete").Register("cache:clear", p.clearCache)
-	db.Callback().Create().After("gorm:create").Register("cache:clear", p.clearCache)
-	db.Callback().Update().After("gorm:update").Register("cache:clear", p.clearCache)
+	// 权限缓存由 perms.Service 按受影响的用户精确清除，见 perms/invalidate.go
 
 	return nil
 }
-func (p *TenantPlugin) clearCache(db *gorm.DB) {
-	//判断表如果是role、permission则删除缓存
-	if db.Statement.Table == new(model.Role).TableName() || db.Statement.Table == new(model.Permission).TableName() {
-		var beginTime = time.Now()
-		err := global.Cache.DeletePrefix("auth:permissions")
+
+// Register 注册租户隔离的模型，用于识别 Table、Raw、Exec 中的表名
+func (p *TenantPlugin) Register(db *gorm.DB, models ...any) error {
+	for _, m := range models {
+		s, err := schema.Parse(m, &sync.Map{}, db.NamingStrategy)
 		if err != nil {
-			log.Println("清除所有权限缓存失败", err)
-			return
+			return err
 		}
-		if gin.Mode() == gin.DebugMode {
-			// 提示用户清除缓存成功
-			log.Println("清除所有权限缓存成功,耗时:", time.Since(beginTime))
+		if s.LookUpField(model.FieldTenantID) != nil {
+			p.tables.Store(s.Table, true)
 		}
 	}
+	return nil
 }
 
-func (p *TenantPlugin) create(db *gorm.DB) {
+// tenantScoped 判断当前语句是否操作租户隔离的表
+func (p *TenantPlugin) tenantScoped(db *gorm.DB) bool {
 	if db.Statement.Schema != nil {
-		// 检查是否跳过租户过滤
-		if skip, ok := db.Get("skip_tenant_filter"); ok && skip.(bool) {
-			return
+		if db.Statement.Schema.LookUpField(model.FieldTenantID) == nil {
+			return false
+		}
+		p.tables.Store(db.Statement.Schema.Table, true)
+		return true
+	}
+	if db.Statement.Table != "" {
+		_, ok := p.tables.Load(db.Statement.Table)
+		return ok
+	}
+	return false
+}
+
+func (p *TenantPlugin) create(db *gorm.DB) {
+	if !p.tenantScoped(db) || db.Statement.Schema == nil {
+		return
+	}
+	tenantID, filter, err := TenantScope(db.Statement.Context)
+	if err != nil {
+		if p.Strict {
+			_ = db.AddError(err)
 		}
+		return
+	}
+	if !filter {
+		return
+	}
 
-		value := db.Statement.Context.Value("user")
-		// 查找模型中是否存在 TenantID 字段
-		if field := db.Statement.Schema.LookUpField("TenantID"); field != nil {
-			// 从 Context 获取租户 ID
-			if value != nil {
-				user := value.(*scope.UserContext)
-				//超级用户可以看所有数据
-				if !user.IsSuper {
-					//给实体设置
-					// 3. 处理数据注入 (支持单条和批量)
-					switch db.Statement.ReflectValue.Kind() {
-					case reflect.Slice, reflect.Array:
-						// 批量插入场景
-						for i := 0; i < db.Statement.ReflectValue.Len(); i++ {
-							item := db.Statement.ReflectValue.Index(i)
-							//设置租户id
-							p.setTenantFieldValue(item, user.TenantID)
-						}
-					case reflect.Struct:
-						// 单条插入场景
-						p.setTenantFieldValue(db.Statement.ReflectValue, user.TenantID)
-					}
-				}
-			}
+	//给实体设置
+	// 处理数据注入 (支持单条和批量)
+	force := !actingTenant(db.Statement.Context)
+	switch db.Statement.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		// 批量插入场景
+		for i := 0; i < db.Statement.ReflectValue.Len(); i++ {
+			item := db.Statement.ReflectValue.Index(i)
+			//设置租户id
+			p.setTenantFieldValue(item, tenantID, force)
 		}
+	case reflect.Struct:
+		// 单条插入场景
+		p.setTenantFieldValue(db.Statement.ReflectValue, tenantID, force)
 	}
 }
 
-func (p *TenantPlugin) setTenantFieldValue(val reflect.Value, tenantID model.ID) {
+// setTenantFieldValue 设置 TenantID，force 为 false 时只填充为 0 的字段
+func (p *TenantPlugin) setTenantFieldValue(val reflect.Value, tenantID model.ID, force bool) {
 	// 如果是指针，获取指向的值
 	if val.Kind() == reflect.Ptr {
 		if val.IsNil() {
@@ -98,7 +118,7 @@ func (p *TenantPlugin) setTenantFieldValue(val reflect.Value, tenantID model.ID)
 	}
 
 	// 查找并设置 TenantID 字段
-	if field := val.FieldByName("TenantID"); field.IsValid() && field.CanSet() {
+	if field := val.FieldByName(model.FieldTenantID); field.IsValid() && field.CanSet() && (force || field.IsZero()) {
 		// 设置字段值
 		if field.Kind() == reflect.Int64 || field.Type().ConvertibleTo(reflect.TypeOf(tenantID)) {
 			field.Set(reflect.ValueOf(tenantID).Convert(field.Type()))
@@ -107,28 +127,47 @@ func (p *TenantPlugin) setTenantFieldValue(val reflect.Value, tenantID model.ID)
 }
 
 func (p *TenantPlugin) filter(db *gorm.DB) {
-
-	if db.Statement.Schema != nil {
-		// 检查是否跳过租户过滤
-		if skip, ok := db.Get("skip_tenant_filter"); ok && skip.(bool) {
-			return
+	// Raw 构建的语句已经生成 SQL，追加条件无效，按原生 SQL 处理
+	if db.Statement.SQL.Len() > 0 {
+		p.raw(db)
+		return
+	}
+	if !p.tenantScoped(db) {
+		return
+	}
+	tenantID, filter, err := TenantScope(db.Statement.Context)
+	if err != nil {
+		if p.Strict {
+			_ = db.AddError(err)
 		}
+		return
+	}
+	if filter {
+		// 带上表名，避免 Joins 时 tenant_id 列歧义
+		db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "tenant_id"}, Value: tenantID})
+	}
+}
+
+// tableNamePattern 从原生 SQL 中提取表名
+var tableNamePattern = regexp.MustCompile("(?i)\\b(?:from|join|update|into)\\s+`?(\\w+)`?")
 
-		value := db.Statement.Context.Value("user")
-
-		// 查找模型中是否存在 TenantID 字段
-		if field := db.Statement.Schema.LookUpField("TenantID"); field != nil {
-			// 从 Context 获取租户 ID
-			if value != nil {
-				user := value.(*scope.UserContext)
-				//超级用户可以看所有数据
-				if !user.IsSuper {
-					// 只有当查询条件中没有 tenant_id 时才添加
-					// 注意：这只是一个简单的检查，复杂的 SQL 可能无法覆盖
-					// 更好的方式是在 Context 中设置一个标志位来跳过租户过滤，或者检查 db.Statement.Vars
-					db.Where("tenant_id = ?", user.TenantID)
-				}
-			}
+// raw 原生 SQL 涉及租户隔离的表时，严格模式下必须有租户上下文或者显式跳过隔离
+// 有租户上下文时不会自动加条件，需要在 SQL 中自行带上 tenant_id
+func (p *TenantPlugin) raw(db *gorm.DB) {
+	if !p.Strict {
+		return
+	}
+	if _, _, err := TenantScope(db.Statement.Context); err == nil {
+		return
+	}
+	if db.Statement.Schema != nil && p.tenantScoped(db) {
+		_ = db.AddError(ErrMissingTenant)
+		return
+	}
+	for _, m := range tableNamePattern.FindAllStringSubmatch(db.Statement.SQL.String(), -1) {
+		if _, ok := p.tables.Load(m[1]); ok {
+			_ = db.AddError(ErrMissingTenant)
+			return
 		}
 	}
 }
@@ -0,0 +1,92 @@
+// Package dbtest 模块测试使用的内存数据库和测试数据
+// 每个模块的 TestMain 调用 Open 迁移自己用到的模型，测试数据由模块自己创建
+package dbtest
+
+import (
+	"context"
+	"seedgo/internal/db"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+	"seedgo/pkg"
+	"seedgo/pkg/cache"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Password 测试用户的密码
+const Password = "123456"
+
+// Open 打开开启严格租户隔离的内存数据库，设置 global.DB、global.Config、global.Cache 并迁移 models
+func Open(models ...any) *gorm.DB {
+	gdb, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		panic(err)
+	}
+	// 内存数据库每个连接都是独立的库
+	sqlDB, _ := gdb.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.Setup(gdb, true); err != nil {
+		panic(err)
+	}
+	global.DB = gdb
+	global.Config = &global.Configuration{JWT: global.JWTConfig{Secret: "test", Expire: 3600}}
+	global.Cache = cache.Use(cache.NewMemoryCache())
+
+	if err := Seed().AutoMigrate(models...); err != nil {
+		panic(err)
+	}
+	return gdb
+}
+
+// Seed 跳过租户隔离的连接，用于写入和检查测试数据
+func Seed() *gorm.DB {
+	return global.DB.WithContext(db.WithoutTenant(context.Background(), "seed test data"))
+}
+
+// Tenant 创建启用的租户
+func Tenant(name string) *model.Tenant {
+	t := &model.Tenant{Name: name, Status: 1}
+	must(Seed().Create(t).Error)
+	return t
+}
+
+// User 在租户下创建用户，密码为 Password
+func User(tenantID model.ID, username string) *model.User {
+	hash, err := pkg.HashPassword(Password)
+	must(err)
+	u := &model.User{Username: username, PasswordHash: hash}
+	u.TenantID = tenantID
+	must(Seed().Create(u).Error)
+	return u
+}
+
+// Super 创建超级用户
+func Super(tenantID model.ID, username string) *model.User {
+	u := User(tenantID, username)
+	isSuper := true
+	must(Seed().Model(u).Update("is_super", &isSuper).Error)
+	u.IsSuper = &isSuper
+	return u
+}
+
+// UserCtx 模拟登录用户的请求上下文
+func UserCtx(u *model.User) context.Context {
+	return context.WithValue(context.Background(), "user", &scope.UserContext{
+		ID:       u.ID,
+		Username: u.Username,
+		TenantID: u.TenantID,
+		IsSuper:  u.IsSuper != nil && *u.IsSuper,
+	})
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,279 @@
+package db_test
+
+import (
+	"context"
+	"os"
+	"seedgo/internal/db"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/form"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/log"
+	"seedgo/internal/modules/perms"
+	"seedgo/internal/modules/policy"
+	"seedgo/internal/modules/report"
+	"seedgo/internal/modules/role"
+	"seedgo/internal/modules/user"
+	"seedgo/internal/scope"
+	"seedgo/pkg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// 两个租户的测试数据
+var (
+	tenantA, tenantB  model.ID = 1, 2
+	alice, bob, admin *model.User
+	roleA, roleB      *model.Role
+)
+
+func TestMain(m *testing.M) {
+	dbtest.Open(
+		&model.Tenant{}, &model.User{}, &model.Role{}, &model.Permission{},
+		&model.RolePermission{}, &model.UserRole{}, &model.Policy{},
+		&model.OperationLog{}, &model.AccessSnapshot{},
+	)
+	seed(dbtest.Seed())
+	os.Exit(m.Run())
+}
+
+func seed(tx *gorm.DB) {
+	tx.Create(&model.Tenant{BaseModel: model.BaseModel{ID: tenantA}, Name: "A", Status: 1})
+	tx.Create(&model.Tenant{BaseModel: model.BaseModel{ID: tenantB}, Name: "B", Status: 1})
+
+	page := model.Permission{Name: "用户管理", PermissionUrls: "/system/users", PermissionCode: "system:user"}
+	tx.Create(&page)
+	tx.Create(&model.Permission{ParentID: &page.ID, Name: "删除", PermissionCode: "system:user:delete"})
+
+	hash, _ := pkg.HashPassword("123456")
+	isSuper := true
+	newUser := func(tenantID model.ID, name string) *model.User {
+		u := &model.User{Username: name, PasswordHash: hash}
+		u.TenantID = tenantID
+		tx.Create(u)
+		return u
+	}
+	alice = newUser(tenantA, "alice")
+	bob = newUser(tenantB, "bob")
+	admin = newUser(tenantA, "admin")
+	tx.Model(admin).Update("is_super", &isSuper)
+
+	newRole := func(tenantID model.ID, name string) *model.Role {
+		r := &model.Role{Name: name}
+		r.TenantID = tenantID
+		tx.Omit("Permissions").Create(r)
+		tx.Create(&model.RolePermission{RoleID: r.ID, PermissionID: page.ID, Effect: model.EffectAllow})
+		return r
+	}
+	roleA = newRole(tenantA, "role-a")
+	roleB = newRole(tenantB, "role-b")
+	tx.Create(&model.UserRole{UserID: alice.ID, RoleID: roleA.ID})
+	tx.Create(&model.UserRole{UserID: bob.ID, RoleID: roleB.ID})
+
+	for _, t := range []model.ID{tenantA, tenantB} {
+		p := &model.Policy{Name: "p", Action: "order:approve", Effect: model.EffectAllow, Expression: "true", Status: 1}
+		p.TenantID = t
+		tx.Create(p)
+		l := &model.OperationLog{Method: "GET", Path: "/api/ping"}
+		l.TenantID = t
+		tx.Create(l)
+	}
+}
+
+func TestStrictModeRequiresTenant(t *testing.T) {
+	bg := context.Background()
+	var users []model.User
+	var count int64
+
+	assert.ErrorIs(t, global.DB.WithContext(bg).Find(&users).Error, db.ErrMissingTenant)
+	assert.ErrorIs(t, global.DB.WithContext(bg).Model(&model.Role{}).Count(&count).Error, db.ErrMissingTenant)
+	assert.ErrorIs(t, global.DB.WithContext(bg).Model(&model.Policy{}).Where("id = 1").Update("status", 0).Error, db.ErrMissingTenant)
+	assert.ErrorIs(t, global.DB.WithContext(bg).Delete(&model.OperationLog{}, 1).Error, db.ErrMissingTenant)
+	assert.ErrorIs(t, global.DB.WithContext(bg).Create(&model.Role{Name: "x"}).Error, db.ErrMissingTenant)
+
+	// 没有模型的查询
+	var rows []map[string]any
+	assert.ErrorIs(t, global.DB.WithContext(bg).Table("user").Find(&rows).Error, db.ErrMissingTenant)
+	assert.ErrorIs(t, global.DB.WithContext(bg).Raw("SELECT * FROM `user`").Scan(&rows).Error, db.ErrMissingTenant)
+	assert.ErrorIs(t, global.DB.WithContext(bg).Exec("UPDATE user SET status = 1").Error, db.ErrMissingTenant)
+
+	// 不涉及租户表的查询不受影响
+	var perms []model.Permission
+	assert.NoError(t, global.DB.WithContext(bg).Find(&perms).Error)
+	assert.NoError(t, global.DB.WithContext(bg).Raw("SELECT * FROM user_role").Scan(&rows).Error)
+
+	// 显式跳过隔离
+	ctx := db.WithoutTenant(bg, "test bypass")
+	assert.NoError(t, global.DB.WithContext(ctx).Find(&users).Error)
+	assert.Len(t, users, 3)
+}
+
+func TestQueriesAreScopedToTenant(t *testing.T) {
+	ctx := dbtest.UserCtx(alice)
+
+	// Find、Scan、Table 都按租户过滤
+	var users []model.User
+	require.NoError(t, global.DB.WithContext(ctx).Find(&users).Error)
+	assert.Len(t, users, 2)
+	var ids []model.ID
+	require.NoError(t, global.DB.WithContext(ctx).Model(&model.User{}).Select("id").Scan(&ids).Error)
+	assert.NotContains(t, ids, bob.ID)
+	var rows []map[string]any
+	require.NoError(t, global.DB.WithContext(ctx).Table("user").Find(&rows).Error)
+	assert.Len(t, rows, 2)
+
+	// 后台任务指定租户
+	var count int64
+	require.NoError(t, global.DB.WithContext(db.WithTenant(context.Background(), tenantB)).Model(&model.User{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// 超级用户不过滤
+	require.NoError(t, global.DB.WithContext(dbtest.UserCtx(admin)).Model(&model.User{}).Count(&count).Error)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestUserModuleIsolation(t *testing.T) {
+	svc := user.GetService()
+	ctx := dbtest.UserCtx(alice)
+
+	_, err := svc.Get(ctx, bob.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	list, err := svc.List(ctx)
+	require.NoError(t, err)
+	for _, u := range list {
+		assert.Equal(t, tenantA, u.TenantID)
+	}
+
+	// 不能修改其他租户的用户，也不能修改其角色
+	roleIDs := []model.ID{roleA.ID}
+	err = svc.Update(ctx, &model.User{BaseTenantModel: model.BaseTenantModel{BaseModel: model.BaseModel{ID: bob.ID}}, Username: "hacked", RoleIds: &roleIDs})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// 不能删除其他租户的用户
+	require.NoError(t, svc.Delete(ctx, bob.ID))
+	_, err = svc.Get(dbtest.UserCtx(bob), bob.ID)
+	assert.NoError(t, err)
+
+	// 新建用户自动归属当前租户
+	created := &model.User{Username: "carol", PasswordHash: "x"}
+	created.TenantID = tenantB
+	require.NoError(t, svc.Create(ctx, created))
+	assert.Equal(t, tenantA, created.TenantID)
+
+	profile, err := svc.GetProfile(dbtest.UserCtx(bob), bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", profile.Username)
+}
+
+func TestSuperActingTenant(t *testing.T) {
+	svc := role.Instance()
+	acting := context.WithValue(context.Background(), "user", &scope.UserContext{ID: admin.ID, TenantID: tenantA, IsSuper: true, ActingTenantID: tenantB})
+
+	// 选择租户后只能看到该租户的数据
+	list, err := svc.List(acting)
+	require.NoError(t, err)
+	require.NotEmpty(t, list)
+	for _, r := range list {
+		assert.Equal(t, tenantB, r.TenantID)
+	}
+	_, err = svc.Get(acting, roleA.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// 创建时没有指定租户的数据归属选择的租户，指定了租户的保持不变
+	created := &model.Role{Name: "acting-b"}
+	require.NoError(t, svc.Create(acting, created))
+	assert.Equal(t, tenantB, created.TenantID)
+	explicit := &model.User{Username: "acting-a", PasswordHash: "x"}
+	explicit.TenantID = tenantA
+	require.NoError(t, global.DB.WithContext(acting).Create(explicit).Error)
+	assert.Equal(t, tenantA, explicit.TenantID)
+
+	// 没有选择租户时不过滤
+	all, err := svc.List(dbtest.UserCtx(admin))
+	require.NoError(t, err)
+	assert.Greater(t, len(all), len(list))
+}
+
+func TestLoginWithoutUserContext(t *testing.T) {
+	svc := user.GetService()
+	vo, err := svc.Login(context.Background(), form.LoginDTO{Username: "bob", Password: "123456"})
+	require.NoError(t, err)
+	assert.Equal(t, tenantB, vo.User.TenantID)
+
+	// 识别出租户时只在该租户内查找
+	ctx := context.WithValue(context.Background(), "tenant", &scope.TenantContext{ID: tenantA})
+	_, err = svc.Login(ctx, form.LoginDTO{Username: "bob", Password: "123456"})
+	assert.Error(t, err)
+}
+
+func TestRoleModuleIsolation(t *testing.T) {
+	svc := role.Instance()
+	ctx := dbtest.UserCtx(alice)
+
+	_, err := svc.Get(ctx, roleB.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// 不能替换其他租户角色的授权
+	ids := []model.ID{}
+	err = svc.Update(ctx, &model.Role{BaseTenantModel: model.BaseTenantModel{BaseModel: model.BaseModel{ID: roleB.ID}}, Name: "x", PermissionIds: &ids})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	var grants int64
+	global.DB.Model(&model.RolePermission{}).Where("role_id = ?", roleB.ID).Count(&grants)
+	assert.Equal(t, int64(1), grants)
+}
+
+func TestPermissionModuleIsolation(t *testing.T) {
+	// 权限计算在没有请求上下文时也能按用户租户执行
+	userPerms, err := perms.GetService().GetUserPerms(&scope.UserContext{ID: bob.ID, TenantID: tenantB})
+	require.NoError(t, err)
+	assert.Len(t, userPerms.Tree, 1)
+
+	// 角色属于其他租户时不生效
+	roles, _, err := perms.GetService().GetActiveRoles(db.WithTenant(context.Background(), tenantA), bob.ID)
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+
+	require.NoError(t, perms.GetService().InvalidateRoles(roleA.ID, roleB.ID))
+}
+
+func TestPolicyModuleIsolation(t *testing.T) {
+	svc := policy.GetService()
+	list, err := svc.List(dbtest.UserCtx(alice))
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, tenantA, list[0].TenantID)
+
+	decision, err := svc.Evaluate(context.Background(), "order:approve", policy.Input{
+		User: &scope.UserContext{ID: bob.ID, TenantID: tenantB},
+	})
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+}
+
+func TestLogModuleIsolation(t *testing.T) {
+	list, err := log.GetService().List(dbtest.UserCtx(bob))
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, tenantB, list[0].TenantID)
+
+	_, err = log.GetService().List(context.Background())
+	assert.ErrorIs(t, err, db.ErrMissingTenant)
+}
+
+func TestReportModuleIsolation(t *testing.T) {
+	svc := report.GetService()
+
+	// 普通用户指定其他租户也查不到数据
+	entries, err := svc.AccessMatrix(dbtest.UserCtx(alice), tenantB, report.AccessFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	entries, err = svc.AccessMatrix(dbtest.UserCtx(bob), tenantB, report.AccessFilter{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "bob", entries[0].Username)
+}
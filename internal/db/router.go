@@ -0,0 +1,321 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"seedgo/internal/model"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// ErrCrossDatabase 事务中访问了其他数据库的租户数据
+var ErrCrossDatabase = errors.New("transaction cannot access tenant data in another database")
+
+// TenantJoinModels 租户数据的关联表，没有 tenant_id 字段，库级隔离时和租户数据放在一起
+var TenantJoinModels = []any{
+	&model.UserRole{},
+	&model.RolePermission{},
+}
+
+// Router 库级隔离的连接路由
+// 登记在 tenant_database 表中的租户，租户数据的读写走自己的连接池，连接在第一次使用时打开；
+// 其他租户、没有租户上下文的语句和共享表（tenant、permission 等）走控制库
+type Router struct {
+	control   *gorm.DB
+	pool      gorm.ConnPool
+	dialector func(dsn string) gorm.Dialector
+
+	// tables 放在租户库中的表
+	tables map[string]bool
+
+	mu      sync.Mutex
+	configs map[model.ID]model.TenantDatabase
+	dbs     map[model.ID]*gorm.DB
+}
+
+var router *Router
+
+// GetRouter 获取连接路由，没有开启库级隔离时返回 nil
+func GetRouter() *Router {
+	return router
+}
+
+// UseRouter 开启库级隔离，dialector 用于打开租户数据库
+func UseRouter(gdb *gorm.DB, dialector func(dsn string) gorm.Dialector) (*Router, error) {
+	r := &Router{
+		control:   gdb,
+		pool:      gdb.ConnPool,
+		dialector: dialector,
+		tables:    map[string]bool{},
+		dbs:       map[model.ID]*gorm.DB{},
+	}
+	for _, m := range append(append([]any{}, TenantModels...), TenantJoinModels...) {
+		s, err := schema.Parse(m, &sync.Map{}, gdb.NamingStrategy)
+		if err != nil {
+			return nil, err
+		}
+		r.tables[s.Table] = true
+	}
+	if err := r.Reload(); err != nil {
+		// 首次迁移前还没有 tenant_database 表
+		log.Printf("[tenant] load tenant databases failed, all tenants use the control database: %v", err)
+	}
+
+	gdb.ConnPool = &routedPool{router: r}
+	gdb.Statement.ConnPool = gdb.ConnPool
+	gdb.Callback().Query().Before("gorm:query").Register("tenant:route", r.route)
+	gdb.Callback().Row().Before("gorm:row").Register("tenant:route", r.route)
+	gdb.Callback().Raw().Before("gorm:raw").Register("tenant:route", r.route)
+	gdb.Callback().Create().Before("gorm:create").Register("tenant:route", r.route)
+	gdb.Callback().Update().Before("gorm:update").Register("tenant:route", r.route)
+	gdb.Callback().Delete().Before("gorm:delete").Register("tenant:route", r.route)
+
+	router = r
+	return r, nil
+}
+
+// Reload 重新读取 tenant_database，DSN 变化或者取消登记的租户关闭旧连接
+func (r *Router) Reload() error {
+	var rows []model.TenantDatabase
+	ctx := WithoutTenant(context.Background(), "load tenant databases")
+	if err := r.control.Session(&gorm.Session{NewDB: true}).WithContext(ctx).Find(&rows).Error; err != nil {
+		return err
+	}
+	configs := make(map[model.ID]model.TenantDatabase, len(rows))
+	for _, row := range rows {
+		configs[row.TenantID] = row
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, gdb := range r.dbs {
+		if config, ok := configs[id]; !ok || config.Dsn != r.configs[id].Dsn {
+			closeDB(gdb)
+			delete(r.dbs, id)
+		}
+	}
+	r.configs = configs
+	return nil
+}
+
+// Tenants 登记了独立数据库的租户
+func (r *Router) Tenants() []model.ID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]model.ID, 0, len(r.configs))
+	for id := range r.configs {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Open 获取租户数据库，没有登记独立数据库时返回 nil
+// 返回的 *gorm.DB 不经过路由和租户插件，只用于迁移、导入导出这类直接操作租户库的场景
+func (r *Router) Open(tenantID model.ID) (*gorm.DB, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if gdb, ok := r.dbs[tenantID]; ok {
+		return gdb, nil
+	}
+	config, ok := r.configs[tenantID]
+	if !ok {
+		return nil, nil
+	}
+
+	gdb, err := gorm.Open(r.dialector(config.Dsn), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Warn),
+		NamingStrategy: r.control.NamingStrategy,
+		// 共享表在控制库中，租户库不能建立指向它们的外键
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open database of tenant %d: %w", tenantID, err)
+	}
+	if err := Setup(gdb, false); err != nil {
+		return nil, err
+	}
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Hour)
+
+	r.dbs[tenantID] = gdb
+	log.Printf("[tenant] opened database of tenant %d", tenantID)
+	return gdb, nil
+}
+
+// Close 关闭所有租户数据库连接
+func (r *Router) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, gdb := range r.dbs {
+		closeDB(gdb)
+		delete(r.dbs, id)
+	}
+}
+
+func closeDB(gdb *gorm.DB) {
+	if sqlDB, err := gdb.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+}
+
+// poolFor 租户对应的连接池，没有独立数据库时返回控制库
+func (r *Router) poolFor(tenantID model.ID) (gorm.ConnPool, error) {
+	if tenantID == 0 {
+		return r.pool, nil
+	}
+	gdb, err := r.Open(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if gdb == nil {
+		return r.pool, nil
+	}
+	return gdb.ConnPool, nil
+}
+
+// contextPool 按 context 中的租户选择连接池，跳过隔离和超级用户走控制库
+func (r *Router) contextPool(ctx context.Context) (gorm.ConnPool, error) {
+	tenantID, _, _ := TenantScope(ctx)
+	return r.poolFor(tenantID)
+}
+
+// tenantStatement 判断语句是否操作放在租户库中的表，原生 SQL 从语句中提取表名
+func (r *Router) tenantStatement(db *gorm.DB) bool {
+	if db.Statement.Table != "" {
+		return r.tables[db.Statement.Table]
+	}
+	for _, m := range tableNamePattern.FindAllStringSubmatch(db.Statement.SQL.String(), -1) {
+		if r.tables[m[1]] {
+			return true
+		}
+	}
+	return false
+}
+
+// route 为语句选择连接：租户表按租户路由，共享表走控制库
+// 事务已经绑定了数据库：租户库的事务中访问共享表时在事务外读写控制库，
+// 识别不出表的原生 SQL（如 SAVEPOINT）留在事务中，访问其他库的租户数据报错
+func (r *Router) route(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	tenant := r.tenantStatement(db)
+
+	if tx, ok := db.Statement.ConnPool.(*routedTx); ok {
+		if !tenant {
+			if tx.pool != r.pool && db.Statement.Table != "" {
+				db.Statement.ConnPool = r.pool
+			}
+			return
+		}
+		pool, err := r.contextPool(db.Statement.Context)
+		if err != nil {
+			_ = db.AddError(err)
+			return
+		}
+		if pool != tx.pool {
+			_ = db.AddError(ErrCrossDatabase)
+		}
+		return
+	}
+	// 不是通过路由开启的事务不处理
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+
+	target := r.pool
+	if tenant {
+		pool, err := r.contextPool(db.Statement.Context)
+		if err != nil {
+			_ = db.AddError(err)
+			return
+		}
+		target = pool
+	}
+	// Preload 等子查询继承了上级语句的连接，同样需要重新选择
+	db.Statement.ConnPool = target
+}
+
+// routedPool 替换 gorm.DB 的 ConnPool，没有经过回调的语句和事务按 context 路由
+type routedPool struct {
+	router *Router
+}
+
+func (p *routedPool) pool(ctx context.Context) (gorm.ConnPool, error) {
+	return p.router.contextPool(ctx)
+}
+
+func (p *routedPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	pool, err := p.pool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pool.PrepareContext(ctx, query)
+}
+
+func (p *routedPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	pool, err := p.pool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pool.ExecContext(ctx, query, args...)
+}
+
+func (p *routedPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	pool, err := p.pool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pool.QueryContext(ctx, query, args...)
+}
+
+func (p *routedPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	pool, err := p.pool(ctx)
+	if err != nil {
+		pool = p.router.pool
+	}
+	return pool.QueryRowContext(ctx, query, args...)
+}
+
+// BeginTx 事务开在 context 中租户所在的数据库
+func (p *routedPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	pool, err := p.pool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	beginner, ok := pool.(gorm.TxBeginner)
+	if !ok {
+		return nil, gorm.ErrInvalidTransaction
+	}
+	tx, err := beginner.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &routedTx{Tx: tx, pool: pool}, nil
+}
+
+// GetDBConn 返回控制库连接，供 gorm.DB.DB() 使用
+func (p *routedPool) GetDBConn() (*sql.DB, error) {
+	if sqlDB, ok := p.router.pool.(*sql.DB); ok {
+		return sqlDB, nil
+	}
+	return nil, gorm.ErrInvalidDB
+}
+
+// routedTx 记录事务所在的连接池
+type routedTx struct {
+	*sql.Tx
+	pool gorm.ConnPool
+}
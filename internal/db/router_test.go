@@ -0,0 +1,79 @@
+package db_test
+
+import (
+	"context"
+	"path/filepath"
+	"seedgo/internal/db"
+	"seedgo/internal/model"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestRouterPerTenantDatabase(t *testing.T) {
+	dir := t.TempDir()
+	open := func(dsn string) gorm.Dialector { return sqlite.Open(dsn) }
+	control, err := gorm.Open(open(filepath.Join(dir, "control.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.Setup(control, true))
+
+	seedCtx := db.WithoutTenant(context.Background(), "seed router test")
+	require.NoError(t, control.WithContext(seedCtx).AutoMigrate(
+		&model.Tenant{}, &model.User{}, &model.Role{}, &model.Permission{},
+		&model.RolePermission{}, &model.UserRole{}, &model.TenantDatabase{},
+	))
+	control.WithContext(seedCtx).Create(&model.Tenant{BaseModel: model.BaseModel{ID: 1}, Name: "shared", Status: 1})
+	control.WithContext(seedCtx).Create(&model.Tenant{BaseModel: model.BaseModel{ID: 2}, Name: "dedicated", Status: 1})
+	control.WithContext(seedCtx).Create(&model.TenantDatabase{TenantID: 2, Dsn: filepath.Join(dir, "tenant2.db"), MaxOpenConns: 1, MaxIdleConns: 1})
+
+	router, err := db.UseRouter(control, open)
+	require.NoError(t, err)
+	defer router.Close()
+	dedicated, err := router.Open(2)
+	require.NoError(t, err)
+	require.NotNil(t, dedicated)
+	require.NoError(t, dedicated.AutoMigrate(append(append([]any{}, db.TenantModels...), db.TenantJoinModels...)...))
+
+	ctx1 := db.WithTenant(context.Background(), 1)
+	ctx2 := db.WithTenant(context.Background(), 2)
+	require.NoError(t, control.WithContext(ctx1).Create(&model.User{Username: "u1", PasswordHash: "x"}).Error)
+	require.NoError(t, control.WithContext(ctx2).Create(&model.User{Username: "u2", PasswordHash: "x"}).Error)
+
+	// 独立数据库的租户写入自己的库，其他租户留在控制库
+	var users []model.User
+	require.NoError(t, dedicated.Find(&users).Error)
+	require.Len(t, users, 1)
+	assert.Equal(t, "u2", users[0].Username)
+	require.NoError(t, control.WithContext(seedCtx).Find(&users).Error)
+	require.Len(t, users, 1)
+	assert.Equal(t, "u1", users[0].Username)
+	require.NoError(t, control.WithContext(ctx2).Find(&users).Error)
+	require.Len(t, users, 1)
+	assert.Equal(t, "u2", users[0].Username)
+
+	// 共享表始终在控制库
+	var tenant model.Tenant
+	assert.NoError(t, control.WithContext(ctx2).Preload("Users").First(&tenant, 2).Error)
+
+	// 事务开在租户库，事务中可以读共享表
+	err = control.WithContext(ctx2).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Permissions").Create(&model.Role{Name: "r2"}).Error; err != nil {
+			return err
+		}
+		return tx.First(&model.Tenant{}, 2).Error
+	})
+	assert.NoError(t, err)
+	var count int64
+	dedicated.Model(&model.Role{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// 事务中不能访问其他库的租户数据
+	err = control.WithContext(ctx2).Transaction(func(tx *gorm.DB) error {
+		return tx.WithContext(ctx1).Find(&users).Error
+	})
+	assert.ErrorIs(t, err, db.ErrCrossDatabase)
+}
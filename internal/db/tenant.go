@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log"
+	"runtime"
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+)
+
+// ErrMissingTenant 严格模式下，租户隔离的模型在没有租户上下文时执行查询
+var ErrMissingTenant = errors.New("tenant context required for tenant-scoped query, use db.WithTenant or db.WithoutTenant")
+
+// LogBypass 为 true 时 WithoutTenant 把调用位置和原因记录到日志，默认开启，由配置 tenant.log_bypass 控制
+var LogBypass = true
+
+type withoutTenantKey struct{}
+
+type tenantKey struct{}
+
+// WithoutTenant 返回跳过租户隔离的 context，必须说明原因，调用位置和原因默认记录到日志
+// 用于跨租户的系统逻辑，如登录时按用户名查找用户、权限缓存失效、数据迁移
+func WithoutTenant(ctx context.Context, reason string) context.Context {
+	if reason == "" {
+		panic("db.WithoutTenant requires a reason")
+	}
+	if LogBypass {
+		if _, file, line, ok := runtime.Caller(1); ok {
+			log.Printf("[tenant] bypass tenant isolation at %s:%d: %s", file, line, reason)
+		} else {
+			log.Printf("[tenant] bypass tenant isolation: %s", reason)
+		}
+	}
+	return context.WithValue(ctx, withoutTenantKey{}, reason)
+}
+
+// WithTenant 返回指定租户的 context，用于后台任务等没有登录用户的场景，优先于登录用户的租户
+func WithTenant(ctx context.Context, tenantID model.ID) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// BypassReason 获取跳过租户隔离的原因
+func BypassReason(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	reason, ok := ctx.Value(withoutTenantKey{}).(string)
+	return reason, ok
+}
+
+// actingTenant 超级用户选择了租户，创建时只填充没有指定租户的数据，超级用户仍然可以为其他租户创建数据
+func actingTenant(ctx context.Context) bool {
+	if ctx == nil || ctx.Value(tenantKey{}) != nil {
+		return false
+	}
+	user := scope.GetUserFromContext(ctx)
+	return user != nil && user.IsSuper && user.ActingTenantID != 0
+}
+
+// TenantScope 获取 context 中的租户
+// 返回值 filter 表示是否需要按租户过滤：跳过隔离和超级用户不过滤；
+// err 不为空表示没有任何租户上下文，是否报错由调用方（严格模式）决定
+func TenantScope(ctx context.Context) (tenantID model.ID, filter bool, err error) {
+	if ctx == nil {
+		return 0, false, ErrMissingTenant
+	}
+	if _, ok := BypassReason(ctx); ok {
+		return 0, false, nil
+	}
+	if id, ok := ctx.Value(tenantKey{}).(model.ID); ok {
+		return id, true, nil
+	}
+	if user := scope.GetUserFromContext(ctx); user != nil {
+		//超级用户可以看所有数据，选择了租户时只看该租户
+		if user.IsSuper {
+			if user.ActingTenantID != 0 {
+				return user.ActingTenantID, true, nil
+			}
+			return 0, false, nil
+		}
+		return user.TenantID, true, nil
+	}
+	return 0, false, ErrMissingTenant
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// WithTx 返回携带事务的 context，service 通过它取得连接时加入该事务
+// 用于把多次 service 调用放在一个事务中，如批量删除时逐个调用模块的 Delete
+func WithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext 获取 context 中的事务，没有时返回 nil
+func TxFromContext(ctx context.Context) *gorm.DB {
+	if ctx == nil {
+		return nil
+	}
+	tx, _ := ctx.Value(txKey{}).(*gorm.DB)
+	return tx
+}
+
+type afterCommitKey struct{}
+
+// afterCommit 事务提交后执行的操作
+type afterCommit struct {
+	fns []func() error
+}
+
+// WithAfterCommit 返回收集提交后操作的 context，事务提交后调用 run 执行收集到的操作，回滚时不调用
+// 用于在一个事务中多次调用 service 的场景，如批量操作：缓存失效推迟到提交之后，
+// 避免回滚后缓存已经失效，或者提交前其他请求读到旧数据重新写入缓存
+func WithAfterCommit(ctx context.Context) (_ context.Context, run func() error) {
+	hooks := &afterCommit{}
+	run = func() error {
+		var errs []error
+		for _, fn := range hooks.fns {
+			if err := fn(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+	return context.WithValue(ctx, afterCommitKey{}, hooks), run
+}
+
+// AfterCommit 在 WithAfterCommit 的事务提交后执行 fn，context 没有收集提交后操作时立即执行
+func AfterCommit(ctx context.Context, fn func() error) error {
+	if ctx != nil {
+		if hooks, ok := ctx.Value(afterCommitKey{}).(*afterCommit); ok {
+			hooks.fns = append(hooks.fns, fn)
+			return nil
+		}
+	}
+	return fn()
+}
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// activeRolesKey 查询设置，值为判断生效的时间
+const activeRolesKey = "seedgo:active_roles"
+
+// ActiveRoles 预加载 User.Roles、Role.Users 时只加载有效期内的角色分配，和权限计算一致
+// 预加载先查询关联表再查询角色，条件只能加在关联表的查询上，通过查询设置传递给预加载
+func ActiveRoles(tx *gorm.DB) *gorm.DB {
+	return tx.Set(activeRolesKey, time.Now())
+}
+
+// filterActiveRoles 查询 user_role 且设置了 ActiveRoles 时，只返回生效中的角色分配
+func filterActiveRoles(tx *gorm.DB) {
+	value, ok := tx.Get(activeRolesKey)
+	if !ok || tx.Statement.Table != "user_role" {
+		return
+	}
+	now := value.(time.Time)
+	tx.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Expr{SQL: "(? IS NULL OR ? <= ?)", Vars: []any{clause.Column{Table: clause.CurrentTable, Name: "valid_from"}, clause.Column{Table: clause.CurrentTable, Name: "valid_from"}, now}},
+		clause.Expr{SQL: "(? IS NULL OR ? > ?)", Vars: []any{clause.Column{Table: clause.CurrentTable, Name: "valid_until"}, clause.Column{Table: clause.CurrentTable, Name: "valid_until"}, now}},
+	}})
+}
@@ -22,3 +22,20 @@ type ChangePasswordDTO struct {
 	OldPassword string `json:"oldPassword" binding:"required"`
 	NewPassword string `json:"newPassword" binding:"required"`
 }
+
+// TransferOwnershipDTO 转让租户主账号，password 为当前操作用户的密码
+type TransferOwnershipDTO struct {
+	UserID   model.ID `json:"userId" binding:"required"`
+	Password string   `json:"password" binding:"required"`
+}
+
+// BatchDeleteDTO 批量删除
+type BatchDeleteDTO struct {
+	IDs []model.ID `json:"ids" binding:"required"`
+}
+
+// BatchUpdateDTO 批量更新，fields 的键为模型的 JSON 字段名，如 {"status": 0}
+type BatchUpdateDTO struct {
+	IDs    []model.ID     `json:"ids" binding:"required"`
+	Fields map[string]any `json:"fields" binding:"required"`
+}
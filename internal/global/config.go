@@ -1,5 +1,7 @@
 package global
 
+import "time"
+
 type ServerConfig struct {
 	Port int    `mapstructure:"port"`
 	Mode string `mapstructure:"mode"`
@@ -7,6 +9,8 @@ type ServerConfig struct {
 
 type DatabaseConfig struct {
 	Dsn string `mapstructure:"dsn"`
+	// PerTenant 库级隔离模式，登记在 tenant_database 表中的租户使用独立数据库
+	PerTenant bool `mapstructure:"per_tenant"`
 }
 
 type JWTConfig struct {
@@ -20,6 +24,46 @@ type AuthConfig struct {
 
 type PermissionConfig struct {
 	ExcludePaths []string `mapstructure:"exclude_paths"`
+	// PlatformCodes 平台权限编码，包括子权限，只有超级用户拥有，租户主账号拥有除此之外的所有权限
+	PlatformCodes []string `mapstructure:"platform_codes"`
+}
+
+// TenantResolverConfig 租户识别配置
+type TenantResolverConfig struct {
+	// Sources 识别来源，按顺序匹配：header、subdomain、path，为空不识别
+	Sources []string `mapstructure:"sources"`
+	// Header 请求头名称，默认 X-Tenant-ID
+	Header string `mapstructure:"header"`
+	// Domain 根域名，如 example.com，acme.example.com 识别为 acme
+	Domain string `mapstructure:"domain"`
+	// PathPrefix 路径前缀，默认 /t/，/t/acme/api/... 识别为 acme 并按 /api/... 路由
+	PathPrefix string `mapstructure:"path_prefix"`
+}
+
+type TenantConfig struct {
+	// Strict 严格隔离模式，租户隔离的表在没有租户上下文时查询报错
+	Strict bool `mapstructure:"strict"`
+	// LogBypass 记录 db.WithoutTenant 的调用位置和原因，用于审计跨租户的访问，默认开启
+	LogBypass bool `mapstructure:"log_bypass"`
+	// GracePeriod 租户到期后的宽限期，如 72h，宽限期内仍可以登录和访问
+	GracePeriod time.Duration `mapstructure:"grace_period"`
+	// Retention 租户删除后的保留期，如 720h，保留期内可以恢复，之后由后台任务清除数据
+	Retention time.Duration `mapstructure:"retention"`
+	// PurgeInterval 后台清除任务的检查间隔，为 0 不启动
+	PurgeInterval time.Duration        `mapstructure:"purge_interval"`
+	Resolver      TenantResolverConfig `mapstructure:"resolver"`
+}
+
+// ExportConfig 列表导出配置
+type ExportConfig struct {
+	// Dir 后台导出文件的目录，默认 storage/exports
+	Dir string `mapstructure:"dir"`
+	// AsyncThreshold 超过该记录数时在后台导出，默认 5000
+	AsyncThreshold int64 `mapstructure:"async_threshold"`
+	// BatchSize 每次从数据库读取的记录数，默认 500
+	BatchSize int `mapstructure:"batch_size"`
+	// Retention 后台导出文件的保留时间，如 24h，过期后删除任务和文件，为 0 不删除
+	Retention time.Duration `mapstructure:"retention"`
 }
 
 type Configuration struct {
@@ -28,4 +72,6 @@ type Configuration struct {
 	JWT        JWTConfig        `mapstructure:"jwt"`
 	Auth       AuthConfig       `mapstructure:"auth"`
 	Permission PermissionConfig `mapstructure:"permission"`
+	Tenant     TenantConfig     `mapstructure:"tenant"`
+	Export     ExportConfig     `mapstructure:"export"`
 }
@@ -2,6 +2,7 @@ package global
 
 import (
 	"log"
+	"strings"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
@@ -13,6 +14,12 @@ func InitConfig(configPath string) {
 	v := viper.New()
 	v.SetConfigFile(configPath)
 	v.SetConfigType("yaml")
+	// 环境变量覆盖配置文件中的同名配置，如 SEEDGO_TENANT_STRICT=true
+	v.SetEnvPrefix("seedgo")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	// 跳过租户隔离默认记录日志
+	v.SetDefault("tenant.log_bypass", true)
 
 	if err := v.ReadInConfig(); err != nil {
 		log.Fatalf("Error reading config file: %s", err)
@@ -4,13 +4,21 @@ import (
 	"context"
 	"net/http"
 	global2 "seedgo/internal/global"
+	"seedgo/internal/modules/tenant"
 	"seedgo/internal/scope"
 	"seedgo/internal/shared"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// 超级用户选择租户上下文的请求头和查询参数，值为租户 ID 或编码
+const (
+	ScopeTenantHeader = "X-Scope-Tenant"
+	ScopeTenantQuery  = "scopeTenant"
+)
+
 // AuthMiddleware 简单的权限验证中间件示例
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -45,12 +53,54 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// 识别出的租户必须与 Token 中的租户一致，超级用户除外
+		if t := scope.GetCurrentTenant(c); t != nil && !claims.Super && t.ID != claims.TenantID {
+			scope.FailWithCode(c, http.StatusForbidden, "tenant mismatch")
+			c.Abort()
+			return
+		}
+
+		// 租户停用或过期后，已签发的 Token 也不能继续使用，超级用户除外
+		if !claims.Super {
+			t, err := tenant.GetService().CheckTenant(c.Request.Context(), claims.TenantID)
+			if err != nil {
+				code := tenant.StatusCode(err)
+				if code == 0 {
+					code = http.StatusUnauthorized
+				}
+				scope.FailWithCode(c, code, err.Error())
+				c.Abort()
+				return
+			}
+			// 宽限期内提示前端租户已到期
+			if tenant.InGracePeriod(t, time.Now()) {
+				c.Header("X-Tenant-Expires-At", t.ExpiresAt.Format(time.RFC3339))
+			}
+		}
+
 		userCtx := &scope.UserContext{
 			ID:       claims.UserID,
 			Username: claims.Username,
 			TenantID: claims.TenantID,
 			IsSuper:  claims.Super,
 		}
+
+		// 超级用户可以选择租户，数据按该租户过滤，其他用户忽略
+		if claims.Super {
+			key := c.GetHeader(ScopeTenantHeader)
+			if key == "" {
+				key = c.Query(ScopeTenantQuery)
+			}
+			if key != "" {
+				t, err := tenant.GetService().Find(c.Request.Context(), key)
+				if err != nil {
+					scope.FailWithCode(c, http.StatusNotFound, err.Error())
+					c.Abort()
+					return
+				}
+				userCtx.ActingTenantID = t.ID
+			}
+		}
 		ctx := context.WithValue(c.Request.Context(), "tenant_id", claims.TenantID)
 		ctx = context.WithValue(ctx, "userId", claims.UserID)
 		ctx = context.WithValue(ctx, "user", userCtx)
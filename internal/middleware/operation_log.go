@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"seedgo/internal/db"
 	"seedgo/internal/model"
 	"seedgo/internal/modules/log"
 	"seedgo/internal/scope"
@@ -46,13 +47,14 @@ func OperationLogMiddleware() gin.HandlerFunc {
 
 		var userID model.ID
 		var username string
-		var tenantID model.ID
+		var tenantID, actingTenantID model.ID
 
 		userCtx := scope.GetCurrentUser(c)
 		if userCtx != nil {
 			userID = userCtx.ID
 			username = userCtx.Username
 			tenantID = userCtx.TenantID
+			actingTenantID = userCtx.ActingTenantID
 		}
 
 		// Truncate body
@@ -67,22 +69,23 @@ func OperationLogMiddleware() gin.HandlerFunc {
 				BaseTenantModel: model.BaseTenantModel{
 					TenantModel: model.TenantModel{TenantID: tenantID},
 				},
-				UserID:       userID,
-				Username:     username,
-				Method:       method,
-				Path:         path,
-				Query:        query,
-				Body:         bodyStr,
-				IP:           clientIP,
-				UserAgent:    userAgent,
-				Status:       status,
-				Latency:      latency,
-				ErrorMessage: errMsg,
+				UserID:         userID,
+				Username:       username,
+				Method:         method,
+				Path:           path,
+				Query:          query,
+				Body:           bodyStr,
+				IP:             clientIP,
+				UserAgent:      userAgent,
+				Status:         status,
+				Latency:        latency,
+				ErrorMessage:   errMsg,
+				ActingTenantID: actingTenantID,
 			}
 			opLog.SetOperationTime()
 
-			// Use background context
-			_ = log.GetService().Create(context.Background(), opLog)
+			// 异步写入没有请求上下文，显式指定租户
+			_ = log.GetService().Create(db.WithTenant(context.Background(), tenantID), opLog)
 		}()
 	}
 }
@@ -50,14 +50,15 @@ func PermissionsMiddleware() gin.HandlerFunc {
 		}
 
 		service := perms.GetService()
-		tree, err := service.GetCacheTree(user)
+		userPerms, err := service.GetCachePerms(user)
 		if err != nil {
 			scope.Fail(c, err.Error())
 			c.Abort()
 			return
 		}
-		// 匹配权限树中的路径
-		if !hasPermission(tree, c.Request.Method, currentPath) {
+		// 先匹配拒绝规则（deny-overrides），再匹配权限树中的路径
+		if isDenied(userPerms.Denied, c.Request.Method, currentPath) ||
+			!hasPermission(userPerms.Tree, c.Request.Method, currentPath) {
 			scope.FailWithCode(c, http.StatusForbidden, "没有权限访问")
 			c.Abort()
 			return
@@ -75,16 +76,11 @@ func hasPermission(perms []*model.Permission, method, path string) bool {
 	// 遍历权限树，检查是否有匹配的路径
 	for _, p := range perms {
 		matched := false
-		// 1.如果path 包含p.Path 或者 p.Path 包含 urls的时候，就匹配到了
-		if p.Path != "" && strings.Contains(path, p.Path) {
-			matched = true
-		} else if p.PermissionUrls != "" {
-			urls := strings.Split(p.PermissionUrls, ",")
-			for _, u := range urls {
-				if strings.Contains(path, strings.TrimSpace(u)) {
-					matched = true
-					break
-				}
+		// 1.请求路径包含 PermissionUrls 中的某一项，就匹配到了
+		for _, u := range strings.Split(p.PermissionUrls, ",") {
+			if u = strings.TrimSpace(u); u != "" && strings.Contains(path, u) {
+				matched = true
+				break
 			}
 		}
 
@@ -95,22 +91,7 @@ func hasPermission(perms []*model.Permission, method, path string) bool {
 			}
 
 			// 如果是其他类型的方法POST、PUT、DELETE，在判断子项的code是否有:create,:update,:delete
-			var suffix string
-			switch method {
-			case "POST":
-				// 3. 例外处理：请求路径：/system/roles/batch-delete，默认为删除权限
-				if strings.HasSuffix(path, "/batch-delete") {
-					suffix = ":delete"
-				} else {
-					suffix = ":create"
-				}
-			case "PUT":
-				suffix = ":update"
-			case "DELETE":
-				suffix = ":delete"
-			}
-
-			if suffix != "" {
+			if suffix := methodSuffix(method, path); suffix != "" {
 				for _, child := range p.Children {
 					if strings.HasSuffix(child.PermissionCode, suffix) {
 						return true
@@ -128,3 +109,39 @@ func hasPermission(perms []*model.Permission, method, path string) bool {
 
 	return false
 }
+
+// isDenied 检查请求是否命中拒绝规则
+func isDenied(rules []perms.DenyRule, method, path string) bool {
+	suffix := methodSuffix(method, path)
+	for _, r := range rules {
+		if !strings.Contains(path, r.Path) {
+			continue
+		}
+		// 拒绝整个路径，或者拒绝的操作与当前方法一致
+		if r.Suffix == "" || r.Suffix == suffix {
+			return true
+		}
+	}
+	return false
+}
+
+// methodSuffix 请求方法对应的权限编码后缀，GET 返回空
+func methodSuffix(method, path string) string {
+	switch method {
+	case "POST":
+		// 例外处理：请求路径：/system/roles/batch-delete，默认为删除权限
+		if strings.HasSuffix(path, "/batch-delete") {
+			return ":delete"
+		}
+		// 批量更新为修改权限
+		if strings.HasSuffix(path, "/batch-update") {
+			return ":update"
+		}
+		return ":create"
+	case "PUT":
+		return ":update"
+	case "DELETE":
+		return ":delete"
+	}
+	return ""
+}
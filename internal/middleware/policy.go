@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"seedgo/internal/modules/policy"
+	"seedgo/internal/scope"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PolicyMiddleware 策略验证中间件，在路由上按动作执行 ABAC 策略
+// resource 用于从请求中提取资源属性，可以为 nil
+//
+//	g.POST("/orders/:id/approve", middleware.PolicyMiddleware("order:approve", nil), h.Approve)
+func PolicyMiddleware(action string, resource func(c *gin.Context) map[string]any) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var attrs map[string]any
+		if resource != nil {
+			attrs = resource(c)
+		}
+
+		decision, err := policy.GetService().Authorize(c, action, attrs)
+		if err != nil {
+			scope.Fail(c, err.Error())
+			c.Abort()
+			return
+		}
+		if !decision.Allowed {
+			scope.FailWithCode(c, http.StatusForbidden, "没有权限访问")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"seedgo/internal/scope"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SuperWrites 只允许超级用户修改，其他用户只能查询，用于套餐、开通模板等平台级数据
+// 需要在 AuthMiddleware 之后使用
+func SuperWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		if !scope.RequireSuper(c) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
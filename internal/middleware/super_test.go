@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"seedgo/internal/scope"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuperWrites(t *testing.T) {
+	request := func(method string, user *scope.UserContext) int {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			if user != nil {
+				c.Set("user", user)
+			}
+		})
+		g := r.Group("/templates", SuperWrites())
+		g.Handle(method, "", func(c *gin.Context) { scope.Ok(c) })
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/templates", nil))
+		var res struct {
+			Code int `json:"code"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		return res.Code
+	}
+
+	member := &scope.UserContext{ID: 2, TenantID: 1}
+	super := &scope.UserContext{ID: 1, TenantID: 1, IsSuper: true}
+	assert.Equal(t, scope.SuccessCode, request(http.MethodGet, member))
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+		assert.Equal(t, http.StatusForbidden, request(method, member))
+		assert.Equal(t, http.StatusForbidden, request(method, nil))
+		assert.Equal(t, scope.SuccessCode, request(method, super))
+	}
+}
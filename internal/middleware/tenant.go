@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"seedgo/internal/global"
+	"seedgo/internal/modules/tenant"
+	"seedgo/internal/scope"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	TenantSourceHeader    = "header"
+	TenantSourceSubdomain = "subdomain"
+	TenantSourcePath      = "path"
+)
+
+// tenantPathKey 路径前缀中识别出的租户，由 TenantPath 去掉前缀时写入请求上下文
+type tenantPathKey struct{}
+
+// TenantResolver 租户识别中间件，按配置从请求头、子域名或路径前缀识别租户
+// 识别到的租户必须存在且为启用状态（未停用、未过期），结果写入上下文，通过 scope.GetCurrentTenant 获取
+// 没有识别到租户时直接放行，登录后的请求由 AuthMiddleware 校验与 Token 中的租户是否一致
+func TenantResolver() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := global.Config.Tenant.Resolver
+		key, source := resolveTenantKey(c, cfg)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		t, err := tenant.GetService().Resolve(c.Request.Context(), key)
+		if err != nil {
+			if code := tenant.StatusCode(err); code != 0 {
+				scope.FailWithCode(c, code, err.Error())
+			} else if errors.Is(err, tenant.ErrTenantNotFound) {
+				scope.FailWithCode(c, http.StatusNotFound, err.Error())
+			} else {
+				scope.Fail(c, err.Error())
+			}
+			c.Abort()
+			return
+		}
+
+		tc := &scope.TenantContext{
+			ID:     t.ID,
+			Name:   t.Name,
+			Source: source,
+		}
+		if t.Code != nil {
+			tc.Code = *t.Code
+		}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), "tenant", tc))
+		c.Set("tenant", tc)
+		c.Next()
+	}
+}
+
+// resolveTenantKey 按配置的顺序获取租户标识（ID 或编码）和来源
+func resolveTenantKey(c *gin.Context, cfg global.TenantResolverConfig) (string, string) {
+	for _, source := range cfg.Sources {
+		var key string
+		switch source {
+		case TenantSourceHeader:
+			header := cfg.Header
+			if header == "" {
+				header = "X-Tenant-ID"
+			}
+			key = c.GetHeader(header)
+		case TenantSourceSubdomain:
+			key = subdomain(c.Request.Host, cfg.Domain)
+		case TenantSourcePath:
+			key, _ = c.Request.Context().Value(tenantPathKey{}).(string)
+		}
+		if key = strings.TrimSpace(key); key != "" {
+			return key, source
+		}
+	}
+	return "", ""
+}
+
+// subdomain 获取根域名前的子域名，acme.example.com 返回 acme，www 和多级子域名不识别
+func subdomain(host, domain string) string {
+	if domain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	sub, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(domain))
+	if !ok || sub == "" || sub == "www" || strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
+
+// TenantPath 开启路径识别时包装路由：/t/{tenant}/api/... 去掉前缀后交给 next 处理
+// 在路由匹配之前改写路径，全局中间件只执行一次
+func TenantPath(next http.Handler) http.Handler {
+	cfg := global.Config.Tenant.Resolver
+	if !slices.Contains(cfg.Sources, TenantSourcePath) {
+		return next
+	}
+	prefix := cfg.PathPrefix
+	if prefix == "" {
+		prefix = "/t/"
+	}
+	prefix = "/" + strings.Trim(prefix, "/") + "/"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rest, ok := strings.CutPrefix(req.URL.Path, prefix)
+		if key, path, _ := strings.Cut(rest, "/"); ok && key != "" {
+			req = req.WithContext(context.WithValue(req.Context(), tenantPathKey{}, key))
+			u := *req.URL
+			u.Path = "/" + path
+			u.RawPath = ""
+			req.URL = &u
+		}
+		next.ServeHTTP(w, req)
+	})
+}
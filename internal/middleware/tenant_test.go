@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"seedgo/internal/global"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubdomain(t *testing.T) {
+	assert.Equal(t, "acme", subdomain("acme.example.com", "example.com"))
+	assert.Equal(t, "acme", subdomain("ACME.example.com:8080", "example.com"))
+	assert.Equal(t, "", subdomain("www.example.com", "example.com"))
+	assert.Equal(t, "", subdomain("a.b.example.com", "example.com"))
+	assert.Equal(t, "", subdomain("example.com", "example.com"))
+	assert.Equal(t, "", subdomain("acme.other.com", "example.com"))
+	assert.Equal(t, "", subdomain("acme.example.com", ""))
+}
+
+func TestResolveTenantKey(t *testing.T) {
+	cfg := global.TenantResolverConfig{
+		Sources: []string{TenantSourceHeader, TenantSourceSubdomain},
+		Domain:  "example.com",
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "http://acme.example.com/api/auth/login", nil)
+	key, source := resolveTenantKey(c, cfg)
+	assert.Equal(t, "acme", key)
+	assert.Equal(t, TenantSourceSubdomain, source)
+
+	// 请求头优先
+	c.Request.Header.Set("X-Tenant-ID", "12")
+	key, source = resolveTenantKey(c, cfg)
+	assert.Equal(t, "12", key)
+	assert.Equal(t, TenantSourceHeader, source)
+
+	// 没有配置来源时不识别
+	key, _ = resolveTenantKey(c, global.TenantResolverConfig{})
+	assert.Equal(t, "", key)
+}
+
+func TestTenantPath(t *testing.T) {
+	global.Config = &global.Configuration{Tenant: global.TenantConfig{
+		Resolver: global.TenantResolverConfig{Sources: []string{TenantSourcePath}},
+	}}
+
+	calls := 0
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		calls++
+		c.Next()
+	})
+	r.GET("/api/ping", func(c *gin.Context) {
+		key, source := resolveTenantKey(c, global.Config.Tenant.Resolver)
+		c.String(http.StatusOK, source+":"+key)
+	})
+	h := TenantPath(r)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/t/acme/api/ping", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "path:acme", w.Body.String())
+	// 全局中间件只执行一次
+	assert.Equal(t, 1, calls)
+
+	// 没有前缀的请求不识别租户
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/api/ping", nil))
+	assert.Equal(t, ":", w.Body.String())
+	assert.Equal(t, 2, calls)
+}
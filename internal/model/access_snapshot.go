@@ -0,0 +1,14 @@
+package model
+
+// AccessSnapshot 权限审查快照，保存某一时刻租户的有效权限矩阵，用于和下一次审查对比
+type AccessSnapshot struct {
+	BaseTenantModel
+	EntryCount int    `json:"entryCount"`
+	Entries    string `gorm:"type:longtext" json:"-"` // JSON 格式的权限矩阵
+	CreatedBy  ID     `json:"createdBy"`
+	Remark     string `gorm:"type:varchar(255)" json:"remark"`
+}
+
+func (AccessSnapshot) TableName() string {
+	return "access_snapshot"
+}
@@ -5,13 +5,13 @@ import "time"
 // Dict 字典表
 type Dict struct {
 	ID          ID        `gorm:"primaryKey;autoIncrement" json:"id"`
-	Code        string    `gorm:"type:varchar(50);unique;not null" json:"code"`
-	Name        string    `gorm:"type:varchar(100);not null" json:"name"`
-	Description *string   `gorm:"type:varchar(255)" json:"description"`
+	Code        string    `gorm:"type:varchar(50);unique;not null" json:"code" seedgo:"writable"`
+	Name        string    `gorm:"type:varchar(100);not null" json:"name" seedgo:"writable"`
+	Description *string   `gorm:"type:varchar(255)" json:"description" seedgo:"writable"`
 	CreatedAt   time.Time `gorm:"index;<-:create" json:"createdAt"`
 	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
 
-	Items []*DictItem `gorm:"foreignKey:DictID" json:"items"`
+	Items []*DictItem `gorm:"foreignKey:DictID" json:"items" seedgo:"writable"`
 }
 
 func (d *Dict) TableName() string {
@@ -23,14 +23,19 @@ func (d *Dict) SearchFields() []string {
 	return []string{"code", "name"}
 }
 
+// ExpandFields 列表可以预加载字典项
+func (d *Dict) ExpandFields() []string {
+	return []string{"items"}
+}
+
 // DictItem 字典项表
 type DictItem struct {
 	ID        ID        `gorm:"primaryKey;autoIncrement" json:"id"`
-	DictID    ID        `gorm:"index;not null" json:"dictId"`
-	Label     string    `gorm:"type:varchar(100);not null" json:"label"`
-	Value     string    `gorm:"type:varchar(100);index;not null" json:"value"`
-	Sort      int       `gorm:"default:0;not null" json:"sort"`
-	Status    int       `gorm:"type:tinyint;default:1;index;not null" json:"status"`
+	DictID    ID        `gorm:"index;not null" json:"dictId" seedgo:"writable"`
+	Label     string    `gorm:"type:varchar(100);not null" json:"label" seedgo:"writable"`
+	Value     string    `gorm:"type:varchar(100);index;not null" json:"value" seedgo:"writable"`
+	Sort      int       `gorm:"default:0;not null" json:"sort" seedgo:"writable"`
+	Status    int       `gorm:"type:tinyint;default:1;index;not null" json:"status" seedgo:"writable"`
 	CreatedAt time.Time `gorm:"index;<-:create" json:"createdAt"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
 
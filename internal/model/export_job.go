@@ -0,0 +1,27 @@
+package model
+
+// 导出任务状态
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportSuccess = "success"
+	ExportFailed  = "failed"
+)
+
+// ExportJob 后台导出任务，数据量大的导出在后台写入文件，完成后由发起的用户下载
+type ExportJob struct {
+	BaseTenantModel
+	UserID     ID        `gorm:"index" json:"userId"`
+	Name       string    `gorm:"size:64" json:"name"` // 导出的模块，如 users
+	Format     string    `gorm:"size:10" json:"format"`
+	Status     string    `gorm:"size:20;index" json:"status"`
+	Total      int64     `json:"total"` // 创建任务时符合条件的记录数
+	Rows       int64     `json:"rows"`  // 实际导出的记录数
+	File       string    `gorm:"size:255" json:"-"`
+	Error      string    `gorm:"type:text" json:"error"`
+	FinishedAt *DateTime `json:"finishedAt"`
+}
+
+func (ExportJob) TableName() string {
+	return "export_job"
+}
@@ -0,0 +1,31 @@
+package model
+
+// Menu 前端菜单（路由），与接口权限 Permission 分开维护
+// 通过 PermissionCode 关联权限，用户拥有该权限时才显示菜单，为空表示登录即可见
+type Menu struct {
+	ID             ID      `gorm:"primarykey" json:"id"`
+	ParentID       *ID     `gorm:"index" json:"parentId" seedgo:"writable"`
+	Name           string  `gorm:"size:100;index" json:"name" seedgo:"writable"`           // 路由名称
+	Title          string  `gorm:"size:100" json:"title" seedgo:"writable"`                // 标题，可以是 i18n 的 key
+	Path           string  `gorm:"size:255" json:"path" seedgo:"writable"`                 // 前端路由地址
+	Component      string  `gorm:"size:255" json:"component" seedgo:"writable"`            // 前端组件路径，目录为空
+	Redirect       string  `gorm:"size:255" json:"redirect" seedgo:"writable"`             // 重定向地址
+	Icon           string  `gorm:"size:100" json:"icon" seedgo:"writable"`                 // 图标
+	Link           string  `gorm:"size:500" json:"link" seedgo:"writable"`                 // 外部链接，不为空时为外链菜单
+	PermissionCode string  `gorm:"size:100;index" json:"permissionCode" seedgo:"writable"` // 关联的权限编码
+	Sort           *int    `gorm:"index" json:"sort" seedgo:"writable"`                    // 排序
+	Visible        *bool   `gorm:"default:true" json:"visible" seedgo:"writable"`          // 是否在菜单栏显示，隐藏的菜单仍然注册路由
+	KeepAlive      *bool   `gorm:"default:false" json:"keepAlive" seedgo:"writable"`       // 是否缓存页面
+	Children       []*Menu `gorm:"-" json:"children,omitempty"`
+}
+
+func (m *Menu) TableName() string {
+	return "menu"
+}
+
+// SearchFields 默认搜索字段
+func (m *Menu) SearchFields() []string {
+	return []string{"name", "title", "path"}
+}
+
+var _ Searchable = (*Menu)(nil)
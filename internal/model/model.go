@@ -41,10 +41,15 @@ type Searchable interface {
 	SearchFields() []string
 }
 
+// Expandable 支持通过 expand 参数预加载关联的接口，返回允许预加载的关联（JSON 字段名）
+type Expandable interface {
+	ExpandFields() []string
+}
+
 // TenantModel 租户类
 type TenantModel struct {
-	//修改禁止更新
-	TenantID ID `gorm:"column:tenant_id;<-:create" json:"tenantId"`
+	//修改禁止更新，仅超级用户可通过接口指定
+	TenantID ID `gorm:"column:tenant_id;<-:create" json:"tenantId" seedgo:"writable,super"`
 }
 
 // DateTime 自定义时间类型，用于处理前端传递的时间字符串, 返回毫秒级时间戳，由前端解析成日期时间字符串，避免跨时区问题
@@ -92,11 +97,15 @@ func (t DateTime) String() string {
 	return time.Time(t).Format(TimeFormat)
 }
 
+// Version 乐观锁版本号，更新时提交读取到的版本号，和数据库不一致说明记录已被其他人修改
+type Version int64
+
 type BaseModel struct {
-	ID        ID             `gorm:"primarykey" json:"id"`
-	CreatedAt *DateTime      `gorm:"index;<-:create" json:"createdAt"`
+	ID        ID             `gorm:"primarykey" json:"id" export:"ID"`
+	CreatedAt *DateTime      `gorm:"index;<-:create" json:"createdAt" export:"创建时间"`
 	UpdatedAt *DateTime      `gorm:"index" json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	Version   Version        `gorm:"not null;default:1" json:"version" seedgo:"writable"`
 }
 
 type BaseTenantModel struct {
@@ -5,18 +5,19 @@ import "time"
 // OperationLog 操作日志
 type OperationLog struct {
 	BaseTenantModel
-	UserID        ID        `gorm:"index" json:"userId"`
-	Username      string    `gorm:"size:64" json:"username"`
-	Method        string    `gorm:"size:10" json:"method"`
-	Path          string    `gorm:"size:255" json:"path"`
-	Query         string    `gorm:"type:text" json:"query"`
-	Body          string    `gorm:"type:text" json:"body"`
-	IP            string    `gorm:"size:64" json:"ip"`
-	UserAgent     string    `gorm:"size:255" json:"userAgent"`
-	Status        int       `json:"status"`
-	Latency       int64     `json:"latency"` // 耗时(ms)
-	ErrorMessage  string    `gorm:"type:text" json:"errorMessage"`
-	OperationTime *DateTime `gorm:"index;<-:create" json:"operationTime"`
+	UserID         ID        `gorm:"index" json:"userId"`
+	Username       string    `gorm:"size:64" json:"username" export:"用户名"`
+	Method         string    `gorm:"size:10" json:"method" export:"方法"`
+	Path           string    `gorm:"size:255" json:"path" export:"路径"`
+	Query          string    `gorm:"type:text" json:"query"`
+	Body           string    `gorm:"type:text" json:"body"`
+	IP             string    `gorm:"size:64" json:"ip" export:"IP"`
+	UserAgent      string    `gorm:"size:255" json:"userAgent"`
+	Status         int       `json:"status" export:"状态码"`
+	Latency        int64     `json:"latency" export:"耗时(ms)"` // 耗时(ms)
+	ErrorMessage   string    `gorm:"type:text" json:"errorMessage"`
+	ActingTenantID ID        `gorm:"index;not null;default:0" json:"actingTenantId"` // 超级用户操作时选择的租户
+	OperationTime  *DateTime `gorm:"index;<-:create" json:"operationTime" export:"操作时间"`
 }
 
 func (o *OperationLog) SetOperationTime() {
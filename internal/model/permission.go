@@ -1,16 +1,21 @@
 package model
 
+// 权限类型
+const (
+	PermissionTypeMenu   = 1 // 页面，PermissionUrls 为接口路径前缀
+	PermissionTypeButton = 2 // 操作，PermissionCode 以 :create、:update、:delete 结尾
+)
+
+// Permission 接口权限，按 PermissionUrls 匹配请求
+// 菜单（路由、图标、是否显示）在 Menu 中维护，通过 PermissionCode 关联
 type Permission struct {
 	ID             ID            `gorm:"primarykey" json:"id"`
-	ParentID       *ID           `gorm:"index" json:"parentId"`
-	Name           string        `gorm:"index" json:"name"`
-	Path           string        `gorm:"index" json:"path"`
-	Icon           string        `gorm:"index" json:"icon"`
-	PermissionCode string        `gorm:"index" json:"permissionCode"`
-	Sort           *int          `gorm:"index" json:"sort"`
-	Visible        *bool         `gorm:"index;default:true" json:"visible"`
-	Type           *int          `gorm:"index" json:"type"`
-	PermissionUrls string        `gorm:"column:permission_urls" json:"permissionUrls"` // 多个Url用英文逗号分割
+	ParentID       *ID           `gorm:"index" json:"parentId" seedgo:"writable"`
+	Name           string        `gorm:"index" json:"name" seedgo:"writable"`
+	PermissionCode string        `gorm:"index" json:"permissionCode" seedgo:"writable"`
+	Sort           *int          `gorm:"index" json:"sort" seedgo:"writable"`
+	Type           *int          `gorm:"index" json:"type" seedgo:"writable"`
+	PermissionUrls string        `gorm:"column:permission_urls" json:"permissionUrls" seedgo:"writable"` // 多个Url用英文逗号分割
 	Children       []*Permission `gorm:"-" json:"children,omitempty"`
 }
 
@@ -0,0 +1,33 @@
+package model
+
+// 配额资源
+const (
+	QuotaUsers   = "users"    // 用户数
+	QuotaRoles   = "roles"    // 角色数
+	QuotaAPIKeys = "api_keys" // API Key 数
+	QuotaStorage = "storage"  // 存储空间，单位 MB
+)
+
+// Plan 套餐，Limits 为各配额资源的上限，未配置的资源不限制
+type Plan struct {
+	BaseModel
+	Code        string           `gorm:"size:50;uniqueIndex;not null" json:"code" seedgo:"writable"`
+	Name        string           `gorm:"size:100;not null" json:"name" seedgo:"writable"`
+	Limits      map[string]int64 `gorm:"type:text;serializer:json" json:"limits" seedgo:"writable"`
+	Description *string          `gorm:"size:255" json:"description" seedgo:"writable"`
+}
+
+func (Plan) TableName() string {
+	return "plan"
+}
+
+func (p Plan) SearchFields() []string {
+	return []string{"name", "code"}
+}
+
+// Quotable 受配额限制的模型，创建前按 QuotaResource 检查租户配额
+type Quotable interface {
+	QuotaResource() string
+}
+
+var _ Searchable = (*Plan)(nil)
@@ -0,0 +1,24 @@
+package model
+
+// Policy 基于属性的访问策略（ABAC），按租户保存，在 RBAC 之上补充路径权限无法表达的规则
+// Expression 语法见 pkg/policy，可用变量：user、request、resource
+type Policy struct {
+	BaseTenantModel
+	Name        string  `gorm:"type:varchar(100);not null" json:"name" seedgo:"writable" export:"名称,required"`
+	Action      string  `gorm:"type:varchar(100);not null;index" json:"action" seedgo:"writable" export:"动作,required"` // 动作编码，如 order:approve
+	Effect      string  `gorm:"type:varchar(10);not null;default:allow" json:"effect" seedgo:"writable" export:"效果"`
+	Expression  string  `gorm:"type:text;not null" json:"expression" seedgo:"writable"`
+	Status      int     `gorm:"type:tinyint;not null;default:1" json:"status" seedgo:"writable" export:"状态,dict=common_status"`
+	Description *string `gorm:"type:varchar(255)" json:"description" seedgo:"writable" export:"描述"`
+}
+
+func (Policy) TableName() string {
+	return "policy"
+}
+
+// SearchFields 支持搜索的字段
+func (p *Policy) SearchFields() []string {
+	return []string{"name", "action"}
+}
+
+var _ Searchable = (*Policy)(nil)
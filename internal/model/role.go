@@ -2,15 +2,19 @@ package model
 
 type Role struct {
 	BaseTenantModel
-	Name        string  `gorm:"type:varchar(50);not null;index:idx_tenant_name" json:"name"`
-	Description *string `gorm:"type:varchar(255)" json:"description"`
+	Name        string  `gorm:"type:varchar(50);not null;index:idx_tenant_name" json:"name" seedgo:"writable" export:"角色名称,required"`
+	Description *string `gorm:"type:varchar(255)" json:"description" seedgo:"writable" export:"描述"`
 
 	Users []*User `gorm:"many2many:user_role;" json:"users,omitempty"`
 	//关联权限
 	Permissions []*Permission `gorm:"many2many:role_permission;" json:"permissions,omitempty"`
 
 	//数据传输用，不处理数据
-	PermissionIds *[]ID `gorm:"-" json:"permissionIds,omitempty"`
+	PermissionIds *[]ID `gorm:"-" json:"permissionIds,omitempty" seedgo:"writable"`
+	// 拒绝的权限，优先级高于 PermissionIds
+	DenyPermissionIds *[]ID `gorm:"-" json:"denyPermissionIds,omitempty" seedgo:"writable"`
+	// 每个权限的最终效果 allow/deny，详情展示用，未出现的权限表示未授权
+	PermissionEffects map[ID]string `gorm:"-" json:"permissionEffects,omitempty"`
 }
 
 func (Role) TableName() string {
@@ -20,3 +24,15 @@ func (Role) TableName() string {
 func (r Role) SearchFields() []string {
 	return []string{"name"}
 }
+
+// ExpandFields 列表和详情可以预加载拥有该角色的用户
+func (Role) ExpandFields() []string {
+	return []string{"users"}
+}
+
+func (Role) QuotaResource() string {
+	return QuotaRoles
+}
+
+var _ Quotable = (*Role)(nil)
+var _ Expandable = (*Role)(nil)
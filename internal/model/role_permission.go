@@ -0,0 +1,20 @@
+package model
+
+// 角色权限的授权效果
+const (
+	// EffectAllow 允许
+	EffectAllow = "allow"
+	// EffectDeny 拒绝，优先级高于允许（deny-overrides）
+	EffectDeny = "deny"
+)
+
+// RolePermission 角色权限关联表，Effect 区分允许和拒绝
+type RolePermission struct {
+	RoleID       ID     `gorm:"primaryKey" json:"roleId"`
+	PermissionID ID     `gorm:"primaryKey" json:"permissionId"`
+	Effect       string `gorm:"type:varchar(10);not null;default:allow;index" json:"effect"`
+}
+
+func (RolePermission) TableName() string {
+	return "role_permission"
+}
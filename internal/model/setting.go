@@ -0,0 +1,54 @@
+package model
+
+// 配置项类型
+const (
+	SettingTypeString = "string"
+	SettingTypeInt    = "int"
+	SettingTypeBool   = "bool"
+	SettingTypeJSON   = "json"
+)
+
+// Setting 配置项定义，保存类型、校验规则和全局默认值，所有租户共用
+// Value、Schema 都是 JSON 文本，Value 按 Type 解析，Schema 见 SettingSchema
+type Setting struct {
+	BaseModel
+	Key         string  `gorm:"size:100;uniqueIndex;not null" json:"key" seedgo:"writable"`
+	Name        string  `gorm:"size:100;not null" json:"name" seedgo:"writable"`
+	Type        string  `gorm:"size:10;not null" json:"type" seedgo:"writable"`
+	Value       string  `gorm:"type:text" json:"value" seedgo:"writable"`
+	Schema      string  `gorm:"type:text" json:"schema" seedgo:"writable"`
+	Overridable bool    `gorm:"not null;default:false" json:"overridable" seedgo:"writable"` // 租户是否可以覆盖
+	Public      bool    `gorm:"not null;default:false" json:"public" seedgo:"writable"`      // 未登录也可以读取，如系统名称、Logo
+	Description *string `gorm:"size:255" json:"description" seedgo:"writable"`
+}
+
+func (Setting) TableName() string {
+	return "setting"
+}
+
+func (s Setting) SearchFields() []string {
+	return []string{"key", "name"}
+}
+
+// SettingSchema 配置项校验规则，为空的规则不校验
+// Min、Max 对 int 是取值范围，对 string 是长度范围
+type SettingSchema struct {
+	Required bool     `json:"required,omitempty"`
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+	Pattern  string   `json:"pattern,omitempty"`
+	Enum     []any    `json:"enum,omitempty"`
+}
+
+// TenantSetting 租户覆盖的配置值，Value 为 JSON 文本
+type TenantSetting struct {
+	BaseTenantModel
+	Key   string `gorm:"size:100;not null;index" json:"key"`
+	Value string `gorm:"type:text" json:"value"`
+}
+
+func (TenantSetting) TableName() string {
+	return "tenant_setting"
+}
+
+var _ Searchable = (*Setting)(nil)
@@ -1,19 +1,30 @@
 package model
 
+import "time"
+
 type Tenant struct {
 	BaseModel
-	Name         string `gorm:"size:255;not null" json:"name"`
-	ContactName  string `gorm:"size:50" json:"contactName"`
-	ContactPhone string `gorm:"size:20" json:"contactPhone"`
-	ContactEmail string `gorm:"size:100" json:"contactEmail"`
-	Status       int    `gorm:"default:1" json:"status"`
-	Users        []User `gorm:"foreignKey:TenantID;references:ID" json:"users"`
+	Name            string           `gorm:"size:255;not null" json:"name" seedgo:"writable" export:"租户名称,required"`
+	Code            *string          `gorm:"size:50;uniqueIndex" json:"code" seedgo:"writable" export:"编码"` // 租户编码，用于子域名和路径识别租户
+	ContactName     string           `gorm:"size:50" json:"contactName" seedgo:"writable" export:"联系人"`
+	ContactPhone    string           `gorm:"size:20" json:"contactPhone" seedgo:"writable" export:"联系电话"`
+	ContactEmail    string           `gorm:"size:100" json:"contactEmail" seedgo:"writable" export:"联系邮箱"`
+	Status          int              `gorm:"default:1" json:"status" seedgo:"writable,super" export:"状态,dict=common_status"`
+	ExpiresAt       *time.Time       `gorm:"index" json:"expiresAt" seedgo:"writable,super" export:"到期时间"`            // 到期时间，为空表示不限制，过期超过宽限期后不能访问
+	SuspendReason   string           `gorm:"size:255" json:"suspendReason"`                                           // 停用原因，由停用/恢复接口维护
+	SuspendedAt     *time.Time       `json:"suspendedAt"`                                                             // 停用时间
+	PurgeAt         *time.Time       `gorm:"index" json:"purgeAt"`                                                    // 删除后计划清除数据的时间，保留期内可以恢复
+	PlanID          *ID              `gorm:"index" json:"planId" seedgo:"writable,super"`                             // 套餐
+	QuotaOverrides  map[string]int64 `gorm:"type:text;serializer:json" json:"quotaOverrides" seedgo:"writable,super"` // 租户单独调整的配额，覆盖套餐上限
+	TemplateID      *ID              `gorm:"index" json:"templateId" seedgo:"writable,super"`                         // 开通模板，为空时使用默认模板
+	TemplateVersion int              `gorm:"not null;default:0" json:"templateVersion"`                               // 已应用的模板版本
+	Users           []User           `gorm:"foreignKey:TenantID;references:ID" json:"users"`
 
 	// 接收参数用
-	Username string `gorm:"-" json:"username,omitempty"`
-	Password string `gorm:"-" json:"password,omitempty"`
-	RealName string `gorm:"-" json:"realName,omitempty"`
-	Phone    string `gorm:"-" json:"phone,omitempty"`
+	Username string `gorm:"-" json:"username,omitempty" seedgo:"writable"`
+	Password string `gorm:"-" json:"password,omitempty" seedgo:"writable"`
+	RealName string `gorm:"-" json:"realName,omitempty" seedgo:"writable"`
+	Phone    string `gorm:"-" json:"phone,omitempty" seedgo:"writable"`
 }
 
 func (t Tenant) SearchFields() []string {
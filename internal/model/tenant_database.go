@@ -0,0 +1,15 @@
+package model
+
+// TenantDatabase 租户独立数据库，保存在控制库中
+// 登记后该租户的业务数据读写走自己的数据库，DSN 含有密码，不通过接口返回
+type TenantDatabase struct {
+	BaseModel
+	TenantID     ID     `gorm:"uniqueIndex;not null" json:"tenantId"`
+	Dsn          string `gorm:"size:500;not null" json:"-"`
+	MaxOpenConns int    `gorm:"default:20" json:"maxOpenConns"`
+	MaxIdleConns int    `gorm:"default:5" json:"maxIdleConns"`
+}
+
+func (TenantDatabase) TableName() string {
+	return "tenant_database"
+}
@@ -0,0 +1,18 @@
+package model
+
+// TenantPurgeLog 租户数据清除报告，租户记录清除后仍然保留
+// Deleted 为各表删除的行数，Remaining 为清除后校验时剩余的行数，全部为 0 时 Verified 为 true
+type TenantPurgeLog struct {
+	BaseModel
+	TenantID   ID               `gorm:"index" json:"tenantId"`
+	TenantName string           `gorm:"size:255" json:"tenantName"`
+	Deleted    map[string]int64 `gorm:"type:text;serializer:json" json:"deleted"`
+	Remaining  map[string]int64 `gorm:"type:text;serializer:json" json:"remaining"`
+	Verified   bool             `json:"verified"`
+	Error      string           `gorm:"type:text" json:"error"`
+	PurgedBy   ID               `json:"purgedBy"` // 手动清除的超级用户，后台任务清除时为 0
+}
+
+func (TenantPurgeLog) TableName() string {
+	return "tenant_purge_log"
+}
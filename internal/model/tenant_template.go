@@ -0,0 +1,55 @@
+package model
+
+import "encoding/json"
+
+// TenantTemplate 租户开通模板，新租户创建时在同一个事务中初始化角色、权限、字典和配置
+// 修改 Content 时内容版本号加 1，租户记录应用过的内容版本，可以把新版本重新应用到已有租户
+type TenantTemplate struct {
+	BaseModel
+	Code           string          `gorm:"size:50;uniqueIndex;not null" json:"code" seedgo:"writable"`
+	Name           string          `gorm:"size:100;not null" json:"name" seedgo:"writable"`
+	ContentVersion int             `gorm:"not null;default:1" json:"contentVersion"`                  // 内容版本，和乐观锁的 version 无关
+	IsDefault      bool            `gorm:"not null;default:false" json:"isDefault" seedgo:"writable"` // 创建租户没有指定模板时使用
+	Content        TemplateContent `gorm:"type:text;serializer:json" json:"content" seedgo:"writable"`
+	Description    *string         `gorm:"size:255" json:"description" seedgo:"writable"`
+}
+
+func (TenantTemplate) TableName() string {
+	return "tenant_template"
+}
+
+func (t TenantTemplate) SearchFields() []string {
+	return []string{"code", "name"}
+}
+
+// TemplateContent 模板内容
+type TemplateContent struct {
+	Roles    []TemplateRole             `json:"roles"`
+	Dicts    []TemplateDict             `json:"dicts"`
+	Settings map[string]json.RawMessage `json:"settings"` // 租户覆盖的配置值，key 为配置项
+}
+
+// TemplateRole 模板中的角色，权限使用权限编码，按名称识别租户中已有的角色
+type TemplateRole struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions"`
+	Deny        []string `json:"deny,omitempty"`
+	Main        bool     `json:"main,omitempty"` // 分配给租户主账号
+}
+
+// TemplateDict 模板中的字典，按编码识别已有的字典
+type TemplateDict struct {
+	Code  string             `json:"code"`
+	Name  string             `json:"name"`
+	Items []TemplateDictItem `json:"items"`
+}
+
+// TemplateDictItem 字典项，按值识别已有的字典项
+type TemplateDictItem struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+	Sort  int    `json:"sort"`
+}
+
+var _ Searchable = (*TenantTemplate)(nil)
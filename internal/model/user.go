@@ -6,21 +6,23 @@ import (
 
 type User struct {
 	BaseTenantModel
-	Username     string     `gorm:"type:varchar(50);not null;uniqueIndex:uniq_tenant_username" json:"username"`
+	Username     string     `gorm:"type:varchar(50);not null;uniqueIndex:uniq_tenant_username" json:"username" seedgo:"writable" export:"用户名,required"`
 	PasswordHash string     `gorm:"type:varchar(255);not null" json:"-"`
-	Phone        *string    `gorm:"type:varchar(20);index:idx_phone" json:"phone"`
-	Email        *string    `gorm:"type:varchar(100)" json:"email"`
-	RealName     *string    `gorm:"type:varchar(50)" json:"realName"`
-	IsMain       *int8      `gorm:"type:tinyint;not null;default:0;index:idx_main" json:"isMain"`
-	IsSuper      *bool      `gorm:"type:tinyint;not null;default:0" json:"isSuper"`
-	Status       *int8      `gorm:"type:tinyint;not null;default:1;index:idx_status" json:"status"`
-	LastLoginAt  *time.Time `json:"lastLoginAt"`
+	Phone        *string    `gorm:"type:varchar(20);index:idx_phone" json:"phone" seedgo:"writable" export:"手机号"`
+	Email        *string    `gorm:"type:varchar(100)" json:"email" seedgo:"writable" export:"邮箱"`
+	RealName     *string    `gorm:"type:varchar(50)" json:"realName" seedgo:"writable" export:"姓名"`
+	IsMain       *int8      `gorm:"type:tinyint;not null;default:0;index:idx_main" json:"isMain" seedgo:"writable,super"`
+	IsSuper      *bool      `gorm:"type:tinyint;not null;default:0" json:"isSuper" seedgo:"writable,super"`
+	Status       *int8      `gorm:"type:tinyint;not null;default:1;index:idx_status" json:"status" seedgo:"writable" export:"状态,dict=common_status"`
+	LastLoginAt  *time.Time `json:"lastLoginAt" export:"最后登录时间"`
 	LastLoginIP  *string    `gorm:"type:varchar(50)" json:"lastLoginIP"`
 
 	Roles []*Role `gorm:"many2many:user_role;" json:"roles"`
 
 	// 接收参数用
-	RoleIds *[]ID `gorm:"-" json:"roleIds,omitempty"`
+	RoleIds *[]ID `gorm:"-" json:"roleIds,omitempty" seedgo:"writable"`
+	// 带有效期的角色分配，提交时优先于 RoleIds
+	RoleAssignments *[]UserRole `gorm:"-" json:"roleAssignments,omitempty" seedgo:"writable"`
 
 	//租户名称
 	// gorm:"foreignKey:TenantID" 明确指定外键
@@ -31,6 +33,15 @@ func (u *User) TableName() string {
 	return "user"
 }
 
+func (u *User) QuotaResource() string {
+	return QuotaUsers
+}
+
+// ExpandFields 列表和详情可以预加载角色和租户
+func (u *User) ExpandFields() []string {
+	return []string{"roles", "tenant"}
+}
+
 // SearchFields 返回搜索字段
 func (u *User) SearchFields() []string {
 	return []string{"username", "realName", "phone", "email"}
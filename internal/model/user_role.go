@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// UserRole 用户角色关联表，ValidFrom/ValidUntil 为空表示不限制
+type UserRole struct {
+	UserID     ID         `gorm:"primaryKey" json:"userId"`
+	RoleID     ID         `gorm:"primaryKey" json:"roleId"`
+	ValidFrom  *time.Time `gorm:"index" json:"validFrom"`
+	ValidUntil *time.Time `gorm:"index" json:"validUntil"`
+}
+
+func (UserRole) TableName() string {
+	return "user_role"
+}
+
+// Active 判断角色分配在指定时间是否生效
+func (r *UserRole) Active(now time.Time) bool {
+	if r.ValidFrom != nil && now.Before(*r.ValidFrom) {
+		return false
+	}
+	if r.ValidUntil != nil && !now.Before(*r.ValidUntil) {
+		return false
+	}
+	return true
+}
+
+// NextChange 获取角色分配在指定时间之后的下一次生效或失效时间，没有返回 nil
+func (r *UserRole) NextChange(now time.Time) *time.Time {
+	if r.ValidFrom != nil && r.ValidFrom.After(now) {
+		return r.ValidFrom
+	}
+	if r.ValidUntil != nil && r.ValidUntil.After(now) {
+		return r.ValidUntil
+	}
+	return nil
+}
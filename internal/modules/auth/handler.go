@@ -1,7 +1,11 @@
 package auth
 
 import (
+	"net/http"
 	"seedgo/internal/form"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/setting"
+	"seedgo/internal/modules/tenant"
 	"seedgo/internal/modules/user"
 	"seedgo/internal/scope"
 
@@ -21,12 +25,38 @@ func NewHandler() *Handler {
 func (h *Handler) Use(g *gin.RouterGroup) {
 	g.POST("/login", h.Login)
 	g.POST("/logout", h.Logout)
+	g.GET("/tenant", h.GetTenant)
+	g.GET("/settings", h.GetSettings)
+}
+
+// GetTenant 获取 TenantResolver 识别出的租户公开信息，用于登录页展示租户名称等
+func (h *Handler) GetTenant(ctx *gin.Context) {
+	tenant := scope.GetCurrentTenant(ctx)
+	if tenant == nil {
+		scope.FailWithCode(ctx, http.StatusNotFound, "tenant not found")
+		return
+	}
+	scope.OkWithData(ctx, tenant)
+}
+
+// GetSettings 获取公开的配置（如系统名称、Logo），识别出租户时返回租户覆盖后的值
+func (h *Handler) GetSettings(ctx *gin.Context) {
+	var tenantID model.ID
+	if tenant := scope.GetCurrentTenant(ctx); tenant != nil {
+		tenantID = tenant.ID
+	}
+	values, err := setting.GetService().Map(tenantID, true)
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, values)
 }
 
 func (h *Handler) GetMe(ctx *gin.Context) {
 	user := scope.GetCurrentUser(ctx)
 	//通过用户ID查询详情，包含角色，排除密码
-	userModel, err := h.logic.GetProfile(user.ID)
+	userModel, err := h.logic.GetProfile(ctx.Request.Context(), user.ID)
 	if err != nil {
 		scope.Fail(ctx, err.Error())
 		return
@@ -44,6 +74,10 @@ func (h *Handler) Login(ctx *gin.Context) {
 
 	vo, err := h.logic.Login(ctx.Request.Context(), dto)
 	if err != nil {
+		if code := tenant.StatusCode(err); code != 0 {
+			scope.FailWithCode(ctx, code, err.Error())
+			return
+		}
 		scope.Fail(ctx, err.Error())
 		return
 	}
@@ -3,8 +3,11 @@ package common
 import (
 	"seedgo/internal/form"
 	"seedgo/internal/model"
+	"seedgo/internal/modules/menu"
 	"seedgo/internal/modules/perms"
+	"seedgo/internal/modules/quota"
 	"seedgo/internal/modules/role"
+	"seedgo/internal/modules/setting"
 	"seedgo/internal/modules/user"
 	"seedgo/internal/scope"
 	"seedgo/pkg"
@@ -27,9 +30,17 @@ func (h Handler) Use(g *gin.RouterGroup) {
 	g.GET("user/profile", h.GetProfile)
 	g.POST("user/profile", h.UpdateProfile)
 	g.POST("user/change-password", h.ChangePassword)
+	//转让租户主账号
+	g.POST("user/transfer-ownership", h.TransferOwnership)
 
 	//权限树获取
 	g.GET("user/permissions", h.GetPermissions)
+	//菜单路由获取
+	g.GET("user/menus", h.GetMenus)
+	//租户配额用量
+	g.GET("quota/usage", h.GetQuotaUsage)
+	//当前租户生效的配置
+	g.GET("settings", h.GetSettings)
 
 	//角色获取
 	options := g.Group("options")
@@ -69,7 +80,7 @@ func (h Handler) GetRoles(ctx *gin.Context) {
 // GetProfile 获取用户信息
 func (h Handler) GetProfile(c *gin.Context) {
 	u := scope.GetCurrentUser(c)
-	out, err := user.GetService().GetProfile(u.ID)
+	out, err := user.GetService().GetProfile(c.Request.Context(), u.ID)
 	if err != nil {
 		scope.Fail(c, err.Error())
 		return
@@ -105,6 +116,20 @@ func (h Handler) ChangePassword(c *gin.Context) {
 	scope.Ok(c)
 }
 
+// TransferOwnership 转让租户主账号，需要再次输入当前用户的密码
+func (h Handler) TransferOwnership(c *gin.Context) {
+	var dto form.TransferOwnershipDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		scope.Fail(c, "Invalid parameters")
+		return
+	}
+	if err := user.GetService().TransferOwnership(c.Request.Context(), scope.GetCurrentUser(c), dto); err != nil {
+		scope.Fail(c, err.Error())
+		return
+	}
+	scope.Ok(c)
+}
+
 func (h Handler) GetPermissions(c *gin.Context) {
 	permissions, err := perms.GetService().GetCacheTree(scope.GetCurrentUser(c))
 	if err != nil {
@@ -113,3 +138,38 @@ func (h Handler) GetPermissions(c *gin.Context) {
 	}
 	scope.OkWithData(c, permissions)
 }
+
+// GetMenus 获取当前用户的菜单路由，前端可以直接注册为路由
+func (h Handler) GetMenus(c *gin.Context) {
+	menus, err := menu.GetService().GetUserMenus(c.Request.Context(), scope.GetCurrentUser(c))
+	if err != nil {
+		scope.Fail(c, err.Error())
+		return
+	}
+	scope.OkWithData(c, menus)
+}
+
+// GetQuotaUsage 获取当前租户的配额用量，超级用户可以通过 tenantId 参数指定租户
+func (h Handler) GetQuotaUsage(ctx *gin.Context) {
+	user := scope.GetCurrentUser(ctx)
+	tenantID := user.CurrentTenantID()
+	if user.IsSuper && ctx.Query("tenantId") != "" {
+		tenantID = model.ToID(ctx.Query("tenantId"))
+	}
+	usage, err := quota.GetService().Usage(ctx.Request.Context(), tenantID)
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, usage)
+}
+
+// GetSettings 获取当前租户生效的配置，key 为配置项，值为 JSON
+func (h Handler) GetSettings(ctx *gin.Context) {
+	values, err := setting.GetService().Map(scope.GetCurrentUser(ctx).CurrentTenantID(), false)
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, values)
+}
@@ -13,9 +13,11 @@ type Service struct {
 }
 
 func NewService() *Service {
-	return &Service{
+	s := &Service{
 		BaseService: shared.NewBaseService[model.Dict](),
 	}
+	s.Impl = s
+	return s
 }
 
 // Create 创建字典及其项
@@ -30,7 +32,7 @@ func (s *Service) Create(ctx context.Context, entity *model.Dict) error {
 
 // Update 更新字典及其项
 func (s *Service) Update(ctx context.Context, entity *model.Dict) error {
-	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return s.Conn(ctx).Transaction(func(tx *gorm.DB) error {
 		// 更新基本信息，忽略 createdAt
 		if err := tx.Model(entity).Omit("CreatedAt").Updates(entity).Error; err != nil {
 			return err
@@ -75,8 +77,8 @@ func (s *Service) Update(ctx context.Context, entity *model.Dict) error {
 }
 
 // Get 获取详情并包含关联项
-func (s *Service) Get(ctx context.Context, id model.ID) (*model.Dict, error) {
+func (s *Service) Get(ctx context.Context, id model.ID, scopes ...func(*gorm.DB) *gorm.DB) (*model.Dict, error) {
 	var entity model.Dict
-	err := s.DB.WithContext(ctx).Preload("Items").First(&entity, id).Error
+	err := s.DB.WithContext(ctx).Preload("Items").Scopes(scopes...).First(&entity, id).Error
 	return &entity, err
 }
@@ -0,0 +1,93 @@
+package export
+
+import (
+	"fmt"
+	"net/http"
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	logic *Service
+}
+
+func NewHandler() *Handler {
+	return &Handler{logic: GetService()}
+}
+
+// Use 注册路由，只能查看和下载自己发起的导出任务
+func (h *Handler) Use(g *gin.RouterGroup) {
+	g.GET("", h.List)
+	g.GET("/:id", h.Get)
+	g.GET("/:id/download", h.Download)
+	g.DELETE("/:id", h.Delete)
+}
+
+func currentUser(ctx *gin.Context) (*scope.UserContext, bool) {
+	user := scope.GetCurrentUser(ctx)
+	if user == nil {
+		scope.FailWithCode(ctx, http.StatusUnauthorized, "Unauthorized")
+		return nil, false
+	}
+	return user, true
+}
+
+// List 导出任务列表
+func (h *Handler) List(ctx *gin.Context) {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+	jobs, err := h.logic.ListOwn(ctx.Request.Context(), user.ID)
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, scope.PageResult{
+		Total: int64(len(jobs)),
+		Items: jobs,
+	})
+}
+
+// Get 导出任务状态
+func (h *Handler) Get(ctx *gin.Context) {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+	job, err := h.logic.GetOwn(ctx.Request.Context(), user.ID, model.ToID(ctx.Param("id")))
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, job)
+}
+
+// Download 下载已完成的导出文件
+func (h *Handler) Download(ctx *gin.Context) {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+	job, err := h.logic.File(ctx.Request.Context(), user.ID, model.ToID(ctx.Param("id")))
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	ctx.FileAttachment(job.File, fmt.Sprintf("%s-%d.%s", job.Name, job.ID, job.Format))
+}
+
+// Delete 删除导出任务和文件
+func (h *Handler) Delete(ctx *gin.Context) {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+	if err := h.logic.DeleteOwn(ctx.Request.Context(), user.ID, model.ToID(ctx.Param("id"))); err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.Ok(ctx)
+}
@@ -0,0 +1,14 @@
+package export_test
+
+import (
+	"os"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/model"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	dbtest.Open(&model.Tenant{}, &model.User{}, &model.Role{}, &model.UserRole{},
+		&model.Dict{}, &model.DictItem{}, &model.ExportJob{})
+	os.Exit(m.Run())
+}
@@ -0,0 +1,138 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"seedgo/internal/db"
+	"seedgo/internal/model"
+	"seedgo/internal/shared"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrExportNotFound = errors.New("export job not found")
+	ErrExportNotReady = errors.New("export job is not finished")
+)
+
+type Service struct {
+	*shared.BaseService[model.ExportJob]
+}
+
+func NewService() *Service {
+	return &Service{
+		BaseService: shared.NewBaseService[model.ExportJob](),
+	}
+}
+
+// 单例模式
+var (
+	instance *Service
+	once     sync.Once
+)
+
+// GetService 获取单例实例
+func GetService() *Service {
+	once.Do(func() {
+		instance = NewService()
+	})
+	return instance
+}
+
+// ListOwn 用户自己的导出任务，最近的在前
+func (s *Service) ListOwn(ctx context.Context, userID model.ID) ([]*model.ExportJob, error) {
+	var jobs []*model.ExportJob
+	err := s.DB.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Limit(100).Find(&jobs).Error
+	return jobs, err
+}
+
+// GetOwn 获取用户自己的导出任务
+func (s *Service) GetOwn(ctx context.Context, userID, id model.ID) (*model.ExportJob, error) {
+	var job model.ExportJob
+	if err := s.DB.WithContext(ctx).Where("user_id = ?", userID).First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExportNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// File 获取已完成的导出文件路径
+func (s *Service) File(ctx context.Context, userID, id model.ID) (*model.ExportJob, error) {
+	job, err := s.GetOwn(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != model.ExportSuccess || job.File == "" {
+		return nil, ErrExportNotReady
+	}
+	return job, nil
+}
+
+// DeleteOwn 删除用户自己的导出任务和文件
+func (s *Service) DeleteOwn(ctx context.Context, userID, id model.ID) error {
+	job, err := s.GetOwn(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.DB.WithContext(ctx).Unscoped().Delete(job).Error; err != nil {
+		return err
+	}
+	removeFile(job)
+	return nil
+}
+
+// CleanExpired 删除完成时间超过保留时间的导出任务和文件
+func (s *Service) CleanExpired(ctx context.Context, retention time.Duration) (int, error) {
+	ctx = db.WithoutTenant(ctx, "clean expired export jobs")
+	var jobs []*model.ExportJob
+	err := s.DB.WithContext(ctx).Where("finished_at IS NOT NULL AND finished_at < ?", time.Now().Add(-retention)).Find(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return 0, err
+	}
+	for _, job := range jobs {
+		if err := s.DB.WithContext(ctx).Unscoped().Delete(job).Error; err != nil {
+			return 0, err
+		}
+		removeFile(job)
+	}
+	return len(jobs), nil
+}
+
+func removeFile(job *model.ExportJob) {
+	if job.File == "" {
+		return
+	}
+	if err := os.Remove(job.File); err != nil && !os.IsNotExist(err) {
+		log.Printf("删除导出文件 %s 失败: %v", job.File, err)
+	}
+}
+
+// StartCleanWorker 启动后台清理任务，按保留时间的十分之一检查，ctx 结束时退出
+func StartCleanWorker(ctx context.Context, retention time.Duration) {
+	interval := retention / 10
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if n, err := GetService().CleanExpired(ctx, retention); err != nil {
+					log.Printf("清理过期导出任务失败: %v", err)
+				} else if n > 0 {
+					log.Printf("清理过期导出任务 %d 个", n)
+				}
+			}
+		}
+	}()
+}
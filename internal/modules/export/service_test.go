@@ -0,0 +1,113 @@
+package export_test
+
+import (
+	"bytes"
+	"os"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/export"
+	"seedgo/internal/modules/user"
+	"seedgo/internal/shared"
+	"seedgo/pkg"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+func TestExportList(t *testing.T) {
+	dict := &model.Dict{Code: "common_status", Name: "通用状态", Items: []*model.DictItem{{Label: "启用", Value: "1"}, {Label: "停用", Value: "0"}}}
+	require.NoError(t, dbtest.Seed().Create(dict).Error)
+	tenantA := dbtest.Tenant("A").ID
+	alice, admin := dbtest.User(tenantA, "alice"), dbtest.Super(tenantA, "admin")
+	dbtest.User(dbtest.Tenant("B").ID, "bob")
+
+	exporter := &shared.Exporter[model.User]{
+		Logic:   user.GetService(),
+		Columns: pkg.ExportColumns(&model.User{}, []string{"username", "status", "passwordHash"}),
+		Scopes: []func(*gorm.DB) *gorm.DB{func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("username IN ?", []string{"alice", "admin", "bob"})
+		}},
+	}
+	// 未声明导出的字段忽略
+	require.Len(t, exporter.Columns, 2)
+
+	// 按租户隔离，字典值翻译为标签
+	ctx := dbtest.UserCtx(alice)
+	var buf bytes.Buffer
+	rows, err := exporter.Write(ctx, &buf, shared.ExportCSV)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, rows)
+	assert.Equal(t, "\xEF\xBB\xBF用户名,状态\nalice,启用\nadmin,启用\n", buf.String())
+
+	buf.Reset()
+	_, err = exporter.Write(ctx, &buf, shared.ExportXLSX)
+	require.NoError(t, err)
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	sheet, err := f.GetRows("Sheet1")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"用户名", "状态"}, {"alice", "启用"}, {"admin", "启用"}}, sheet)
+
+	// 后台导出，只有发起的用户可以下载
+	global.Config.Export.Dir = t.TempDir()
+	defer func() { global.Config.Export.Dir = "" }()
+	job, err := shared.StartExportJob(ctx, "users", shared.ExportCSV, 2, exporter)
+	require.NoError(t, err)
+	svc := export.GetService()
+	require.Eventually(t, func() bool {
+		current, err := svc.GetOwn(ctx, alice.ID, job.ID)
+		return err == nil && current.Status == model.ExportSuccess
+	}, 5*time.Second, 20*time.Millisecond)
+	_, err = svc.File(dbtest.UserCtx(admin), admin.ID, job.ID)
+	assert.ErrorIs(t, err, export.ErrExportNotFound)
+	done, err := svc.File(ctx, alice.ID, job.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, done.Rows)
+	content, err := os.ReadFile(done.File)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(content), "alice,启用\nadmin,启用\n"))
+
+	require.NoError(t, svc.DeleteOwn(ctx, alice.ID, job.ID))
+	_, err = os.Stat(done.File)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExportSortAndEscape(t *testing.T) {
+	tenantID := dbtest.Tenant("排序").ID
+	for _, name := range []string{"sort-b", "=1+1", "sort-a", "@sum", "sort-c"} {
+		dbtest.User(tenantID, name)
+	}
+	global.Config.Export.BatchSize = 2
+	defer func() { global.Config.Export.BatchSize = 0 }()
+
+	exporter := &shared.Exporter[model.User]{
+		Logic:    user.GetService(),
+		Columns:  pkg.ExportColumns(&model.User{}, []string{"username"}),
+		Scopes:   []func(*gorm.DB) *gorm.DB{func(tx *gorm.DB) *gorm.DB { return tx.Where("tenant_id = ?", tenantID) }},
+		SortBy:   "username",
+		SortDesc: true,
+	}
+	ctx := dbtest.UserCtx(dbtest.Super(tenantID, "sort-admin"))
+
+	// 分批读取时保持列表的排序，公式开头的文本加上单引号
+	var buf bytes.Buffer
+	rows, err := exporter.Write(ctx, &buf, shared.ExportCSV)
+	require.NoError(t, err)
+	assert.EqualValues(t, 6, rows)
+	assert.Equal(t, "\xEF\xBB\xBF用户名\nsort-c\nsort-b\nsort-admin\nsort-a\n'@sum\n'=1+1\n", buf.String())
+
+	buf.Reset()
+	_, err = exporter.Write(ctx, &buf, shared.ExportXLSX)
+	require.NoError(t, err)
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	sheet, err := f.GetRows("Sheet1")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"用户名"}, {"sort-c"}, {"sort-b"}, {"sort-admin"}, {"sort-a"}, {"'@sum"}, {"'=1+1"}}, sheet)
+}
@@ -18,12 +18,13 @@ func NewHandler() *Handler {
 	return h
 }
 
-// Use 注册路由，支持查询和删除
+// Use 注册路由，支持查询、删除和导出
 func (h *Handler) Use(g *gin.RouterGroup) {
 	g.GET("", h.List)
 	g.GET("/:id", h.Get)
 	g.DELETE("/:id", h.Delete)
 	g.POST("/batch-delete", h.BatchDelete)
+	g.GET("/export/:format", h.ExportList)
 }
 
 func (h *Handler) BeforeList(ctx *gin.Context) []func(*gorm.DB) *gorm.DB {
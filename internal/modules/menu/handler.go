@@ -0,0 +1,38 @@
+package menu
+
+import (
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+	"seedgo/internal/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	logic *Service
+	*shared.BaseHandler[model.Menu]
+}
+
+func NewHandler() *Handler {
+	logic := GetService()
+	h := &Handler{
+		logic: logic,
+	}
+	h.BaseHandler = shared.NewBaseHandler[model.Menu](logic, nil, h)
+	return h
+}
+
+func (h *Handler) Use(g *gin.RouterGroup) {
+	g.GET("/tree", h.GetTree)
+	h.BaseHandler.Use(g)
+}
+
+// GetTree 获取完整菜单树，用于菜单管理
+func (h *Handler) GetTree(ctx *gin.Context) {
+	tree, err := h.logic.GetTree(ctx.Request.Context())
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, tree)
+}
@@ -0,0 +1,191 @@
+package menu
+
+import (
+	"context"
+	"errors"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/perms"
+	"seedgo/internal/scope"
+	"seedgo/internal/shared"
+	"sync"
+)
+
+type Service struct {
+	*shared.BaseService[model.Menu]
+}
+
+func NewService() *Service {
+	s := &Service{
+		BaseService: shared.NewBaseService[model.Menu](),
+	}
+	s.Impl = s
+	return s
+}
+
+// 单例模式
+var (
+	instance *Service
+	once     sync.Once
+)
+
+// GetService 获取单例实例
+func GetService() *Service {
+	once.Do(func() {
+		instance = NewService()
+	})
+	return instance
+}
+
+// RouteMenu 前端路由结构，可以直接交给前端路由注册
+type RouteMenu struct {
+	Path      string       `json:"path"`
+	Name      string       `json:"name,omitempty"`
+	Component string       `json:"component,omitempty"`
+	Redirect  string       `json:"redirect,omitempty"`
+	Meta      RouteMeta    `json:"meta"`
+	Children  []*RouteMenu `json:"children,omitempty"`
+}
+
+// RouteMeta 路由元信息
+type RouteMeta struct {
+	Title          string `json:"title"`
+	Icon           string `json:"icon,omitempty"`
+	Hidden         bool   `json:"hidden"`
+	KeepAlive      bool   `json:"keepAlive"`
+	Link           string `json:"link,omitempty"`
+	PermissionCode string `json:"permissionCode,omitempty"`
+}
+
+// Create 创建菜单，关联的权限必须存在
+func (s *Service) Create(ctx context.Context, entity *model.Menu) error {
+	if err := s.checkPermission(ctx, entity.PermissionCode); err != nil {
+		return err
+	}
+	return s.BaseService.Create(ctx, entity)
+}
+
+// Update 更新菜单，关联的权限必须存在
+func (s *Service) Update(ctx context.Context, entity *model.Menu) error {
+	if entity.ParentID != nil && *entity.ParentID == entity.ID {
+		return errors.New("menu cannot be its own parent")
+	}
+	if err := s.checkPermission(ctx, entity.PermissionCode); err != nil {
+		return err
+	}
+	return s.BaseService.Update(ctx, entity)
+}
+
+// Delete 删除菜单，存在子菜单时不允许删除
+func (s *Service) Delete(ctx context.Context, id model.ID) error {
+	var count int64
+	if err := s.Conn(ctx).Model(&model.Menu{}).Where("parent_id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("menu has children")
+	}
+	return s.BaseService.Delete(ctx, id)
+}
+
+// checkPermission 检查关联的权限编码是否存在
+func (s *Service) checkPermission(ctx context.Context, code string) error {
+	if code == "" {
+		return nil
+	}
+	var count int64
+	if err := s.Conn(ctx).Model(&model.Permission{}).Where("permission_code = ?", code).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("permission code not found")
+	}
+	return nil
+}
+
+// GetTree 获取完整菜单树
+func (s *Service) GetTree(ctx context.Context) ([]*model.Menu, error) {
+	var all []*model.Menu
+	if err := s.DB.WithContext(ctx).Order("sort").Order("id").Find(&all).Error; err != nil {
+		return nil, err
+	}
+	return buildTree(all), nil
+}
+
+// GetUserMenus 获取用户可见的菜单路由
+// 菜单关联的权限需要在用户的有效权限中（拒绝的权限不在其中），超级用户可以看到全部菜单
+func (s *Service) GetUserMenus(ctx context.Context, user *scope.UserContext) ([]*RouteMenu, error) {
+	tree, err := s.GetTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := func(code string) bool { return true }
+	if !user.IsSuper {
+		userPerms, err := perms.GetService().GetCachePerms(user)
+		if err != nil {
+			return nil, err
+		}
+		codes := make(map[string]bool)
+		collectCodes(userPerms.Tree, codes)
+		allowed = func(code string) bool { return code == "" || codes[code] }
+	}
+
+	return toRoutes(tree, allowed), nil
+}
+
+// toRoutes 过滤没有权限的菜单并转换为路由结构
+// 没有组件和外链的目录，如果子菜单全部被过滤，目录也不显示
+func toRoutes(menus []*model.Menu, allowed func(code string) bool) []*RouteMenu {
+	routes := make([]*RouteMenu, 0, len(menus))
+	for _, m := range menus {
+		if !allowed(m.PermissionCode) {
+			continue
+		}
+		children := toRoutes(m.Children, allowed)
+		if m.Component == "" && m.Link == "" && len(m.Children) > 0 && len(children) == 0 {
+			continue
+		}
+		routes = append(routes, &RouteMenu{
+			Path:      m.Path,
+			Name:      m.Name,
+			Component: m.Component,
+			Redirect:  m.Redirect,
+			Meta: RouteMeta{
+				Title:          m.Title,
+				Icon:           m.Icon,
+				Hidden:         m.Visible != nil && !*m.Visible,
+				KeepAlive:      m.KeepAlive != nil && *m.KeepAlive,
+				Link:           m.Link,
+				PermissionCode: m.PermissionCode,
+			},
+			Children: children,
+		})
+	}
+	return routes
+}
+
+// collectCodes 收集权限树中的权限编码
+func collectCodes(tree []*model.Permission, codes map[string]bool) {
+	for _, p := range tree {
+		if p.PermissionCode != "" {
+			codes[p.PermissionCode] = true
+		}
+		collectCodes(p.Children, codes)
+	}
+}
+
+func buildTree(menus []*model.Menu) []*model.Menu {
+	var roots []*model.Menu
+	menuMap := make(map[model.ID]*model.Menu)
+	for _, m := range menus {
+		menuMap[m.ID] = m
+	}
+	for _, m := range menus {
+		if m.ParentID == nil || *m.ParentID == 0 {
+			roots = append(roots, m)
+		} else if parent, ok := menuMap[*m.ParentID]; ok {
+			parent.Children = append(parent.Children, m)
+		}
+	}
+	return roots
+}
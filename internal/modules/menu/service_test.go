@@ -0,0 +1,32 @@
+package menu
+
+import (
+	"seedgo/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToRoutes(t *testing.T) {
+	system, external := model.ID(1), model.ID(4)
+	hidden := false
+	menus := buildTree([]*model.Menu{
+		{ID: system, Name: "System", Title: "menu.system", Path: "/system"},
+		{ID: 2, ParentID: &system, Name: "Users", Path: "/system/users", Component: "system/UserList", PermissionCode: "system:user"},
+		{ID: 3, ParentID: &system, Name: "Roles", Path: "/system/roles", Component: "system/RoleList", PermissionCode: "system:role", Visible: &hidden},
+		{ID: external, Name: "Docs", Link: "https://example.com"},
+	})
+
+	// 只有角色权限：用户菜单被过滤，隐藏的角色菜单仍然返回
+	routes := toRoutes(menus, func(code string) bool { return code == "" || code == "system:role" })
+	assert.Len(t, routes, 2)
+	assert.Len(t, routes[0].Children, 1)
+	assert.Equal(t, "Roles", routes[0].Children[0].Name)
+	assert.True(t, routes[0].Children[0].Meta.Hidden)
+	assert.Equal(t, "https://example.com", routes[1].Meta.Link)
+
+	// 子菜单全部没有权限时，目录也不显示
+	routes = toRoutes(menus, func(code string) bool { return code == "" })
+	assert.Len(t, routes, 1)
+	assert.Equal(t, "Docs", routes[0].Name)
+}
@@ -0,0 +1,115 @@
+package perms
+
+import (
+	"seedgo/internal/model"
+	"strings"
+	"time"
+)
+
+// UserPerms 用户的有效权限
+type UserPerms struct {
+	// Tree 允许访问的权限树
+	Tree []*model.Permission `json:"tree"`
+	// Denied 拒绝规则，匹配时优先于 Tree
+	Denied []DenyRule `json:"denied,omitempty"`
+	// ExpiresAt 下一次角色分配生效或失效的时间，缓存不能超过该时间
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// Version 计算时的租户权限版本号
+	Version int64 `json:"version"`
+}
+
+// DenyRule 拒绝规则
+type DenyRule struct {
+	// Path 匹配的路径（PermissionUrls 中的一项）
+	Path string `json:"path"`
+	// Suffix 拒绝的操作后缀，如 :delete；为空表示拒绝该路径的所有方法
+	Suffix string `json:"suffix,omitempty"`
+}
+
+// ResolveEffects 根据角色授权计算每个权限的最终效果
+// 规则：
+//  1. 任意角色拒绝即拒绝（deny-overrides）
+//  2. 拒绝一个节点，同时拒绝它的所有子孙节点
+//  3. 未出现在结果中的权限表示未授权
+func ResolveEffects(all []*model.Permission, grants []*model.RolePermission) map[model.ID]string {
+	children := make(map[model.ID][]model.ID)
+	for _, p := range all {
+		if p.ParentID != nil && *p.ParentID != 0 {
+			children[*p.ParentID] = append(children[*p.ParentID], p.ID)
+		}
+	}
+
+	effects := make(map[model.ID]string)
+	for _, g := range grants {
+		if g.Effect != model.EffectDeny && effects[g.PermissionID] == "" {
+			effects[g.PermissionID] = model.EffectAllow
+		}
+	}
+
+	// 拒绝向下传递给子孙节点
+	var deny func(id model.ID)
+	deny = func(id model.ID) {
+		if effects[id] == model.EffectDeny {
+			return
+		}
+		effects[id] = model.EffectDeny
+		for _, child := range children[id] {
+			deny(child)
+		}
+	}
+	for _, g := range grants {
+		if g.Effect == model.EffectDeny {
+			deny(g.PermissionID)
+		}
+	}
+	return effects
+}
+
+// buildDenyRules 把被拒绝的权限转换为拒绝规则
+// 页面/接口节点拒绝整个路径，按钮节点（没有路径）拒绝父节点路径上对应的操作
+func buildDenyRules(all []*model.Permission, effects map[model.ID]string) []DenyRule {
+	permMap := make(map[model.ID]*model.Permission)
+	for _, p := range all {
+		permMap[p.ID] = p
+	}
+
+	var rules []DenyRule
+	for _, p := range all {
+		if effects[p.ID] != model.EffectDeny {
+			continue
+		}
+		if paths := permissionPaths(p); len(paths) > 0 {
+			for _, path := range paths {
+				rules = append(rules, DenyRule{Path: path})
+			}
+			continue
+		}
+
+		if p.ParentID == nil {
+			continue
+		}
+		parent, ok := permMap[*p.ParentID]
+		if !ok {
+			continue
+		}
+		idx := strings.LastIndex(p.PermissionCode, ":")
+		if idx == -1 {
+			continue
+		}
+		for _, path := range permissionPaths(parent) {
+			rules = append(rules, DenyRule{Path: path, Suffix: p.PermissionCode[idx:]})
+		}
+	}
+	return rules
+}
+
+// permissionPaths 获取权限可匹配的所有路径
+func permissionPaths(p *model.Permission) []string {
+	var paths []string
+	for _, u := range strings.Split(p.PermissionUrls, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			paths = append(paths, u)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,42 @@
+package perms
+
+import (
+	"seedgo/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveEffects(t *testing.T) {
+	root, users := model.ID(1), model.ID(2)
+	all := []*model.Permission{
+		{ID: root, Name: "系统管理", PermissionUrls: "/system"},
+		{ID: users, ParentID: &root, Name: "用户管理", PermissionUrls: "/system/users"},
+		{ID: 3, ParentID: &users, Name: "新增", PermissionCode: "system:user:create"},
+		{ID: 4, ParentID: &users, Name: "删除", PermissionCode: "system:user:delete"},
+	}
+
+	// 一个角色允许用户管理全部操作，另一个角色拒绝删除
+	grants := []*model.RolePermission{
+		{RoleID: 1, PermissionID: 2, Effect: model.EffectAllow},
+		{RoleID: 1, PermissionID: 3, Effect: model.EffectAllow},
+		{RoleID: 1, PermissionID: 4, Effect: model.EffectAllow},
+		{RoleID: 2, PermissionID: 4, Effect: model.EffectDeny},
+	}
+	effects := ResolveEffects(all, grants)
+	assert.Equal(t, model.EffectAllow, effects[2])
+	assert.Equal(t, model.EffectAllow, effects[3])
+	assert.Equal(t, model.EffectDeny, effects[4])
+	_, ok := effects[1]
+	assert.False(t, ok)
+
+	rules := buildDenyRules(all, effects)
+	assert.Equal(t, []DenyRule{{Path: "/system/users", Suffix: ":delete"}}, rules)
+
+	// 拒绝父节点会传递给所有子节点
+	grants = append(grants, &model.RolePermission{RoleID: 2, PermissionID: 1, Effect: model.EffectDeny})
+	effects = ResolveEffects(all, grants)
+	for _, p := range all {
+		assert.Equal(t, model.EffectDeny, effects[p.ID])
+	}
+}
@@ -2,6 +2,7 @@ package perms
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"seedgo/internal/model"
@@ -19,6 +20,8 @@ type Handler struct {
 // 实现这个方法可以重新
 func (c *Handler) Use(g *gin.RouterGroup) {
 	g.GET("/tree", c.GetTree)
+	g.GET("/export", c.Export)
+	g.POST("/import", c.Import)
 	c.BaseHandler.Use(g)
 	log.Println("Registering perms routes")
 }
@@ -38,9 +41,9 @@ func (c *Handler) Update(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id := model.ToID(idStr)
 
-	// 绑定JSON请求体到Permission数据传输对象
+	// 绑定JSON请求体到Permission数据传输对象，只保留可写字段
 	var dto model.Permission
-	if err := ctx.ShouldBindJSON(&dto); err != nil {
+	if err := c.BindWritable(ctx, &dto); err != nil {
 		scope.Fail(ctx, fmt.Sprintf("Invalid parameters:%v", err.Error()))
 		return
 	}
@@ -48,7 +51,7 @@ func (c *Handler) Update(ctx *gin.Context) {
 
 	// 调用业务逻辑层更新权限信息
 	if err := c.logic.Update(ctx.Request.Context(), &dto); err != nil {
-		scope.Fail(ctx, err.Error())
+		scope.FailWithError(ctx, err)
 		return
 	}
 
@@ -93,6 +96,75 @@ func (c *Handler) GetTree(ctx *gin.Context) {
 	scope.OkWithData(ctx, tree)
 }
 
+// Export 导出权限树，format 为 yaml（默认）或 json，仅超级用户可用
+func (c *Handler) Export(ctx *gin.Context) {
+	if !scope.RequireSuper(ctx) {
+		return
+	}
+
+	format := ctx.DefaultQuery("format", "yaml")
+	file, err := c.logic.Export(ctx.Request.Context())
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	data, err := MarshalPermissionFile(file, format)
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+
+	contentType := "application/x-yaml"
+	if format == "json" {
+		contentType = "application/json"
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=permissions.%s", format))
+	ctx.Data(http.StatusOK, contentType, data)
+}
+
+// Import 导入权限树，支持上传文件（file 字段）或直接提交请求体
+// 查询参数 dryRun=true 只返回差异，prune=true 删除文件中不存在的权限，仅超级用户可用
+func (c *Handler) Import(ctx *gin.Context) {
+	if !scope.RequireSuper(ctx) {
+		return
+	}
+
+	var data []byte
+	if fh, err := ctx.FormFile("file"); err == nil {
+		f, err := fh.Open()
+		if err != nil {
+			scope.Fail(ctx, err.Error())
+			return
+		}
+		defer f.Close()
+		if data, err = io.ReadAll(f); err != nil {
+			scope.Fail(ctx, err.Error())
+			return
+		}
+	} else if data, err = ctx.GetRawData(); err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+
+	file, err := UnmarshalPermissionFile(data)
+	if err != nil {
+		scope.Fail(ctx, fmt.Sprintf("Invalid permission file:%v", err.Error()))
+		return
+	}
+
+	opts := ImportOptions{
+		DryRun: ctx.Query("dryRun") == "true",
+		Prune:  ctx.Query("prune") == "true",
+	}
+	diff, err := c.logic.Import(ctx.Request.Context(), file, opts)
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+
+	scope.OkWithData(ctx, diff)
+}
+
 func NewHandler() *Handler {
 	logic := GetService()
 	ctrl := &Handler{
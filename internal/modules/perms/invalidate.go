@@ -0,0 +1,200 @@
+package perms
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"seedgo/internal/db"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+)
+
+// 权限缓存失效
+//
+// 只清除受影响用户的缓存，不再整体清除 auth:permissions。
+// 每个租户维护一个权限版本号，缓存的权限记录计算时的版本号；用户失效时记录最低可用版本号，
+// 低于该版本的缓存视为旧数据，防止多实例部署时，失效后又被其他实例并发计算的旧结果写回。
+var (
+	// versionKey 租户权限版本号，不放在 auth:permissions 前缀下，避免被整体清除后版本号回退
+	versionKey = "auth:perm_version:%d"
+	// minVersionKey 用户最低可用版本号
+	minVersionKey = "auth:permissions:min:%s"
+)
+
+// tenantVersion 获取租户当前权限版本号
+func tenantVersion(tenantID model.ID) int64 {
+	var version int64
+	_ = global.Cache.Get(fmt.Sprintf(versionKey, tenantID), &version)
+	return version
+}
+
+// minVersion 获取用户最低可用版本号
+func minVersion(userID model.ID) int64 {
+	var version int64
+	_ = global.Cache.Get(fmt.Sprintf(minVersionKey, userID.String()), &version)
+	return version
+}
+
+// InvalidateUsers 使指定用户的权限缓存失效
+func (s *Service) InvalidateUsers(userIDs ...model.ID) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	// 超级用户修改的角色、权限可能影响多个租户的用户
+	ctx := db.WithoutTenant(context.Background(), "group users by tenant for permission cache invalidation")
+	var users []*model.User
+	if err := s.DB.WithContext(ctx).Select("id", "tenant_id").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return err
+	}
+
+	byTenant := make(map[model.ID][]model.ID)
+	for _, u := range users {
+		byTenant[u.TenantID] = append(byTenant[u.TenantID], u.ID)
+	}
+
+	for tenantID, ids := range byTenant {
+		version, err := global.Cache.Incr(fmt.Sprintf(versionKey, tenantID))
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := global.Cache.Set(fmt.Sprintf(minVersionKey, id.String()), version); err != nil {
+				return err
+			}
+			if err := global.Cache.Delete(fmt.Sprintf(cacheKey, id.String())); err != nil {
+				return err
+			}
+		}
+		log.Printf("清除租户 %d 下 %d 个用户的权限缓存，版本号 %d", tenantID, len(ids), version)
+	}
+	return nil
+}
+
+// PurgeTenantCache 清除租户的权限缓存和版本号，租户数据清除后调用
+func (s *Service) PurgeTenantCache(tenantID model.ID, userIDs []model.ID) error {
+	for _, id := range userIDs {
+		if err := global.Cache.Delete(fmt.Sprintf(cacheKey, id.String())); err != nil {
+			return err
+		}
+		if err := global.Cache.Delete(fmt.Sprintf(minVersionKey, id.String())); err != nil {
+			return err
+		}
+	}
+	return global.Cache.Delete(fmt.Sprintf(versionKey, tenantID))
+}
+
+// InvalidateRoles 使拥有指定角色的用户权限缓存失效
+func (s *Service) InvalidateRoles(roleIDs ...model.ID) error {
+	if len(roleIDs) == 0 {
+		return nil
+	}
+	var userIDs []model.ID
+	if err := s.DB.Model(&model.UserRole{}).Distinct().Where("role_id IN ?", roleIDs).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+	return s.InvalidateUsers(userIDs...)
+}
+
+// AffectedUsers 获取权限变化会影响到的用户
+// 包括授权了该权限、祖先或子孙权限的角色下的用户（拒绝会向下传递，树结构依赖父子关系），以及所有超级用户
+func (s *Service) AffectedUsers(ctx context.Context, permIDs ...model.ID) ([]model.ID, error) {
+	var all []*model.Permission
+	if err := s.Conn(ctx).Find(&all).Error; err != nil {
+		return nil, err
+	}
+
+	parents := make(map[model.ID]model.ID)
+	children := make(map[model.ID][]model.ID)
+	for _, p := range all {
+		if p.ParentID != nil && *p.ParentID != 0 {
+			parents[p.ID] = *p.ParentID
+			children[*p.ParentID] = append(children[*p.ParentID], p.ID)
+		}
+	}
+
+	related := make(map[model.ID]bool)
+	var down func(id model.ID)
+	down = func(id model.ID) {
+		if related[id] {
+			return
+		}
+		related[id] = true
+		for _, child := range children[id] {
+			down(child)
+		}
+	}
+	for _, id := range permIDs {
+		down(id)
+		for parent, ok := parents[id]; ok && !related[parent]; parent, ok = parents[parent] {
+			related[parent] = true
+		}
+	}
+
+	ids := make([]model.ID, 0, len(related))
+	for id := range related {
+		ids = append(ids, id)
+	}
+
+	var userIDs []model.ID
+	err := s.DB.Model(&model.UserRole{}).Distinct().
+		Where("role_id IN (?)", s.DB.Model(&model.RolePermission{}).Select("role_id").Where("permission_id IN ?", ids)).
+		Pluck("user_id", &userIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// 超级用户可能属于任意租户
+	var supers []model.ID
+	superCtx := db.WithoutTenant(ctx, "find super users for permission cache invalidation")
+	if err := s.Conn(superCtx).Model(&model.User{}).Where("is_super = ?", true).Pluck("id", &supers).Error; err != nil {
+		return nil, err
+	}
+	return append(userIDs, supers...), nil
+}
+
+// InvalidatePermissions 使权限变化影响到的用户权限缓存失效
+func (s *Service) InvalidatePermissions(ctx context.Context, permIDs ...model.ID) error {
+	userIDs, err := s.AffectedUsers(ctx, permIDs...)
+	if err != nil {
+		return err
+	}
+	return s.InvalidateUsers(userIDs...)
+}
+
+// Create 创建权限，新权限只影响超级用户
+func (s *Service) Create(ctx context.Context, entity *model.Permission) error {
+	if err := s.BaseService.Create(ctx, entity); err != nil {
+		return err
+	}
+	return s.InvalidatePermissions(ctx, entity.ID)
+}
+
+// Update 更新权限，父节点可能变化，更新前后受影响的用户都需要失效
+func (s *Service) Update(ctx context.Context, entity *model.Permission) error {
+	before, err := s.AffectedUsers(ctx, entity.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.BaseService.Update(ctx, entity); err != nil {
+		return err
+	}
+	after, err := s.AffectedUsers(ctx, entity.ID)
+	if err != nil {
+		return err
+	}
+	return s.InvalidateUsers(append(before, after...)...)
+}
+
+// Delete 删除权限
+func (s *Service) Delete(ctx context.Context, id model.ID) error {
+	affected, err := s.AffectedUsers(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.BaseService.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.InvalidateUsers(affected...)
+}
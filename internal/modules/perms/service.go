@@ -1,12 +1,15 @@
 package perms
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"seedgo/internal/db"
 	"seedgo/internal/global"
 	"seedgo/internal/model"
 	"seedgo/internal/scope"
 	"seedgo/internal/shared"
+	"strings"
 	"sync"
 	"time"
 )
@@ -20,6 +23,7 @@ func NewService() *Service {
 		shared.NewBaseService[model.Permission](),
 	}
 	logic.DB = logic.BaseService.DB
+	logic.Impl = logic
 	return logic
 }
 
@@ -55,68 +59,180 @@ func (s *Service) ClearPermissionAllCache() error {
 
 // GetCacheTree 获取权限树，有缓存默认30分钟，频繁请求会续期
 func (s *Service) GetCacheTree(user *scope.UserContext) ([]*model.Permission, error) {
+	perms, err := s.GetCachePerms(user)
+	if err != nil {
+		return nil, err
+	}
+	return perms.Tree, nil
+}
+
+// GetCachePerms 获取用户有效权限（权限树和拒绝规则），有缓存默认30分钟，频繁请求会续期
+func (s *Service) GetCachePerms(user *scope.UserContext) (*UserPerms, error) {
 	// key 格式: auth:permissions:{userId}
-	cacheKey := "auth:permissions:" + user.ID.String()
+	cacheKey := fmt.Sprintf(cacheKey, user.ID.String())
 	ttl := 30 * time.Minute // 合理的过期时间
 
-	var perms []*model.Permission
-
-	err := global.Cache.Call(cacheKey, &perms, func() (any, error) {
+	var perms UserPerms
+	load := func() (any, error) {
 		log.Printf("获取角色权限，缓存初始化。%s\n", cacheKey)
-		return s.GetAllPerms(user)
-	}, ttl)
-
-	_ = global.Cache.Expire(cacheKey, ttl)
+		// 先取版本号再计算，计算期间发生的失效会使结果作废
+		version := tenantVersion(user.TenantID)
+		p, err := s.GetUserPerms(user)
+		if err != nil {
+			return nil, err
+		}
+		p.Version = version
+		return p, nil
+	}
 
-	if err != nil {
+	if err := global.Cache.Call(cacheKey, &perms, load, ttl); err != nil {
 		return nil, err
 	}
 
-	return perms, err
+	// 低于最低可用版本号的缓存是失效前计算的旧数据，重新计算
+	if perms.Version < minVersion(user.ID) {
+		_ = global.Cache.Delete(cacheKey)
+		perms = UserPerms{}
+		if err := global.Cache.Call(cacheKey, &perms, load, ttl); err != nil {
+			return nil, err
+		}
+	}
 
+	// 有角色分配即将生效或失效时，续期不能超过该时间
+	if perms.ExpiresAt != nil {
+		until := time.Until(*perms.ExpiresAt)
+		if until <= 0 {
+			_ = global.Cache.Delete(cacheKey)
+			return s.GetUserPerms(user)
+		}
+		if until < ttl {
+			ttl = until
+		}
+	}
+	_ = global.Cache.Expire(cacheKey, ttl)
+
+	return &perms, nil
 }
 
 // GetAllPerms 获取所有权限，无缓存
 func (s *Service) GetAllPerms(user *scope.UserContext) ([]*model.Permission, error) {
-	var perms []*model.Permission
-	var err error
+	perms, err := s.GetUserPerms(user)
+	if err != nil {
+		return nil, err
+	}
+	return perms.Tree, nil
+}
 
-	// 1. 获取所有相关权限
+// GetUserPerms 获取用户有效权限，无缓存
+// 多个角色的授权合并后按 deny-overrides 计算，拒绝的权限不会出现在权限树中
+func (s *Service) GetUserPerms(user *scope.UserContext) (*UserPerms, error) {
+	// 1. 获取所有权限
+	var all []*model.Permission
+	if err := s.DB.Order("sort").Find(&all).Error; err != nil {
+		return nil, err
+	}
+
+	// 超级管理员获取所有权限
 	if user.IsSuper {
-		// 超级管理员获取所有权限
-		err = s.DB.Order("sort").Find(&perms).Error
+		return &UserPerms{Tree: buildTree(all)}, nil
+	}
 
-	} else {
+	// 租户主账号拥有平台权限以外的所有权限，不受角色影响
+	main, err := s.isMain(user)
+	if err != nil {
+		return nil, err
+	}
+	if main {
+		var tenantPerms []*model.Permission
+		for _, p := range all {
+			if !IsPlatformPermission(p.PermissionCode) {
+				tenantPerms = append(tenantPerms, p)
+			}
+		}
+		return &UserPerms{Tree: buildTree(tenantPerms)}, nil
+	}
 
-		//如果是普通用户，需要把角色信息填充到用户中
-		var userModel model.User
-		err = s.DB.Preload("Roles").First(&userModel, user.ID).Error
-		if err != nil {
+	// 2. 普通用户根据当前生效的角色获取授权，不在有效期内的角色分配忽略
+	roles, expiresAt, err := s.GetActiveRoles(db.WithTenant(context.Background(), user.TenantID), user.ID)
+	if err != nil {
+		return nil, err
+	}
+	//把角色信息填充到用户中
+	user.Roles = roles
+
+	var roleIDs []model.ID
+	for _, role := range roles {
+		roleIDs = append(roleIDs, role.ID)
+	}
+
+	var grants []*model.RolePermission
+	if len(roleIDs) > 0 {
+		if err := s.DB.Where("role_id IN ?", roleIDs).Find(&grants).Error; err != nil {
 			return nil, err
 		}
-		user.Roles = userModel.Roles
+	}
 
-		// 普通用户根据角色获取权限
-		var roleIDs []uint64
-		for _, role := range user.Roles {
-			roleIDs = append(roleIDs, uint64(role.ID))
+	// 3. 计算最终效果，构建树形结构
+	effects := ResolveEffects(all, grants)
+	var allowed []*model.Permission
+	for _, p := range all {
+		if effects[p.ID] == model.EffectAllow {
+			allowed = append(allowed, p)
 		}
+	}
+
+	return &UserPerms{
+		Tree:      buildTree(allowed),
+		Denied:    buildDenyRules(all, effects),
+		ExpiresAt: expiresAt,
+	}, nil
+}
 
-		if len(roleIDs) > 0 {
-			err = s.DB.Distinct("permission.*").
-				Joins("JOIN role_permission ON role_permission.permission_id = permission.id").
-				Where("role_permission.role_id IN ?", roleIDs).
-				Order("sort").
-				Find(&perms).Error
+// isMain 用户是否为租户主账号，从数据库读取，主账号转让后权限缓存失效即可生效
+func (s *Service) isMain(user *scope.UserContext) (bool, error) {
+	var count int64
+	err := s.DB.WithContext(db.WithTenant(context.Background(), user.TenantID)).Model(&model.User{}).
+		Where("id = ? AND is_main = ?", user.ID, 1).Count(&count).Error
+	return count > 0, err
+}
+
+// IsPlatformPermission 权限编码是否为平台权限（配置的编码或其子权限）
+func IsPlatformPermission(code string) bool {
+	for _, prefix := range global.Config.Permission.PlatformCodes {
+		if code == prefix || strings.HasPrefix(code, prefix+":") {
+			return true
 		}
 	}
+	return false
+}
 
-	if err != nil {
-		return nil, err
+// GetActiveRoles 获取用户当前生效的角色，以及下一次角色分配生效或失效的时间
+// 角色分配生效或失效时，权限缓存需要同时过期
+func (s *Service) GetActiveRoles(ctx context.Context, userID model.ID) ([]*model.Role, *time.Time, error) {
+	now := time.Now()
+	var assignments []*model.UserRole
+	if err := s.DB.WithContext(ctx).Where("user_id = ?", userID).Find(&assignments).Error; err != nil {
+		return nil, nil, err
 	}
 
-	// 2. 构建树形结构
-	return buildTree(perms), nil
+	var roleIDs []model.ID
+	var expiresAt *time.Time
+	for _, a := range assignments {
+		if a.Active(now) {
+			roleIDs = append(roleIDs, a.RoleID)
+		}
+		if next := a.NextChange(now); next != nil && (expiresAt == nil || next.Before(*expiresAt)) {
+			expiresAt = next
+		}
+	}
+
+	roles := make([]*model.Role, 0, len(roleIDs))
+	if len(roleIDs) > 0 {
+		if err := s.DB.WithContext(ctx).Where("id IN ?", roleIDs).Find(&roles).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	return roles, expiresAt, nil
 }
 
 func buildTree(perms []*model.Permission) []*model.Permission {
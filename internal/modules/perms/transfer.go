@@ -0,0 +1,361 @@
+package perms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"seedgo/internal/model"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+	"gorm.io/gorm"
+)
+
+// PermissionFileVersion 权限文件格式版本
+const PermissionFileVersion = 1
+
+// PermissionFile 权限树导入导出文件，按 PermissionCode 关联，不包含数据库 ID，可以在分支和环境之间合并
+type PermissionFile struct {
+	Version     int               `json:"version" yaml:"version"`
+	Permissions []*PermissionNode `json:"permissions" yaml:"permissions"`
+}
+
+// PermissionNode 权限树节点
+type PermissionNode struct {
+	Code string `json:"code" yaml:"code"`
+	Name string `json:"name" yaml:"name"`
+	// Path 旧文件中页面的接口路径前缀，导入时作为 Urls 的第一项，导出时不再写入
+	Path     string            `json:"path,omitempty" yaml:"path,omitempty"`
+	Sort     *int              `json:"sort,omitempty" yaml:"sort,omitempty"`
+	Type     *int              `json:"type,omitempty" yaml:"type,omitempty"`
+	Urls     []string          `json:"urls,omitempty" yaml:"urls,omitempty"`
+	Children []*PermissionNode `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	// DryRun 只计算差异，不写入
+	DryRun bool `json:"dryRun"`
+	// Prune 删除文件中不存在的权限
+	Prune bool `json:"prune"`
+}
+
+// ImportDiff 导入差异
+type ImportDiff struct {
+	Created   []string         `json:"created"`
+	Updated   []*UpdatedChange `json:"updated"`
+	Deleted   []string         `json:"deleted"`
+	Unchanged int              `json:"unchanged"`
+	DryRun    bool             `json:"dryRun"`
+}
+
+// UpdatedChange 更新的权限及变化的字段
+type UpdatedChange struct {
+	Code   string   `json:"code"`
+	Fields []string `json:"fields"`
+}
+
+// Export 导出完整权限树
+func (s *Service) Export(ctx context.Context) (*PermissionFile, error) {
+	var all []*model.Permission
+	if err := s.DB.WithContext(ctx).Order("sort").Order("id").Find(&all).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make(map[string]bool)
+	ids := make(map[model.ID]bool)
+	for _, p := range all {
+		ids[p.ID] = true
+	}
+	for _, p := range all {
+		// 父节点不存在的权限作为根节点导出，避免丢失
+		if p.ParentID != nil && !ids[*p.ParentID] {
+			p.ParentID = nil
+		}
+		if p.PermissionCode == "" {
+			return nil, fmt.Errorf("permission %q (id %d) has no permissionCode", p.Name, p.ID)
+		}
+		if codes[p.PermissionCode] {
+			return nil, fmt.Errorf("duplicate permissionCode %q", p.PermissionCode)
+		}
+		codes[p.PermissionCode] = true
+	}
+
+	var toNodes func(perms []*model.Permission) []*PermissionNode
+	toNodes = func(perms []*model.Permission) []*PermissionNode {
+		nodes := make([]*PermissionNode, 0, len(perms))
+		for _, p := range perms {
+			node := &PermissionNode{
+				Code: p.PermissionCode,
+				Name: p.Name,
+				Sort: p.Sort,
+				Type: p.Type,
+				Urls: permissionUrls(p.PermissionUrls),
+			}
+			if len(p.Children) > 0 {
+				node.Children = toNodes(p.Children)
+			}
+			nodes = append(nodes, node)
+		}
+		return nodes
+	}
+
+	return &PermissionFile{
+		Version:     PermissionFileVersion,
+		Permissions: toNodes(buildTree(all)),
+	}, nil
+}
+
+// importPlan 导入计划，先计算再执行，保证 DryRun 和实际导入结果一致
+type importPlan struct {
+	creates []*planCreate
+	updates []*planUpdate
+	deletes []*model.Permission
+}
+
+type planCreate struct {
+	perm       *model.Permission
+	parentCode string
+}
+
+type planUpdate struct {
+	perm       *model.Permission
+	parentCode string
+	columns    []string
+}
+
+// Import 按 PermissionCode 导入权限树：存在则更新，不存在则创建，Prune 时删除文件中没有的权限
+func (s *Service) Import(ctx context.Context, file *PermissionFile, opts ImportOptions) (*ImportDiff, error) {
+	if file == nil || file.Version != PermissionFileVersion {
+		return nil, fmt.Errorf("unsupported permission file version, expected %d", PermissionFileVersion)
+	}
+
+	var existing []*model.Permission
+	if err := s.DB.WithContext(ctx).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	plan, diff, err := planImport(existing, file, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun {
+		return diff, nil
+	}
+	if len(plan.creates) == 0 && len(plan.updates) == 0 && len(plan.deletes) == 0 {
+		return diff, nil
+	}
+
+	// 修改和删除前计算受影响的用户
+	var touched []model.ID
+	for _, u := range plan.updates {
+		touched = append(touched, u.perm.ID)
+	}
+	for _, p := range plan.deletes {
+		touched = append(touched, p.ID)
+	}
+	affected, err := s.AffectedUsers(ctx, touched...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids := make(map[string]model.ID)
+		for _, p := range existing {
+			ids[p.PermissionCode] = p.ID
+		}
+		parentID := func(code string) *model.ID {
+			if code == "" {
+				return nil
+			}
+			id := ids[code]
+			return &id
+		}
+
+		// 按先序创建，父节点一定先于子节点
+		for _, c := range plan.creates {
+			c.perm.ParentID = parentID(c.parentCode)
+			if err := tx.Create(c.perm).Error; err != nil {
+				return err
+			}
+			ids[c.perm.PermissionCode] = c.perm.ID
+		}
+		for _, u := range plan.updates {
+			u.perm.ParentID = parentID(u.parentCode)
+			if err := tx.Model(&model.Permission{ID: u.perm.ID}).Select(u.columns).Updates(u.perm).Error; err != nil {
+				return err
+			}
+		}
+		if len(plan.deletes) > 0 {
+			var deleteIDs []model.ID
+			for _, p := range plan.deletes {
+				deleteIDs = append(deleteIDs, p.ID)
+			}
+			if err := tx.Where("permission_id IN ?", deleteIDs).Delete(&model.RolePermission{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("id IN ?", deleteIDs).Delete(&model.Permission{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 新建的权限只影响超级用户，AffectedUsers 已包含
+	return diff, s.InvalidateUsers(affected...)
+}
+
+// planImport 计算导入计划和差异
+func planImport(existing []*model.Permission, file *PermissionFile, opts ImportOptions) (*importPlan, *ImportDiff, error) {
+	byCode := make(map[string]*model.Permission)
+	codeOf := make(map[model.ID]string)
+	for _, p := range existing {
+		codeOf[p.ID] = p.PermissionCode
+		if p.PermissionCode == "" {
+			continue
+		}
+		if _, ok := byCode[p.PermissionCode]; ok {
+			return nil, nil, fmt.Errorf("duplicate permissionCode %q in database", p.PermissionCode)
+		}
+		byCode[p.PermissionCode] = p
+	}
+
+	plan := &importPlan{}
+	diff := &ImportDiff{
+		Created: []string{},
+		Updated: []*UpdatedChange{},
+		Deleted: []string{},
+		DryRun:  opts.DryRun,
+	}
+	seen := make(map[string]bool)
+
+	var walk func(nodes []*PermissionNode, parentCode string) error
+	walk = func(nodes []*PermissionNode, parentCode string) error {
+		for _, node := range nodes {
+			code := strings.TrimSpace(node.Code)
+			if code == "" {
+				return fmt.Errorf("permission %q has no code", node.Name)
+			}
+			if seen[code] {
+				return fmt.Errorf("duplicate code %q in file", code)
+			}
+			seen[code] = true
+
+			urls := node.Urls
+			if node.Path != "" && !slices.Contains(urls, node.Path) {
+				urls = append([]string{node.Path}, urls...)
+			}
+			target := &model.Permission{
+				Name:           node.Name,
+				PermissionCode: code,
+				Sort:           node.Sort,
+				Type:           node.Type,
+				PermissionUrls: strings.Join(urls, ","),
+			}
+
+			current, ok := byCode[code]
+			if !ok {
+				plan.creates = append(plan.creates, &planCreate{perm: target, parentCode: parentCode})
+				diff.Created = append(diff.Created, code)
+			} else {
+				var currentParent string
+				if current.ParentID != nil {
+					currentParent = codeOf[*current.ParentID]
+				}
+				fields, columns := changedFields(current, target, currentParent, parentCode)
+				if len(fields) > 0 {
+					target.ID = current.ID
+					plan.updates = append(plan.updates, &planUpdate{perm: target, parentCode: parentCode, columns: columns})
+					diff.Updated = append(diff.Updated, &UpdatedChange{Code: code, Fields: fields})
+				} else {
+					diff.Unchanged++
+				}
+			}
+
+			if err := walk(node.Children, code); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(file.Permissions, ""); err != nil {
+		return nil, nil, err
+	}
+
+	if opts.Prune {
+		for _, p := range existing {
+			if !seen[p.PermissionCode] {
+				plan.deletes = append(plan.deletes, p)
+				diff.Deleted = append(diff.Deleted, p.PermissionCode)
+			}
+		}
+	}
+
+	return plan, diff, nil
+}
+
+// changedFields 比较两个权限的差异，返回字段名（json）和数据库列名
+func changedFields(current, target *model.Permission, currentParent, targetParent string) ([]string, []string) {
+	var fields, columns []string
+	add := func(field, column string) {
+		fields = append(fields, field)
+		columns = append(columns, column)
+	}
+	if currentParent != targetParent {
+		add("parentId", "parent_id")
+	}
+	if current.Name != target.Name {
+		add("name", "name")
+	}
+	if !reflect.DeepEqual(current.Sort, target.Sort) {
+		add("sort", "sort")
+	}
+	if !reflect.DeepEqual(current.Type, target.Type) {
+		add("type", "type")
+	}
+	if strings.Join(permissionUrls(current.PermissionUrls), ",") != target.PermissionUrls {
+		add("permissionUrls", "permission_urls")
+	}
+	return fields, columns
+}
+
+// permissionUrls 把逗号分隔的 PermissionUrls 拆分为数组
+func permissionUrls(urls string) []string {
+	var list []string
+	for _, u := range strings.Split(urls, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			list = append(list, u)
+		}
+	}
+	return list
+}
+
+// MarshalPermissionFile 按格式序列化权限文件，format 为 yaml 或 json
+func MarshalPermissionFile(file *PermissionFile, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		return json.MarshalIndent(file, "", "  ")
+	case "", "yaml", "yml":
+		return yaml.Marshal(file)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// UnmarshalPermissionFile 解析权限文件，YAML 兼容 JSON，两种格式都可以直接解析
+func UnmarshalPermissionFile(data []byte) (*PermissionFile, error) {
+	var file PermissionFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if len(file.Permissions) == 0 {
+		return nil, errors.New("permission file is empty")
+	}
+	return &file, nil
+}
@@ -0,0 +1,57 @@
+package perms
+
+import (
+	"seedgo/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanImport(t *testing.T) {
+	root := model.ID(1)
+	sort := 1
+	existing := []*model.Permission{
+		{ID: root, Name: "系统管理", PermissionUrls: "/system", PermissionCode: "system", Sort: &sort},
+		{ID: 2, ParentID: &root, Name: "用户", PermissionUrls: "/system/users", PermissionCode: "system:user"},
+		{ID: 3, ParentID: &root, Name: "旧菜单", PermissionCode: "system:legacy"},
+	}
+
+	data := []byte(`
+version: 1
+permissions:
+  - code: system
+    name: 系统管理
+    path: /system
+    sort: 1
+    children:
+      - code: system:user
+        name: 用户管理
+        path: /system/users
+        children:
+          - code: system:user:delete
+            name: 删除
+            urls: [DELETE /api/system/users/:id]
+`)
+	file, err := UnmarshalPermissionFile(data)
+	assert.NoError(t, err)
+
+	_, diff, err := planImport(existing, file, ImportOptions{DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"system:user:delete"}, diff.Created)
+	assert.Len(t, diff.Updated, 1)
+	assert.Equal(t, "system:user", diff.Updated[0].Code)
+	assert.Equal(t, []string{"name"}, diff.Updated[0].Fields)
+	assert.Empty(t, diff.Deleted)
+	assert.Equal(t, 1, diff.Unchanged)
+
+	// prune 删除文件中不存在的权限
+	plan, diff, err := planImport(existing, file, ImportOptions{Prune: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"system:legacy"}, diff.Deleted)
+	assert.Equal(t, "system:user", plan.creates[0].parentCode)
+
+	// 重复的 code 直接拒绝
+	file.Permissions = append(file.Permissions, &PermissionNode{Code: "system", Name: "重复"})
+	_, _, err = planImport(existing, file, ImportOptions{})
+	assert.Error(t, err)
+}
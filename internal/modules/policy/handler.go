@@ -0,0 +1,50 @@
+package policy
+
+import (
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+	"seedgo/internal/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	logic *Service
+	*shared.BaseHandler[model.Policy]
+}
+
+func NewHandler() *Handler {
+	logic := GetService()
+	h := &Handler{
+		logic: logic,
+	}
+	h.BaseHandler = shared.NewBaseHandler[model.Policy](logic, nil, h)
+	return h
+}
+
+func (h *Handler) Use(g *gin.RouterGroup) {
+	g.POST("/evaluate", h.Evaluate)
+	h.BaseHandler.Use(g)
+}
+
+// EvaluateDTO 测试策略的参数
+type EvaluateDTO struct {
+	Action   string         `json:"action" binding:"required"`
+	Resource map[string]any `json:"resource"`
+}
+
+// Evaluate 使用当前用户和请求测试某个动作的策略
+func (h *Handler) Evaluate(ctx *gin.Context) {
+	var dto EvaluateDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		scope.Fail(ctx, "Invalid parameters")
+		return
+	}
+
+	decision, err := h.logic.Authorize(ctx, dto.Action, dto.Resource)
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, decision)
+}
@@ -0,0 +1,249 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"seedgo/internal/db"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/perms"
+	"seedgo/internal/scope"
+	"seedgo/internal/shared"
+	engine "seedgo/pkg/policy"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Service struct {
+	*shared.BaseService[model.Policy]
+}
+
+func NewService() *Service {
+	s := &Service{
+		BaseService: shared.NewBaseService[model.Policy](),
+	}
+	s.Impl = s
+	return s
+}
+
+// 单例模式
+var (
+	instance *Service
+	once     sync.Once
+)
+
+// GetService 获取单例实例
+func GetService() *Service {
+	once.Do(func() {
+		instance = NewService()
+	})
+	return instance
+}
+
+var cacheKey = "policy:%d:%s"
+
+// Input 策略执行的输入
+type Input struct {
+	User     *scope.UserContext
+	IP       string
+	Time     time.Time
+	Method   string
+	Path     string
+	Resource map[string]any
+}
+
+// NewInput 从请求构建输入，resource 为业务资源属性
+func NewInput(c *gin.Context, resource map[string]any) Input {
+	return Input{
+		User:     scope.GetCurrentUser(c),
+		IP:       c.ClientIP(),
+		Time:     time.Now(),
+		Method:   c.Request.Method,
+		Path:     c.Request.URL.Path,
+		Resource: resource,
+	}
+}
+
+// Env 转换为表达式执行环境
+func (in Input) Env() engine.Env {
+	user := map[string]any{}
+	if in.User != nil {
+		var roles []string
+		for _, r := range in.User.Roles {
+			roles = append(roles, r.Name)
+		}
+		user = map[string]any{
+			"id":       in.User.ID,
+			"username": in.User.Username,
+			"tenantId": in.User.TenantID,
+			"isSuper":  in.User.IsSuper,
+			"roles":    roles,
+		}
+	}
+	return engine.Env{
+		"user": user,
+		"request": map[string]any{
+			"ip":     in.IP,
+			"time":   in.Time,
+			"method": in.Method,
+			"path":   in.Path,
+		},
+		"resource": in.Resource,
+	}
+}
+
+// Decision 策略执行结果
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Policy  string `json:"policy,omitempty"` // 决定结果的策略名称
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Decide 用给定的策略执行判断，不访问数据库，便于单元测试
+// 规则：
+//  1. 任意拒绝策略命中即拒绝
+//  2. 存在允许策略时，至少命中一条才允许；没有允许策略表示该动作不受限制
+//  3. 表达式执行出错按拒绝处理
+func Decide(policies []*model.Policy, env engine.Env) *Decision {
+	var allows []*model.Policy
+	for _, p := range policies {
+		if p.Effect != model.EffectDeny {
+			allows = append(allows, p)
+			continue
+		}
+		matched, err := evalPolicy(p, env)
+		if err != nil {
+			return &Decision{Policy: p.Name, Reason: err.Error()}
+		}
+		if matched {
+			return &Decision{Policy: p.Name, Reason: "denied by policy"}
+		}
+	}
+
+	if len(allows) == 0 {
+		return &Decision{Allowed: true}
+	}
+	for _, p := range allows {
+		matched, err := evalPolicy(p, env)
+		if err != nil {
+			log.Printf("策略执行失败 %s: %v", p.Name, err)
+			continue
+		}
+		if matched {
+			return &Decision{Allowed: true, Policy: p.Name}
+		}
+	}
+	return &Decision{Reason: "no allow policy matched"}
+}
+
+func evalPolicy(p *model.Policy, env engine.Env) (bool, error) {
+	expr, err := engine.CompileCached(p.Expression)
+	if err != nil {
+		return false, err
+	}
+	return expr.EvalBool(env)
+}
+
+// Evaluate 执行当前租户下某个动作的所有启用策略，超级用户不受策略限制
+func (s *Service) Evaluate(ctx context.Context, action string, input Input) (*Decision, error) {
+	if input.User == nil {
+		return &Decision{Reason: "unauthorized"}, nil
+	}
+	if input.User.IsSuper {
+		return &Decision{Allowed: true}, nil
+	}
+	// 权限缓存命中时 UserContext 没有角色信息，这里补充
+	if input.User.Roles == nil {
+		roles, _, err := perms.GetService().GetActiveRoles(db.WithTenant(ctx, input.User.TenantID), input.User.ID)
+		if err != nil {
+			return nil, err
+		}
+		input.User.Roles = roles
+	}
+
+	policies, err := s.getCachePolicies(input.User.TenantID, action)
+	if err != nil {
+		return nil, err
+	}
+	return Decide(policies, input.Env()), nil
+}
+
+// Authorize 在 handler 中调用，使用当前请求执行策略
+func (s *Service) Authorize(c *gin.Context, action string, resource map[string]any) (*Decision, error) {
+	return s.Evaluate(c.Request.Context(), action, NewInput(c, resource))
+}
+
+// getCachePolicies 获取租户某个动作的启用策略，缓存10分钟
+func (s *Service) getCachePolicies(tenantID model.ID, action string) ([]*model.Policy, error) {
+	var policies []*model.Policy
+	key := fmt.Sprintf(cacheKey, tenantID, action)
+	err := global.Cache.Call(key, &policies, func() (any, error) {
+		var list []*model.Policy
+		ctx := db.WithTenant(context.Background(), tenantID)
+		err := s.DB.WithContext(ctx).Where("action = ? AND status = 1", action).Find(&list).Error
+		return list, err
+	}, 10*time.Minute)
+	return policies, err
+}
+
+// clearCache 清除当前租户的策略缓存，超级用户可能修改任意租户，清除全部
+func (s *Service) clearCache(ctx context.Context) error {
+	user := scope.GetUserFromContext(ctx)
+	if user == nil || user.IsSuper {
+		return global.Cache.DeletePrefix("policy:")
+	}
+	return global.Cache.DeletePrefix(fmt.Sprintf("policy:%d:", user.TenantID))
+}
+
+// ClearTenantCache 清除指定租户的策略缓存
+func (s *Service) ClearTenantCache(tenantID model.ID) error {
+	return global.Cache.DeletePrefix(fmt.Sprintf("policy:%d:", tenantID))
+}
+
+// validate 校验策略的效果和表达式
+func validate(entity *model.Policy) error {
+	if entity.Effect == "" {
+		entity.Effect = model.EffectAllow
+	}
+	if entity.Effect != model.EffectAllow && entity.Effect != model.EffectDeny {
+		return errors.New("effect must be allow or deny")
+	}
+	if _, err := engine.Compile(entity.Expression); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Create 创建策略，保存前校验表达式
+func (s *Service) Create(ctx context.Context, entity *model.Policy) error {
+	if err := validate(entity); err != nil {
+		return err
+	}
+	if err := s.BaseService.Create(ctx, entity); err != nil {
+		return err
+	}
+	return s.clearCache(ctx)
+}
+
+// Update 更新策略，保存前校验表达式
+func (s *Service) Update(ctx context.Context, entity *model.Policy) error {
+	if err := validate(entity); err != nil {
+		return err
+	}
+	if err := s.BaseService.Update(ctx, entity); err != nil {
+		return err
+	}
+	return s.clearCache(ctx)
+}
+
+// Delete 删除策略
+func (s *Service) Delete(ctx context.Context, id model.ID) error {
+	if err := s.BaseService.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.clearCache(ctx)
+}
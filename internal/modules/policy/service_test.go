@@ -0,0 +1,43 @@
+package policy
+
+import (
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecide(t *testing.T) {
+	input := Input{
+		User:     &scope.UserContext{ID: 1, TenantID: 2, Roles: []*model.Role{{Name: "finance"}}},
+		IP:       "192.168.1.10",
+		Time:     time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local),
+		Resource: map[string]any{"amount": 8000},
+	}
+	underLimit := &model.Policy{Name: "under 10k", Effect: model.EffectAllow, Expression: "resource.amount < 10000"}
+	officeOnly := &model.Policy{Name: "office only", Effect: model.EffectDeny, Expression: "!cidr(request.ip, '192.168.0.0/16')"}
+
+	// 没有策略不受限制
+	assert.True(t, Decide(nil, input.Env()).Allowed)
+
+	d := Decide([]*model.Policy{underLimit, officeOnly}, input.Env())
+	assert.True(t, d.Allowed)
+	assert.Equal(t, "under 10k", d.Policy)
+
+	// 拒绝策略优先
+	input.IP = "8.8.8.8"
+	d = Decide([]*model.Policy{underLimit, officeOnly}, input.Env())
+	assert.False(t, d.Allowed)
+	assert.Equal(t, "office only", d.Policy)
+
+	// 允许策略都不命中
+	input.IP = "192.168.1.10"
+	input.Resource["amount"] = 20000
+	assert.False(t, Decide([]*model.Policy{underLimit}, input.Env()).Allowed)
+
+	// 角色属性
+	financeOnly := &model.Policy{Name: "finance", Effect: model.EffectAllow, Expression: "'finance' in user.roles"}
+	assert.True(t, Decide([]*model.Policy{financeOnly}, input.Env()).Allowed)
+}
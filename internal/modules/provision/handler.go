@@ -0,0 +1,52 @@
+package provision
+
+import (
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+	"seedgo/internal/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler 租户开通模板管理
+type Handler struct {
+	logic *Service
+	*shared.BaseHandler[model.TenantTemplate]
+}
+
+func NewHandler() *Handler {
+	logic := GetService()
+	h := &Handler{
+		logic: logic,
+	}
+	h.BaseHandler = shared.NewBaseHandler[model.TenantTemplate](logic, nil, h)
+	return h
+}
+
+func (h *Handler) Use(g *gin.RouterGroup) {
+	g.POST("/:id/apply", h.Apply)
+	h.BaseHandler.Use(g)
+}
+
+// ApplyDTO 重新应用模板的参数，tenantIds 为空时应用到使用该模板且版本落后的租户
+type ApplyDTO struct {
+	TenantIds []model.ID `json:"tenantIds"`
+}
+
+// Apply 把模板应用到已有租户，仅超级用户可用
+func (h *Handler) Apply(ctx *gin.Context) {
+	if !scope.RequireSuper(ctx) {
+		return
+	}
+	var dto ApplyDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		scope.Fail(ctx, "Invalid parameters")
+		return
+	}
+	results, err := h.logic.Apply(ctx.Request.Context(), model.ToID(ctx.Param("id")), dto.TenantIds)
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, results)
+}
@@ -0,0 +1,15 @@
+package provision_test
+
+import (
+	"os"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/model"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	dbtest.Open(&model.Tenant{}, &model.User{}, &model.Role{}, &model.Permission{},
+		&model.RolePermission{}, &model.UserRole{}, &model.Setting{}, &model.TenantSetting{},
+		&model.TenantTemplate{}, &model.Dict{}, &model.DictItem{})
+	os.Exit(m.Run())
+}
@@ -0,0 +1,367 @@
+package provision
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"seedgo/internal/db"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/perms"
+	"seedgo/internal/modules/setting"
+	"seedgo/internal/shared"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrTemplateNotFound = errors.New("tenant template not found")
+
+type Service struct {
+	*shared.BaseService[model.TenantTemplate]
+}
+
+func NewService() *Service {
+	s := &Service{
+		BaseService: shared.NewBaseService[model.TenantTemplate](),
+	}
+	s.Impl = s
+	return s
+}
+
+// 单例模式
+var (
+	instance *Service
+	once     sync.Once
+)
+
+// GetService 获取单例实例
+func GetService() *Service {
+	once.Do(func() {
+		instance = NewService()
+	})
+	return instance
+}
+
+// ApplyResult 模板应用到一个租户的结果
+type ApplyResult struct {
+	TenantID model.ID `json:"tenantId"`
+	Version  int      `json:"version"` // 应用的模板内容版本
+	Error    string   `json:"error,omitempty"`
+}
+
+// Create 创建模板，内容版本从 1 开始
+func (s *Service) Create(ctx context.Context, entity *model.TenantTemplate) error {
+	if err := s.validate(ctx, entity); err != nil {
+		return err
+	}
+	entity.ContentVersion = 1
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entity).Error; err != nil {
+			return err
+		}
+		return s.resetDefault(tx, entity)
+	})
+}
+
+// Update 更新模板，内容变化时内容版本号加 1，提交了 version 时按乐观锁更新
+func (s *Service) Update(ctx context.Context, entity *model.TenantTemplate) error {
+	if err := s.validate(ctx, entity); err != nil {
+		return err
+	}
+	old, err := s.BaseService.Get(ctx, entity.ID)
+	if err != nil {
+		return err
+	}
+	columns, err := s.WritableColumns(ctx, entity)
+	if err != nil {
+		return err
+	}
+	return s.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		entity.ContentVersion = old.ContentVersion
+		if !sameContent(old.Content, entity.Content) {
+			entity.ContentVersion++
+		}
+		err := s.Versioned(tx, entity, func(tx *gorm.DB) *gorm.DB {
+			return tx.Select(append(columns, "content_version")).Omit("created_at").Save(entity)
+		})
+		if err != nil {
+			return err
+		}
+		return s.resetDefault(tx, entity)
+	})
+}
+
+// resetDefault 只能有一个默认模板
+func (s *Service) resetDefault(tx *gorm.DB, entity *model.TenantTemplate) error {
+	if !entity.IsDefault {
+		return nil
+	}
+	return tx.Model(&model.TenantTemplate{}).Where("id <> ? AND is_default = ?", entity.ID, true).
+		Update("is_default", false).Error
+}
+
+func sameContent(a, b model.TemplateContent) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return bytes.Equal(x, y)
+}
+
+// validate 校验模板内容：角色名称不重复，权限编码和配置项存在，配置值符合规则
+func (s *Service) validate(ctx context.Context, entity *model.TenantTemplate) error {
+	if entity.Code == "" || entity.Name == "" {
+		return errors.New("template code and name are required")
+	}
+	content := entity.Content
+
+	names := map[string]bool{}
+	var codes []string
+	for _, role := range content.Roles {
+		if role.Name == "" {
+			return errors.New("template role name is required")
+		}
+		if names[role.Name] {
+			return fmt.Errorf("duplicate template role %s", role.Name)
+		}
+		names[role.Name] = true
+		codes = append(append(codes, role.Permissions...), role.Deny...)
+	}
+	permissionIDs, err := s.permissionIDs(s.Conn(ctx), codes)
+	if err != nil {
+		return err
+	}
+	for _, code := range codes {
+		if _, ok := permissionIDs[code]; !ok {
+			return fmt.Errorf("unknown permission %s", code)
+		}
+	}
+
+	for _, dict := range content.Dicts {
+		if dict.Code == "" || dict.Name == "" {
+			return errors.New("template dict code and name are required")
+		}
+	}
+
+	for key, value := range content.Settings {
+		var def model.Setting
+		if err := s.Conn(ctx).Where("`key` = ?", key).First(&def).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("unknown setting %s", key)
+			}
+			return err
+		}
+		if !def.Overridable {
+			return fmt.Errorf("setting %s is not overridable", key)
+		}
+		if _, err := setting.Validate(&def, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Provision 在创建租户的事务中初始化租户数据，使用租户指定的模板或默认模板，都没有时不处理
+func (s *Service) Provision(ctx context.Context, tx *gorm.DB, tenant *model.Tenant) error {
+	var tpl model.TenantTemplate
+	query := tx.Session(&gorm.Session{NewDB: true})
+	if tenant.TemplateID != nil {
+		query = query.Where("id = ?", *tenant.TemplateID)
+	} else {
+		query = query.Where("is_default = ?", true)
+	}
+	if err := query.First(&tpl).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if tenant.TemplateID != nil {
+				return ErrTemplateNotFound
+			}
+			return nil
+		}
+		return err
+	}
+	_, err := s.apply(tx.WithContext(db.WithTenant(ctx, tenant.ID)), tenant.ID, &tpl)
+	if err != nil {
+		return err
+	}
+	tenant.TemplateID = &tpl.ID
+	tenant.TemplateVersion = tpl.ContentVersion
+	return nil
+}
+
+// Apply 把模板重新应用到已有租户，tenantIDs 为空时应用到使用该模板且版本落后的租户
+// 每个租户一个事务，只补充缺少的角色、权限、字典项和配置，不修改租户已有的数据
+func (s *Service) Apply(ctx context.Context, templateID model.ID, tenantIDs []model.ID) ([]*ApplyResult, error) {
+	tpl, err := s.BaseService.Get(ctx, templateID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+	if len(tenantIDs) == 0 {
+		err := s.DB.WithContext(ctx).Model(&model.Tenant{}).
+			Where("template_id = ? AND template_version < ?", tpl.ID, tpl.ContentVersion).
+			Pluck("id", &tenantIDs).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]*ApplyResult, 0, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		result := &ApplyResult{TenantID: tenantID, Version: tpl.ContentVersion}
+		results = append(results, result)
+
+		var roleIDs []model.ID
+		err := s.DB.WithContext(db.WithTenant(ctx, tenantID)).Transaction(func(tx *gorm.DB) error {
+			ids, err := s.apply(tx, tenantID, tpl)
+			if err != nil {
+				return err
+			}
+			roleIDs = ids
+			return tx.Model(&model.Tenant{}).Where("id = ?", tenantID).
+				Updates(map[string]any{"template_id": tpl.ID, "template_version": tpl.ContentVersion}).Error
+		})
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		if err := perms.GetService().InvalidateRoles(roleIDs...); err != nil {
+			log.Printf("清除租户 %d 的权限缓存失败: %v", tenantID, err)
+		}
+		if err := setting.GetService().ClearTenantCache(tenantID); err != nil {
+			log.Printf("清除租户 %d 的配置缓存失败: %v", tenantID, err)
+		}
+	}
+	return results, nil
+}
+
+// apply 在事务中写入模板内容，tx 需要带有该租户的上下文，返回模板中的角色 ID
+func (s *Service) apply(tx *gorm.DB, tenantID model.ID, tpl *model.TenantTemplate) ([]model.ID, error) {
+	content := tpl.Content
+	var codes []string
+	for _, role := range content.Roles {
+		codes = append(append(codes, role.Permissions...), role.Deny...)
+	}
+	permissionIDs, err := s.permissionIDs(tx, codes)
+	if err != nil {
+		return nil, err
+	}
+	var mainIDs []model.ID
+	if err := tx.Model(&model.User{}).Where("is_main = ?", 1).Pluck("id", &mainIDs).Error; err != nil {
+		return nil, err
+	}
+
+	roleIDs := make([]model.ID, 0, len(content.Roles))
+	for _, item := range content.Roles {
+		var role model.Role
+		err := tx.Where("name = ?", item.Name).First(&role).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			role = model.Role{Name: item.Name}
+			if item.Description != "" {
+				role.Description = &item.Description
+			}
+			role.TenantID = tenantID
+			err = tx.Omit(clause.Associations).Create(&role).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+		roleIDs = append(roleIDs, role.ID)
+
+		// 拒绝先写入，同一个权限同时出现在允许和拒绝中时以拒绝为准
+		var rows []model.RolePermission
+		for _, grant := range []struct {
+			codes  []string
+			effect string
+		}{{item.Deny, model.EffectDeny}, {item.Permissions, model.EffectAllow}} {
+			for _, code := range grant.codes {
+				id, ok := permissionIDs[code]
+				if !ok {
+					log.Printf("模板 %s 的权限 %s 不存在，跳过", tpl.Code, code)
+					continue
+				}
+				rows = append(rows, model.RolePermission{RoleID: role.ID, PermissionID: id, Effect: grant.effect})
+			}
+		}
+		if len(rows) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+				return nil, err
+			}
+		}
+
+		if item.Main && len(mainIDs) > 0 {
+			assignments := make([]model.UserRole, 0, len(mainIDs))
+			for _, userID := range mainIDs {
+				assignments = append(assignments, model.UserRole{UserID: userID, RoleID: role.ID})
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&assignments).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, item := range content.Dicts {
+		if err := s.applyDict(tx, item); err != nil {
+			return nil, err
+		}
+	}
+
+	for key, value := range content.Settings {
+		if err := setting.GetService().InitTenantValue(tx, tenantID, key, value); err != nil {
+			return nil, fmt.Errorf("setting %s: %w", key, err)
+		}
+	}
+	return roleIDs, nil
+}
+
+// applyDict 字典所有租户共用，不存在时创建，补充缺少的字典项
+func (s *Service) applyDict(tx *gorm.DB, item model.TemplateDict) error {
+	var dict model.Dict
+	err := tx.Where("code = ?", item.Code).First(&dict).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		dict = model.Dict{Code: item.Code, Name: item.Name}
+		err = tx.Omit(clause.Associations).Create(&dict).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	var values []string
+	if err := tx.Model(&model.DictItem{}).Where("dict_id = ?", dict.ID).Pluck("value", &values).Error; err != nil {
+		return err
+	}
+	exists := make(map[string]bool, len(values))
+	for _, v := range values {
+		exists[v] = true
+	}
+	var items []*model.DictItem
+	for _, it := range item.Items {
+		if exists[it.Value] {
+			continue
+		}
+		items = append(items, &model.DictItem{DictID: dict.ID, Label: it.Label, Value: it.Value, Sort: it.Sort, Status: 1})
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return tx.Create(&items).Error
+}
+
+// permissionIDs 权限编码对应的 ID
+func (s *Service) permissionIDs(tx *gorm.DB, codes []string) (map[string]model.ID, error) {
+	out := make(map[string]model.ID, len(codes))
+	if len(codes) == 0 {
+		return out, nil
+	}
+	var permissions []model.Permission
+	if err := tx.Where("permission_code IN ?", codes).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range permissions {
+		out[p.PermissionCode] = p.ID
+	}
+	return out, nil
+}
@@ -0,0 +1,114 @@
+package provision_test
+
+import (
+	"context"
+	"encoding/json"
+	"seedgo/internal/db"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/provision"
+	"seedgo/internal/modules/setting"
+	"seedgo/internal/modules/tenant"
+	"seedgo/internal/shared"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvisionTemplate(t *testing.T) {
+	page := &model.Permission{Name: "用户管理", PermissionUrls: "/system/users", PermissionCode: "system:user"}
+	require.NoError(t, dbtest.Seed().Create(page).Error)
+	require.NoError(t, dbtest.Seed().Create(&model.Permission{ParentID: &page.ID, Name: "删除", PermissionCode: "system:user:delete"}).Error)
+	ctx := dbtest.UserCtx(dbtest.Super(dbtest.Tenant("platform").ID, "admin"))
+	require.NoError(t, setting.GetService().Create(ctx, &model.Setting{Key: "tpl.currency", Name: "币种",
+		Type: model.SettingTypeString, Value: `"CNY"`, Overridable: true}))
+
+	svc := provision.GetService()
+	assert.Error(t, svc.Create(ctx, &model.TenantTemplate{Code: "bad", Name: "Bad", Content: model.TemplateContent{
+		Roles: []model.TemplateRole{{Name: "x", Permissions: []string{"no:such"}}},
+	}}))
+
+	tpl := &model.TenantTemplate{Code: "store", Name: "门店", IsDefault: true, Content: model.TemplateContent{
+		Roles:    []model.TemplateRole{{Name: "店长", Permissions: []string{"system:user"}, Deny: []string{"system:user:delete"}, Main: true}},
+		Dicts:    []model.TemplateDict{{Code: "tpl_level", Name: "等级", Items: []model.TemplateDictItem{{Label: "普通", Value: "1"}}}},
+		Settings: map[string]json.RawMessage{"tpl.currency": json.RawMessage(`"USD"`)},
+	}}
+	require.NoError(t, svc.Create(ctx, tpl))
+	assert.Equal(t, 1, tpl.ContentVersion)
+
+	// 创建租户时在同一个事务中初始化
+	entity := &model.Tenant{Name: "门店租户", Status: 1, Username: "store-owner", Password: "123456"}
+	require.NoError(t, tenant.GetService().Create(ctx, entity))
+	tctx := db.WithTenant(context.Background(), entity.ID)
+
+	var owner model.User
+	require.NoError(t, svc.DB.WithContext(tctx).Preload("Roles").Where("username = ?", "store-owner").First(&owner).Error)
+	require.Len(t, owner.Roles, 1)
+	assert.Equal(t, "店长", owner.Roles[0].Name)
+	var grants []model.RolePermission
+	svc.DB.Where("role_id = ?", owner.Roles[0].ID).Find(&grants)
+	assert.Len(t, grants, 2)
+	currency, err := setting.GetSetting[string](tctx, "tpl.currency")
+	require.NoError(t, err)
+	assert.Equal(t, "USD", currency)
+	var items int64
+	svc.DB.Model(&model.DictItem{}).Where("dict_id IN (?)", svc.DB.Model(&model.Dict{}).Select("id").Where("code = ?", "tpl_level")).Count(&items)
+	assert.Equal(t, int64(1), items)
+
+	// 内容变化后版本加 1，重新应用只补充缺少的数据
+	tpl.Content.Roles = append(tpl.Content.Roles, model.TemplateRole{Name: "收银员", Permissions: []string{"system:user"}})
+	require.NoError(t, svc.Update(ctx, tpl))
+	assert.Equal(t, 2, tpl.ContentVersion)
+	require.NoError(t, setting.GetService().SetTenantValue(ctx, entity.ID, "tpl.currency", json.RawMessage(`"EUR"`)))
+
+	results, err := svc.Apply(ctx, tpl.ID, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Empty(t, results[0].Error)
+
+	var roles int64
+	svc.DB.WithContext(tctx).Model(&model.Role{}).Count(&roles)
+	assert.Equal(t, int64(2), roles)
+	currency, _ = setting.GetSetting[string](tctx, "tpl.currency")
+	assert.Equal(t, "EUR", currency)
+	var applied model.Tenant
+	svc.DB.First(&applied, entity.ID)
+	assert.Equal(t, 2, applied.TemplateVersion)
+
+	// 没有落后的租户
+	results, err = svc.Apply(ctx, tpl.ID, nil)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestUpdateTemplateStaleVersion(t *testing.T) {
+	ctx := dbtest.UserCtx(dbtest.Super(dbtest.Tenant("tpl-version").ID, "tpl-version-admin"))
+	svc := provision.GetService()
+	created := &model.TenantTemplate{Code: "stale", Name: "过期"}
+	require.NoError(t, svc.Create(ctx, created))
+	load := func() *model.TenantTemplate {
+		var tpl model.TenantTemplate
+		require.NoError(t, dbtest.Seed().First(&tpl, created.ID).Error)
+		return &tpl
+	}
+	first, second := load(), load()
+	require.EqualValues(t, 1, first.Version)
+
+	// 乐观锁版本和内容版本分开计数，只改名称时内容版本不变
+	first.Name = "过期-1"
+	require.NoError(t, svc.Update(ctx, first))
+	saved := load()
+	assert.EqualValues(t, 2, saved.Version)
+	assert.Equal(t, 1, saved.ContentVersion)
+
+	// 使用旧版本号提交被拒绝，不覆盖先提交的修改
+	second.Name = "过期-2"
+	second.Content.Dicts = []model.TemplateDict{{Code: "stale_dict", Name: "字典"}}
+	var conflict *shared.ConflictError
+	require.ErrorAs(t, svc.Update(ctx, second), &conflict)
+	saved = load()
+	assert.Equal(t, "过期-1", saved.Name)
+	assert.Equal(t, 1, saved.ContentVersion)
+	assert.Empty(t, saved.Content.Dicts)
+}
@@ -0,0 +1,17 @@
+package quota
+
+import (
+	"seedgo/internal/model"
+	"seedgo/internal/shared"
+)
+
+// Handler 套餐管理
+type Handler struct {
+	shared.BaseHandler[model.Plan]
+}
+
+func NewHandler() *Handler {
+	ctr := &Handler{}
+	ctr.BaseHandler = *shared.NewBaseHandler(GetService(), nil, ctr)
+	return ctr
+}
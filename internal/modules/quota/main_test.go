@@ -0,0 +1,17 @@
+package quota_test
+
+import (
+	"os"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/quota"
+	"seedgo/internal/shared"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	dbtest.Open(&model.Plan{}, &model.Tenant{}, &model.User{}, &model.Role{},
+		&model.Permission{}, &model.RolePermission{}, &model.UserRole{})
+	shared.RegisterCreateHook(quota.GetService().CheckCreate)
+	os.Exit(m.Run())
+}
@@ -0,0 +1,210 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"seedgo/internal/db"
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+	"seedgo/internal/shared"
+	"seedgo/pkg"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Service struct {
+	*shared.BaseService[model.Plan]
+}
+
+// 单例模式
+var (
+	instance *Service
+	once     sync.Once
+)
+
+func GetService() *Service {
+	once.Do(func() {
+		instance = &Service{
+			BaseService: shared.NewBaseService[model.Plan](),
+		}
+	})
+	return instance
+}
+
+// ExceededError 超出配额
+type ExceededError struct {
+	Resource string `json:"resource"`
+	Limit    int64  `json:"limit"`
+	Used     int64  `json:"used"`
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded: %s limit %d, used %d", e.Resource, e.Limit, e.Used)
+}
+
+// Code 业务码，供 scope.FailWithError 使用
+func (e *ExceededError) Code() int {
+	return scope.QuotaExceededCode
+}
+
+// Counter 统计租户某项资源的已用量，tx 已带租户上下文
+type Counter func(tx *gorm.DB, tenantID model.ID) (int64, error)
+
+var counters = map[string]Counter{
+	model.QuotaUsers: CountModel(&model.User{}),
+	model.QuotaRoles: CountModel(&model.Role{}),
+}
+
+// RegisterCounter 注册资源用量统计，启动时调用，非并发安全
+// 没有注册统计的资源只展示上限，不做检查
+func RegisterCounter(resource string, counter Counter) {
+	counters[resource] = counter
+}
+
+// CountModel 按租户统计模型行数
+func CountModel(m any) Counter {
+	return func(tx *gorm.DB, tenantID model.ID) (int64, error) {
+		var count int64
+		err := tx.Model(m).Where("tenant_id = ?", tenantID).Count(&count).Error
+		return count, err
+	}
+}
+
+// Limits 获取租户的配额上限：套餐上限叠加租户覆盖值
+// 未出现的资源不限制，覆盖值为负数表示取消该资源的限制
+func (s *Service) Limits(ctx context.Context, tenantID model.ID) (map[string]int64, error) {
+	return loadLimits(s.DB.WithContext(ctx), tenantID)
+}
+
+func loadLimits(tx *gorm.DB, tenantID model.ID) (map[string]int64, error) {
+	var tenant model.Tenant
+	if err := tx.First(&tenant, tenantID).Error; err != nil {
+		return nil, err
+	}
+	result := map[string]int64{}
+	if tenant.PlanID != nil {
+		var plan model.Plan
+		err := tx.First(&plan, *tenant.PlanID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		for resource, limit := range plan.Limits {
+			result[resource] = limit
+		}
+	}
+	for resource, limit := range tenant.QuotaOverrides {
+		if limit < 0 {
+			delete(result, resource)
+			continue
+		}
+		result[resource] = limit
+	}
+	return result, nil
+}
+
+// Check 检查租户增加 delta 个资源后是否超出配额，超出时返回 *ExceededError
+// tx 为空时使用默认连接，在创建事务中调用时传入事务：先锁定租户行（SELECT ... FOR UPDATE），
+// 同一租户的创建事务串行执行，避免并发创建时都按旧的用量通过检查
+func (s *Service) Check(ctx context.Context, tx *gorm.DB, tenantID model.ID, resource string, delta int64) error {
+	counter, ok := counters[resource]
+	if !ok {
+		return nil
+	}
+	lock := tx != nil
+	if tx == nil {
+		tx = s.DB
+	}
+	tx = tx.WithContext(db.WithTenant(ctx, tenantID))
+	if lock {
+		var tenant model.Tenant
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).Select("id").First(&tenant, tenantID).Error; err != nil {
+			return err
+		}
+	}
+	limits, err := loadLimits(tx, tenantID)
+	if err != nil {
+		return err
+	}
+	limit, ok := limits[resource]
+	if !ok {
+		return nil
+	}
+	used, err := counter(tx, tenantID)
+	if err != nil {
+		return err
+	}
+	if used+delta > limit {
+		return &ExceededError{Resource: resource, Limit: limit, Used: used}
+	}
+	return nil
+}
+
+// CheckCreate 创建钩子，对实现 model.Quotable 的模型检查配额
+// 租户取实体的 TenantID，为空时取上下文中的租户；平台级数据（没有租户）不检查
+func (s *Service) CheckCreate(ctx context.Context, tx *gorm.DB, entity any) error {
+	quotable, ok := entity.(model.Quotable)
+	if !ok {
+		return nil
+	}
+	var tenantID model.ID
+	if value, err := pkg.GetFieldValue(entity, model.FieldTenantID); err == nil {
+		tenantID, _ = value.(model.ID)
+	}
+	if tenantID == 0 {
+		tenantID, _, _ = db.TenantScope(ctx)
+	}
+	if tenantID == 0 {
+		return nil
+	}
+	return s.Check(ctx, tx, tenantID, quotable.QuotaResource(), 1)
+}
+
+// Usage 资源用量，Limit 为空表示不限制，Tracked 表示是否统计了用量
+type Usage struct {
+	Resource  string `json:"resource"`
+	Used      int64  `json:"used"`
+	Limit     *int64 `json:"limit"`
+	Remaining *int64 `json:"remaining"`
+	Tracked   bool   `json:"tracked"`
+}
+
+// Usage 获取租户各项资源的用量和上限
+func (s *Service) Usage(ctx context.Context, tenantID model.ID) ([]*Usage, error) {
+	limits, err := s.Limits(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	resources := map[string]bool{}
+	for resource := range limits {
+		resources[resource] = true
+	}
+	for resource := range counters {
+		resources[resource] = true
+	}
+
+	tx := s.DB.WithContext(db.WithTenant(ctx, tenantID))
+	result := make([]*Usage, 0, len(resources))
+	for resource := range resources {
+		usage := &Usage{Resource: resource}
+		if counter, ok := counters[resource]; ok {
+			if usage.Used, err = counter(tx, tenantID); err != nil {
+				return nil, err
+			}
+			usage.Tracked = true
+		}
+		if limit, ok := limits[resource]; ok {
+			remaining := max(limit-usage.Used, 0)
+			usage.Limit = &limit
+			usage.Remaining = &remaining
+		}
+		result = append(result, usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Resource < result[j].Resource
+	})
+	return result, nil
+}
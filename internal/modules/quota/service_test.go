@@ -0,0 +1,84 @@
+package quota_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/quota"
+	"seedgo/internal/modules/role"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaOnCreate(t *testing.T) {
+	plan := &model.Plan{Code: "basic", Name: "Basic", Limits: map[string]int64{model.QuotaRoles: 1, model.QuotaUsers: 5}}
+	require.NoError(t, dbtest.Seed().Create(plan).Error)
+	tenant := &model.Tenant{Name: "C", Status: 1, PlanID: &plan.ID, QuotaOverrides: map[string]int64{model.QuotaUsers: 2}}
+	require.NoError(t, dbtest.Seed().Create(tenant).Error)
+	owner := dbtest.User(tenant.ID, "quota-owner")
+	other := dbtest.User(dbtest.Tenant("D").ID, "quota-other")
+
+	ctx := dbtest.UserCtx(owner)
+
+	// 套餐上限 1 个角色
+	assert.NoError(t, role.Instance().Create(ctx, &model.Role{Name: "r1"}))
+	err := role.Instance().Create(ctx, &model.Role{Name: "r2"})
+	var exceeded *quota.ExceededError
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, model.QuotaRoles, exceeded.Resource)
+	assert.Equal(t, int64(1), exceeded.Used)
+
+	// 其他租户不受影响
+	assert.NoError(t, role.Instance().Create(dbtest.UserCtx(other), &model.Role{Name: "d-extra"}))
+
+	usage, err := quota.GetService().Usage(context.Background(), tenant.ID)
+	require.NoError(t, err)
+	byResource := map[string]*quota.Usage{}
+	for _, u := range usage {
+		byResource[u.Resource] = u
+	}
+	// 租户覆盖值优先于套餐
+	assert.Equal(t, int64(2), *byResource[model.QuotaUsers].Limit)
+	assert.Equal(t, int64(1), *byResource[model.QuotaUsers].Remaining)
+	assert.Equal(t, int64(0), *byResource[model.QuotaRoles].Remaining)
+}
+
+func TestQuotaConcurrentCreate(t *testing.T) {
+	plan := &model.Plan{Code: "concurrent", Name: "Concurrent", Limits: map[string]int64{model.QuotaRoles: 3}}
+	require.NoError(t, dbtest.Seed().Create(plan).Error)
+	tenant := &model.Tenant{Name: "E", Status: 1, PlanID: &plan.ID}
+	require.NoError(t, dbtest.Seed().Create(tenant).Error)
+	ctx := dbtest.UserCtx(dbtest.User(tenant.ID, "quota-concurrent"))
+
+	// 并发创建时只有配额内的创建成功
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = role.Instance().Create(ctx, &model.Role{Name: fmt.Sprintf("concurrent-%d", i)})
+		}()
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		var exceeded *quota.ExceededError
+		if err == nil {
+			created++
+		} else {
+			assert.True(t, errors.As(err, &exceeded), err)
+		}
+	}
+	assert.Equal(t, 3, created)
+
+	var count int64
+	require.NoError(t, dbtest.Seed().Model(&model.Role{}).Where("tenant_id = ?", tenant.ID).Count(&count).Error)
+	assert.Equal(t, int64(3), count)
+}
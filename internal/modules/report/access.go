@@ -0,0 +1,337 @@
+package report
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/perms"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// SuperRole 超级用户拥有全部权限，授权角色显示为该值
+	SuperRole = "[super]"
+	// MainRole 租户主账号拥有平台权限以外的所有权限，授权角色显示为该值
+	MainRole = "[main]"
+)
+
+// AccessEntry 权限矩阵中的一项：用户拥有某个权限，以及授予该权限的角色
+type AccessEntry struct {
+	UserID         model.ID `json:"userId"`
+	Username       string   `json:"username"`
+	PermissionID   model.ID `json:"permissionId"`
+	PermissionCode string   `json:"permissionCode"`
+	PermissionName string   `json:"permissionName"`
+	Path           string   `json:"path"`
+	Roles          []string `json:"roles"`
+}
+
+// key 对比快照时使用的唯一标识
+func (e *AccessEntry) key() string {
+	return e.UserID.String() + "|" + e.PermissionCode
+}
+
+// AccessFilter 过滤条件，Code、Path 为模糊匹配
+type AccessFilter struct {
+	Code   string   `form:"code"`
+	Path   string   `form:"path"`
+	UserID model.ID `form:"userId"`
+}
+
+func (f AccessFilter) match(e *AccessEntry) bool {
+	if f.Code != "" && !strings.Contains(e.PermissionCode, f.Code) {
+		return false
+	}
+	if f.Path != "" && !strings.Contains(e.Path, f.Path) {
+		return false
+	}
+	if f.UserID != 0 && f.UserID != e.UserID {
+		return false
+	}
+	return true
+}
+
+// AccessDiff 两次审查之间的差异
+type AccessDiff struct {
+	SnapshotID model.ID       `json:"snapshotId"`
+	Added      []*AccessEntry `json:"added"`
+	Removed    []*AccessEntry `json:"removed"`
+	// Changed 权限不变，但授权角色发生了变化，记录当前的授权角色
+	Changed []*AccessEntry `json:"changed"`
+}
+
+// ErrTenantNotFound 超级用户指定的租户不存在或已删除
+var ErrTenantNotFound = errors.New("tenant not found")
+
+type Service struct {
+	DB *gorm.DB
+}
+
+// 单例模式
+var (
+	instance *Service
+	once     sync.Once
+)
+
+// GetService 获取单例实例
+func GetService() *Service {
+	once.Do(func() {
+		instance = &Service{DB: global.DB}
+	})
+	return instance
+}
+
+// AccessMatrix 计算租户内所有用户的有效权限矩阵（用户 × 权限编码），规则与 PermissionsMiddleware 一致：
+// 只计算当前有效期内的角色，拒绝优先，超级用户拥有全部权限，租户主账号拥有平台权限以外的所有权限（同 perms.GetUserPerms）
+func (s *Service) AccessMatrix(ctx context.Context, tenantID model.ID, filter AccessFilter) ([]*AccessEntry, error) {
+	db := s.DB.WithContext(ctx)
+
+	var all []*model.Permission
+	if err := db.Order("sort").Order("id").Find(&all).Error; err != nil {
+		return nil, err
+	}
+
+	var users []*model.User
+	if err := db.Where("tenant_id = ?", tenantID).Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return []*AccessEntry{}, nil
+	}
+	userIDs := make([]model.ID, 0, len(users))
+	for _, u := range users {
+		userIDs = append(userIDs, u.ID)
+	}
+
+	// 当前生效的角色分配
+	var assignments []*model.UserRole
+	if err := db.Where("user_id IN ?", userIDs).Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	userRoles := make(map[model.ID][]model.ID)
+	roleSet := make(map[model.ID]bool)
+	for _, a := range assignments {
+		if a.Active(now) {
+			userRoles[a.UserID] = append(userRoles[a.UserID], a.RoleID)
+			roleSet[a.RoleID] = true
+		}
+	}
+
+	roleNames := make(map[model.ID]string)
+	roleGrants := make(map[model.ID][]*model.RolePermission)
+	if len(roleSet) > 0 {
+		roleIDs := make([]model.ID, 0, len(roleSet))
+		for id := range roleSet {
+			roleIDs = append(roleIDs, id)
+		}
+		var roles []*model.Role
+		if err := db.Where("id IN ?", roleIDs).Find(&roles).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range roles {
+			roleNames[r.ID] = r.Name
+		}
+		var grants []*model.RolePermission
+		if err := db.Where("role_id IN ?", roleIDs).Find(&grants).Error; err != nil {
+			return nil, err
+		}
+		for _, g := range grants {
+			roleGrants[g.RoleID] = append(roleGrants[g.RoleID], g)
+		}
+	}
+
+	entries := make([]*AccessEntry, 0)
+	for _, u := range users {
+		granted := make(map[model.ID][]string)
+		if u.IsSuper != nil && *u.IsSuper {
+			for _, p := range all {
+				granted[p.ID] = []string{SuperRole}
+			}
+		} else if u.IsMain != nil && *u.IsMain == 1 {
+			for _, p := range all {
+				if !perms.IsPlatformPermission(p.PermissionCode) {
+					granted[p.ID] = []string{MainRole}
+				}
+			}
+		} else {
+			var grants []*model.RolePermission
+			for _, roleID := range userRoles[u.ID] {
+				// 角色已被删除或不可见
+				if _, ok := roleNames[roleID]; !ok {
+					continue
+				}
+				grants = append(grants, roleGrants[roleID]...)
+			}
+			effects := perms.ResolveEffects(all, grants)
+			for _, g := range grants {
+				if g.Effect == model.EffectDeny || effects[g.PermissionID] != model.EffectAllow {
+					continue
+				}
+				granted[g.PermissionID] = append(granted[g.PermissionID], roleNames[g.RoleID])
+			}
+		}
+
+		for _, p := range all {
+			roles, ok := granted[p.ID]
+			if !ok {
+				continue
+			}
+			sort.Strings(roles)
+			entry := &AccessEntry{
+				UserID:         u.ID,
+				Username:       u.Username,
+				PermissionID:   p.ID,
+				PermissionCode: p.PermissionCode,
+				PermissionName: p.Name,
+				Path:           p.PermissionUrls,
+				Roles:          roles,
+			}
+			if filter.match(entry) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// CheckTenant 确认租户存在，已删除的租户不能审查
+func (s *Service) CheckTenant(ctx context.Context, tenantID model.ID) error {
+	var count int64
+	if err := s.DB.WithContext(ctx).Model(&model.Tenant{}).Where("id = ?", tenantID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrTenantNotFound
+	}
+	return nil
+}
+
+// CreateSnapshot 保存当前权限矩阵为快照，并返回与上一次快照的差异
+func (s *Service) CreateSnapshot(ctx context.Context, tenantID, createdBy model.ID, remark string) (*model.AccessSnapshot, *AccessDiff, error) {
+	entries, err := s.AccessMatrix(ctx, tenantID, AccessFilter{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	diff, err := s.Diff(ctx, tenantID, 0, entries)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	snapshot := &model.AccessSnapshot{
+		EntryCount: len(entries),
+		Entries:    string(data),
+		CreatedBy:  createdBy,
+		Remark:     remark,
+	}
+	snapshot.TenantID = tenantID
+	if err := s.DB.WithContext(ctx).Create(snapshot).Error; err != nil {
+		return nil, nil, err
+	}
+	return snapshot, diff, nil
+}
+
+// ListSnapshots 获取租户的快照列表，按时间倒序
+func (s *Service) ListSnapshots(ctx context.Context, tenantID model.ID) ([]*model.AccessSnapshot, error) {
+	var snapshots []*model.AccessSnapshot
+	err := s.DB.WithContext(ctx).Omit("entries").Where("tenant_id = ?", tenantID).Order("id DESC").Find(&snapshots).Error
+	return snapshots, err
+}
+
+// Diff 对比当前权限矩阵和快照，snapshotID 为 0 时使用最近一次快照，current 为空时重新计算
+// 没有快照时，当前所有权限都视为新增
+func (s *Service) Diff(ctx context.Context, tenantID, snapshotID model.ID, current []*AccessEntry) (*AccessDiff, error) {
+	if current == nil {
+		var err error
+		if current, err = s.AccessMatrix(ctx, tenantID, AccessFilter{}); err != nil {
+			return nil, err
+		}
+	}
+
+	var previous []*AccessEntry
+	diff := &AccessDiff{}
+	var snapshot model.AccessSnapshot
+	query := s.DB.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if snapshotID != 0 {
+		query = query.Where("id = ?", snapshotID)
+	}
+	err := query.Order("id DESC").First(&snapshot).Error
+	switch {
+	case err == nil:
+		diff.SnapshotID = snapshot.ID
+		if err := json.Unmarshal([]byte(snapshot.Entries), &previous); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound) && snapshotID == 0:
+	default:
+		return nil, err
+	}
+
+	diff.Added, diff.Removed, diff.Changed = diffEntries(previous, current)
+	return diff, nil
+}
+
+// diffEntries 按 用户+权限编码 对比两次权限矩阵
+func diffEntries(previous, current []*AccessEntry) (added, removed, changed []*AccessEntry) {
+	added, removed, changed = []*AccessEntry{}, []*AccessEntry{}, []*AccessEntry{}
+	before := make(map[string]*AccessEntry, len(previous))
+	for _, e := range previous {
+		before[e.key()] = e
+	}
+	seen := make(map[string]bool, len(current))
+	for _, e := range current {
+		seen[e.key()] = true
+		old, ok := before[e.key()]
+		if !ok {
+			added = append(added, e)
+		} else if strings.Join(old.Roles, ",") != strings.Join(e.Roles, ",") {
+			changed = append(changed, e)
+		}
+	}
+	for _, e := range previous {
+		if !seen[e.key()] {
+			removed = append(removed, e)
+		}
+	}
+	return
+}
+
+// WriteCSV 把权限矩阵写为 CSV，带 BOM 方便 Excel 打开中文
+func WriteCSV(w io.Writer, entries []*AccessEntry) error {
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"用户ID", "用户名", "权限编码", "权限名称", "路径", "授权角色"}); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		row := []string{
+			e.UserID.String(),
+			e.Username,
+			e.PermissionCode,
+			e.PermissionName,
+			e.Path,
+			strings.Join(e.Roles, ";"),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,39 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffEntries(t *testing.T) {
+	previous := []*AccessEntry{
+		{UserID: 1, PermissionCode: "system:user:delete", Roles: []string{"admin"}},
+		{UserID: 2, PermissionCode: "system:user:delete", Roles: []string{"auditor"}},
+	}
+	current := []*AccessEntry{
+		{UserID: 1, PermissionCode: "system:user:delete", Roles: []string{"admin", "ops"}},
+		{UserID: 3, PermissionCode: "system:user:delete", Roles: []string{"admin"}},
+	}
+
+	added, removed, changed := diffEntries(previous, current)
+	assert.Len(t, added, 1)
+	assert.Equal(t, "3", added[0].UserID.String())
+	assert.Len(t, removed, 1)
+	assert.Equal(t, "2", removed[0].UserID.String())
+	assert.Len(t, changed, 1)
+	assert.Equal(t, []string{"admin", "ops"}, changed[0].Roles)
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, []*AccessEntry{
+		{UserID: 1, Username: "alice", PermissionCode: "system:user:delete", PermissionName: "删除", Roles: []string{"admin", "ops"}},
+	})
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "1,alice,system:user:delete,删除,,admin;ops", lines[1])
+}
@@ -0,0 +1,161 @@
+package report
+
+import (
+	"fmt"
+	"net/http"
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	logic *Service
+}
+
+func NewHandler() *Handler {
+	return &Handler{logic: GetService()}
+}
+
+func (h *Handler) Use(g *gin.RouterGroup) {
+	g.GET("", h.Matrix)
+	g.GET("/export", h.Export)
+	g.GET("/diff", h.Diff)
+	g.GET("/snapshots", h.ListSnapshots)
+	g.POST("/snapshots", h.CreateSnapshot)
+}
+
+// tenantID 获取审查的租户，超级用户可以通过 tenantId 参数指定，指定的租户需要存在
+func (h *Handler) tenantID(ctx *gin.Context) (model.ID, bool) {
+	user := scope.GetCurrentUser(ctx)
+	if user == nil {
+		scope.FailWithCode(ctx, http.StatusUnauthorized, "Unauthorized")
+		return 0, false
+	}
+	if user.IsSuper {
+		if id := ctx.Query("tenantId"); id != "" {
+			tenant := model.ToID(id)
+			if err := h.logic.CheckTenant(ctx.Request.Context(), tenant); err != nil {
+				scope.Fail(ctx, err.Error())
+				return 0, false
+			}
+			return tenant, true
+		}
+	}
+	return user.CurrentTenantID(), true
+}
+
+// Matrix 获取权限矩阵，支持 code、path、userId 过滤
+func (h *Handler) Matrix(ctx *gin.Context) {
+	tenant, ok := h.tenantID(ctx)
+	if !ok {
+		return
+	}
+	var filter AccessFilter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		scope.Fail(ctx, "Invalid parameters")
+		return
+	}
+	entries, err := h.logic.AccessMatrix(ctx.Request.Context(), tenant, filter)
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, scope.PageResult{
+		Total: int64(len(entries)),
+		Items: entries,
+	})
+}
+
+// Export 导出权限矩阵为 CSV
+func (h *Handler) Export(ctx *gin.Context) {
+	tenant, ok := h.tenantID(ctx)
+	if !ok {
+		return
+	}
+	var filter AccessFilter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		scope.Fail(ctx, "Invalid parameters")
+		return
+	}
+	entries, err := h.logic.AccessMatrix(ctx.Request.Context(), tenant, filter)
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("access-review-%d-%s.csv", tenant, time.Now().Format("20060102"))
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	if err := WriteCSV(ctx.Writer, entries); err != nil {
+		_ = ctx.Error(err)
+	}
+}
+
+// Diff 对比当前权限和快照，snapshotId 为空时对比最近一次快照
+func (h *Handler) Diff(ctx *gin.Context) {
+	tenant, ok := h.tenantID(ctx)
+	if !ok {
+		return
+	}
+	var snapshotID model.ID
+	if id := ctx.Query("snapshotId"); id != "" {
+		snapshotID = model.ToID(id)
+	}
+	diff, err := h.logic.Diff(ctx.Request.Context(), tenant, snapshotID, nil)
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, diff)
+}
+
+// ListSnapshots 获取快照列表
+func (h *Handler) ListSnapshots(ctx *gin.Context) {
+	tenant, ok := h.tenantID(ctx)
+	if !ok {
+		return
+	}
+	snapshots, err := h.logic.ListSnapshots(ctx.Request.Context(), tenant)
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, scope.PageResult{
+		Total: int64(len(snapshots)),
+		Items: snapshots,
+	})
+}
+
+// SnapshotDTO 创建快照参数
+type SnapshotDTO struct {
+	Remark string `json:"remark" binding:"max=255"`
+}
+
+// CreateSnapshot 保存当前权限矩阵为快照，返回与上一次快照的差异
+func (h *Handler) CreateSnapshot(ctx *gin.Context) {
+	tenant, ok := h.tenantID(ctx)
+	if !ok {
+		return
+	}
+	// 备注可选，没有请求体时不绑定
+	var dto SnapshotDTO
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&dto); err != nil {
+			scope.Fail(ctx, "Invalid parameters")
+			return
+		}
+	}
+
+	user := scope.GetCurrentUser(ctx)
+	snapshot, diff, err := h.logic.CreateSnapshot(ctx.Request.Context(), tenant, user.ID, dto.Remark)
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, gin.H{
+		"snapshot": snapshot,
+		"diff":     diff,
+	})
+}
@@ -0,0 +1,44 @@
+package report_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/modules/report"
+	"seedgo/internal/scope"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSnapshotValidation(t *testing.T) {
+	entity := dbtest.Tenant("快照校验")
+	ctx := dbtest.UserCtx(dbtest.Super(entity.ID, "snapshot-super"))
+
+	r := gin.New()
+	g := r.Group("/access-reviews", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(ctx)
+		c.Set("user", scope.GetUserFromContext(ctx))
+	})
+	report.NewHandler().Use(g)
+	create := func(query, body string) scope.Response {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("POST", "/access-reviews/snapshots"+query, strings.NewReader(body)))
+		var res scope.Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		return res
+	}
+
+	// 请求体格式错误、备注过长、指定的租户不存在时拒绝
+	assert.Equal(t, scope.ErrorCode, create("", `{"remark":`).Code)
+	assert.Equal(t, scope.ErrorCode, create("", `{"remark":"`+strings.Repeat("a", 256)+`"}`).Code)
+	res := create("?tenantId=999999", `{"remark":"月度审查"}`)
+	assert.Equal(t, scope.ErrorCode, res.Code)
+	assert.Equal(t, report.ErrTenantNotFound.Error(), res.Message)
+
+	assert.Equal(t, scope.SuccessCode, create("?tenantId="+entity.ID.String(), `{"remark":"月度审查"}`).Code)
+	assert.Equal(t, scope.SuccessCode, create("", "").Code)
+}
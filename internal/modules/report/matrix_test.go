@@ -0,0 +1,41 @@
+package report_test
+
+import (
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/report"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessMatrixMainAccount(t *testing.T) {
+	global.Config.Permission.PlatformCodes = []string{"tenant"}
+	defer func() { global.Config.Permission.PlatformCodes = nil }()
+
+	platform := &model.Permission{Name: "租户管理", PermissionCode: "tenant"}
+	page := &model.Permission{Name: "用户管理", PermissionCode: "system:user"}
+	require.NoError(t, dbtest.Seed().Create(platform).Error)
+	require.NoError(t, dbtest.Seed().Create(page).Error)
+
+	entity := dbtest.Tenant("权限审查")
+	owner := dbtest.User(entity.ID, "matrix-owner")
+	isMain := int8(1)
+	require.NoError(t, dbtest.Seed().Model(owner).Update("is_main", &isMain).Error)
+	member := dbtest.User(entity.ID, "matrix-member")
+	super := dbtest.UserCtx(dbtest.Super(dbtest.Tenant("平台").ID, "matrix-admin"))
+
+	entries, err := report.GetService().AccessMatrix(super, entity.ID, report.AccessFilter{})
+	require.NoError(t, err)
+
+	// 主账号没有角色也拥有租户内的权限，平台权限除外；普通用户没有角色时没有权限
+	require.Len(t, entries, 1)
+	assert.Equal(t, owner.ID, entries[0].UserID)
+	assert.Equal(t, "system:user", entries[0].PermissionCode)
+	assert.Equal(t, []string{report.MainRole}, entries[0].Roles)
+	for _, e := range entries {
+		assert.NotEqual(t, member.ID, e.UserID)
+	}
+}
@@ -17,6 +17,7 @@ func NewHandler() *Handler {
 	var ctr = &Handler{}
 
 	ctr.BaseHandler = shared.NewBaseHandler(NewService(), nil, ctr)
+	ctr.RequireVersion = true
 	return ctr
 }
 
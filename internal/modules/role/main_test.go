@@ -0,0 +1,14 @@
+package role_test
+
+import (
+	"os"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/model"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	dbtest.Open(&model.Tenant{}, &model.User{}, &model.Role{}, &model.Permission{},
+		&model.RolePermission{}, &model.UserRole{})
+	os.Exit(m.Run())
+}
@@ -2,6 +2,7 @@ package role
 
 import (
 	"context"
+	"seedgo/internal/db"
 	"seedgo/internal/model"
 	"seedgo/internal/modules/perms"
 	"seedgo/internal/shared"
@@ -15,9 +16,11 @@ type Service struct {
 }
 
 func NewService() *Service {
-	return &Service{
+	s := &Service{
 		BaseService: *shared.NewBaseService[model.Role](),
 	}
+	s.Impl = s
+	return s
 }
 
 // 单例模式
@@ -35,43 +38,44 @@ func Instance() *Service {
 
 // Create 创建
 func (l *Service) Create(ctx context.Context, entity *model.Role) error {
-	return l.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 处理关联权限
-		if entity.PermissionIds != nil && len(*entity.PermissionIds) > 0 {
-			var perms []*model.Permission
-			if err := tx.Where("id IN ?", *entity.PermissionIds).Find(&perms).Error; err != nil {
-				return err
-			}
-			entity.Permissions = perms
+	return l.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := l.RunCreateHooks(ctx, tx, entity); err != nil {
+			return err
 		}
-
-		if err := tx.Create(entity).Error; err != nil {
+		if err := tx.Omit("Permissions").Create(entity).Error; err != nil {
 			return err
 		}
+
+		// 处理关联权限
+		if entity.PermissionIds != nil || entity.DenyPermissionIds != nil {
+			return savePermissions(tx, entity)
+		}
 		return nil
 	})
 }
 
 // Update 更新角色
 func (l *Service) Update(ctx context.Context, entity *model.Role) error {
-	err := l.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 更新基本信息
-		if err := tx.Omit("created_at").Save(entity).Error; err != nil {
+	columns, err := l.WritableColumns(ctx, entity)
+	if err != nil {
+		return err
+	}
+	err = l.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// 关联表没有租户字段，先确认角色属于当前租户
+		if err := tx.Select("id").First(&model.Role{}, entity.ID).Error; err != nil {
+			return err
+		}
+		// 更新基本信息，只更新可写字段
+		err := l.Versioned(tx, entity, func(tx *gorm.DB) *gorm.DB {
+			return tx.Select(columns).Omit("created_at").Save(entity)
+		})
+		if err != nil {
 			return err
 		}
 
 		// 处理关联权限
-		if entity.PermissionIds != nil {
-			var perms []*model.Permission
-			if len(*entity.PermissionIds) > 0 {
-				if err := tx.Where("id IN ?", *entity.PermissionIds).Find(&perms).Error; err != nil {
-					return err
-				}
-			}
-			// 替换关联
-			if err := tx.Model(entity).Association("Permissions").Replace(perms); err != nil {
-				return err
-			}
+		if entity.PermissionIds != nil || entity.DenyPermissionIds != nil {
+			return savePermissions(tx, entity)
 		}
 		return nil
 	})
@@ -80,27 +84,126 @@ func (l *Service) Update(ctx context.Context, entity *model.Role) error {
 		return err
 	}
 
-	// 更新成功后，清除所有用户的权限缓存
-	return perms.GetService().ClearPermissionAllCache()
+	// 授权变化后，只清除拥有该角色的用户权限缓存，在外层事务中（如批量更新）时等提交后再清除
+	if entity.PermissionIds != nil || entity.DenyPermissionIds != nil {
+		return db.AfterCommit(ctx, func() error {
+			return perms.GetService().InvalidateRoles(entity.ID)
+		})
+	}
+	return nil
+}
+
+// Delete 删除角色，并清除拥有该角色的用户权限缓存
+func (l *Service) Delete(ctx context.Context, id model.ID) error {
+	if err := l.BaseService.Delete(ctx, id); err != nil {
+		return err
+	}
+	return db.AfterCommit(ctx, func() error {
+		return perms.GetService().InvalidateRoles(id)
+	})
+}
+
+// FieldsScope 展开的用户只包含有效期内拥有该角色的用户
+func (l *Service) FieldsScope(fields, expand []string, required ...string) (func(*gorm.DB) *gorm.DB, error) {
+	fieldsScope, err := l.BaseService.FieldsScope(fields, expand, required...)
+	if err != nil {
+		return nil, err
+	}
+	return func(tx *gorm.DB) *gorm.DB {
+		return fieldsScope(db.ActiveRoles(tx))
+	}, nil
 }
 
-func (l *Service) Get(ctx context.Context, id model.ID) (*model.Role, error) {
+func (l *Service) Get(ctx context.Context, id model.ID, scopes ...func(*gorm.DB) *gorm.DB) (*model.Role, error) {
 	var role model.Role
 	// 1. 只查角色基础信息
-	if err := l.DB.WithContext(ctx).First(&role, id).Error; err != nil {
+	if err := l.DB.WithContext(ctx).Scopes(scopes...).First(&role, id).Error; err != nil {
 		return nil, err
 	}
 
-	// 2. 仅查询关联表的 ID
-	var ids []model.ID
-	err := l.DB.WithContext(ctx).
-		Table("role_permission").
-		Where("role_id = ?", role.ID).
-		Pluck("permission_id", &ids).Error // Pluck 专门用于提取单列
+	// 2. 查询关联表的授权
+	var grants []*model.RolePermission
+	if err := l.DB.WithContext(ctx).Where("role_id = ?", role.ID).Find(&grants).Error; err != nil {
+		return nil, err
+	}
 
-	if err == nil {
-		role.PermissionIds = &ids
+	allowIds := make([]model.ID, 0)
+	denyIds := make([]model.ID, 0)
+	for _, g := range grants {
+		if g.Effect == model.EffectDeny {
+			denyIds = append(denyIds, g.PermissionID)
+		} else {
+			allowIds = append(allowIds, g.PermissionID)
+		}
 	}
+	role.PermissionIds = &allowIds
+	role.DenyPermissionIds = &denyIds
+
+	// 3. 计算每个权限的最终效果（拒绝会传递给子节点）
+	var all []*model.Permission
+	if err := l.DB.WithContext(ctx).Find(&all).Error; err != nil {
+		return nil, err
+	}
+	role.PermissionEffects = perms.ResolveEffects(all, grants)
 
 	return &role, nil
 }
+
+// savePermissions 全量替换角色的授权，同一权限同时允许和拒绝时以拒绝为准
+func savePermissions(tx *gorm.DB, entity *model.Role) error {
+	var allow, deny []model.ID
+	if entity.PermissionIds != nil {
+		allow = *entity.PermissionIds
+	}
+	if entity.DenyPermissionIds != nil {
+		deny = *entity.DenyPermissionIds
+	}
+	if entity.PermissionIds == nil || entity.DenyPermissionIds == nil {
+		// 只提交了一种，另一种保持不变
+		var grants []*model.RolePermission
+		if err := tx.Where("role_id = ?", entity.ID).Find(&grants).Error; err != nil {
+			return err
+		}
+		for _, g := range grants {
+			if g.Effect == model.EffectDeny && entity.DenyPermissionIds == nil {
+				deny = append(deny, g.PermissionID)
+			} else if g.Effect != model.EffectDeny && entity.PermissionIds == nil {
+				allow = append(allow, g.PermissionID)
+			}
+		}
+	}
+	// 拒绝最后写入，覆盖同一权限的允许
+	effects := make(map[model.ID]string)
+	for _, id := range allow {
+		effects[id] = model.EffectAllow
+	}
+	for _, id := range deny {
+		effects[id] = model.EffectDeny
+	}
+
+	if err := tx.Where("role_id = ?", entity.ID).Delete(&model.RolePermission{}).Error; err != nil {
+		return err
+	}
+	if len(effects) == 0 {
+		return nil
+	}
+
+	// 只保留存在的权限
+	ids := make([]model.ID, 0, len(effects))
+	for id := range effects {
+		ids = append(ids, id)
+	}
+	var valid []model.ID
+	if err := tx.Model(&model.Permission{}).Where("id IN ?", ids).Pluck("id", &valid).Error; err != nil {
+		return err
+	}
+
+	rows := make([]*model.RolePermission, 0, len(valid))
+	for _, id := range valid {
+		rows = append(rows, &model.RolePermission{RoleID: entity.ID, PermissionID: id, Effect: effects[id]})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
@@ -0,0 +1,46 @@
+package role_test
+
+import (
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/role"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavePermissionsDenyWins(t *testing.T) {
+	page := &model.Permission{Name: "用户管理", PermissionCode: "system:user"}
+	require.NoError(t, dbtest.Seed().Create(page).Error)
+	remove := &model.Permission{ParentID: &page.ID, Name: "删除", PermissionCode: "system:user:delete"}
+	require.NoError(t, dbtest.Seed().Create(remove).Error)
+	ctx := dbtest.UserCtx(dbtest.User(dbtest.Tenant("A").ID, "role-admin"))
+
+	svc := role.Instance()
+	allow, deny := []model.ID{page.ID}, []model.ID{remove.ID}
+	created := &model.Role{Name: "客服", PermissionIds: &allow, DenyPermissionIds: &deny}
+	require.NoError(t, svc.Create(ctx, created))
+
+	// 只提交允许的权限时，已有的拒绝保持不变，并且仍然优先
+	allow = []model.ID{page.ID, remove.ID}
+	update := &model.Role{Name: "客服", PermissionIds: &allow}
+	update.ID = created.ID
+	require.NoError(t, svc.Update(ctx, update))
+
+	saved, err := svc.Get(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []model.ID{page.ID}, *saved.PermissionIds)
+	assert.Equal(t, []model.ID{remove.ID}, *saved.DenyPermissionIds)
+	assert.Equal(t, model.EffectDeny, saved.PermissionEffects[remove.ID])
+
+	// 只提交拒绝的权限时，允许保持不变
+	deny = []model.ID{}
+	update = &model.Role{Name: "客服", DenyPermissionIds: &deny}
+	update.ID = created.ID
+	require.NoError(t, svc.Update(ctx, update))
+	saved, err = svc.Get(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []model.ID{page.ID}, *saved.PermissionIds)
+	assert.Empty(t, *saved.DenyPermissionIds)
+}
@@ -0,0 +1,79 @@
+package setting
+
+import (
+	"encoding/json"
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+	"seedgo/internal/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	logic *Service
+	*shared.BaseHandler[model.Setting]
+}
+
+func NewHandler() *Handler {
+	logic := GetService()
+	h := &Handler{
+		logic: logic,
+	}
+	h.BaseHandler = shared.NewBaseHandler[model.Setting](logic, nil, h)
+	return h
+}
+
+func (h *Handler) Use(g *gin.RouterGroup) {
+	//租户生效值
+	g.GET("/values", h.Values)
+	g.PUT("/values/:key", h.SetValue)
+	g.DELETE("/values/:key", h.ResetValue)
+	h.BaseHandler.Use(g)
+}
+
+// ValueDTO 租户覆盖配置值的参数
+type ValueDTO struct {
+	Value json.RawMessage `json:"value" binding:"required"`
+}
+
+// tenantID 当前用户的租户，超级用户可以通过 tenantId 参数指定租户
+func tenantID(ctx *gin.Context) model.ID {
+	user := scope.GetCurrentUser(ctx)
+	if user.IsSuper && ctx.Query("tenantId") != "" {
+		return model.ToID(ctx.Query("tenantId"))
+	}
+	return user.CurrentTenantID()
+}
+
+// Values 配置项在租户下的生效值，超级用户不指定租户时返回默认值
+func (h *Handler) Values(ctx *gin.Context) {
+	values, err := h.logic.Values(ctx.Request.Context(), tenantID(ctx))
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, values)
+}
+
+// SetValue 租户覆盖配置值
+func (h *Handler) SetValue(ctx *gin.Context) {
+	var dto ValueDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		scope.Fail(ctx, "Invalid parameters")
+		return
+	}
+	if err := h.logic.SetTenantValue(ctx.Request.Context(), tenantID(ctx), ctx.Param("key"), dto.Value); err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.Ok(ctx)
+}
+
+// ResetValue 恢复为默认值
+func (h *Handler) ResetValue(ctx *gin.Context) {
+	if err := h.logic.ResetTenantValue(ctx.Request.Context(), tenantID(ctx), ctx.Param("key")); err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.Ok(ctx)
+}
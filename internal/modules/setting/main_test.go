@@ -0,0 +1,13 @@
+package setting_test
+
+import (
+	"os"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/model"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	dbtest.Open(&model.Tenant{}, &model.User{}, &model.Setting{}, &model.TenantSetting{})
+	os.Exit(m.Run())
+}
@@ -0,0 +1,341 @@
+package setting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"seedgo/internal/db"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+	"seedgo/internal/shared"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSettingNotFound = errors.New("setting not found")
+	ErrNotOverridable  = errors.New("setting is not overridable by tenant")
+	ErrTenantRequired  = errors.New("tenant is required to override setting")
+	ErrSuperOnly       = errors.New("only super user can change setting definitions")
+)
+
+type Service struct {
+	*shared.BaseService[model.Setting]
+}
+
+func NewService() *Service {
+	s := &Service{
+		BaseService: shared.NewBaseService[model.Setting](),
+	}
+	s.Impl = s
+	return s
+}
+
+// 单例模式
+var (
+	instance *Service
+	once     sync.Once
+)
+
+// GetService 获取单例实例
+func GetService() *Service {
+	once.Do(func() {
+		instance = NewService()
+	})
+	return instance
+}
+
+// cacheKey 租户生效的配置值，租户 0 为全局默认值
+var cacheKey = "setting:%d"
+
+// cachedValue 缓存的配置值，Public 用于过滤未登录可读的配置
+type cachedValue struct {
+	Value  json.RawMessage `json:"value"`
+	Public bool            `json:"public"`
+}
+
+// Value 配置项在某个租户下的生效值
+type Value struct {
+	ID          model.ID        `json:"id"`
+	Key         string          `json:"key"`
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Schema      string          `json:"schema"`
+	Description *string         `json:"description"`
+	Overridable bool            `json:"overridable"`
+	Public      bool            `json:"public"`
+	Default     json.RawMessage `json:"default"`
+	Value       json.RawMessage `json:"value"`
+	Overridden  bool            `json:"overridden"` // 是否为租户覆盖的值
+}
+
+// Create 创建配置项，默认值需要符合类型和校验规则
+func (s *Service) Create(ctx context.Context, entity *model.Setting) error {
+	if err := checkSuper(ctx); err != nil {
+		return err
+	}
+	if err := validateDefinition(entity); err != nil {
+		return err
+	}
+	if err := s.BaseService.Create(ctx, entity); err != nil {
+		return err
+	}
+	return s.clearCache()
+}
+
+// Update 更新配置项，修改 Key 时同步租户覆盖的值
+func (s *Service) Update(ctx context.Context, entity *model.Setting) error {
+	if err := checkSuper(ctx); err != nil {
+		return err
+	}
+	if err := validateDefinition(entity); err != nil {
+		return err
+	}
+	old, err := s.BaseService.Get(ctx, entity.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.BaseService.Update(ctx, entity); err != nil {
+		return err
+	}
+	if old.Key != entity.Key {
+		err := s.Conn(db.WithoutTenant(ctx, "rename setting key")).
+			Model(&model.TenantSetting{}).Where("`key` = ?", old.Key).Update("key", entity.Key).Error
+		if err != nil {
+			return err
+		}
+	}
+	return s.clearCache()
+}
+
+// Delete 删除配置项和所有租户覆盖的值
+func (s *Service) Delete(ctx context.Context, id model.ID) error {
+	if err := checkSuper(ctx); err != nil {
+		return err
+	}
+	def, err := s.BaseService.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.BaseService.Delete(ctx, id); err != nil {
+		return err
+	}
+	err = s.Conn(db.WithoutTenant(ctx, "delete setting overrides")).
+		Where("`key` = ?", def.Key).Delete(&model.TenantSetting{}).Error
+	if err != nil {
+		return err
+	}
+	return s.clearCache()
+}
+
+// checkSuper 配置项定义所有租户共用，只有超级用户和系统调用可以修改
+func checkSuper(ctx context.Context) error {
+	if user := scope.GetUserFromContext(ctx); user != nil && !user.IsSuper {
+		return ErrSuperOnly
+	}
+	return nil
+}
+
+// Value 获取当前租户生效的配置值（JSON），租户没有覆盖时返回默认值
+func (s *Service) Value(ctx context.Context, key string) (json.RawMessage, error) {
+	tenantID, _, err := db.TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	values, err := s.getCacheValues(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := values[key]
+	if !ok {
+		return nil, ErrSettingNotFound
+	}
+	return v.Value, nil
+}
+
+// GetSetting 获取当前租户生效的配置值并解析为 T
+//
+//	name, err := setting.GetSetting[string](ctx, "system.name")
+func GetSetting[T any](ctx context.Context, key string) (T, error) {
+	var out T
+	raw, err := GetService().Value(ctx, key)
+	if err != nil {
+		return out, err
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return out, fmt.Errorf("setting %s: %w", key, err)
+	}
+	return out, nil
+}
+
+// Map 租户生效的配置值，public 为 true 时只返回未登录可读的配置
+func (s *Service) Map(tenantID model.ID, public bool) (map[string]json.RawMessage, error) {
+	values, err := s.getCacheValues(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]json.RawMessage, len(values))
+	for key, v := range values {
+		if public && !v.Public {
+			continue
+		}
+		out[key] = v.Value
+	}
+	return out, nil
+}
+
+// Values 配置项在租户下的生效值和来源，用于管理页面，不走缓存
+func (s *Service) Values(ctx context.Context, tenantID model.ID) ([]*Value, error) {
+	defs, overrides, err := s.load(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Value, 0, len(defs))
+	for _, def := range defs {
+		v := &Value{
+			ID:          def.ID,
+			Key:         def.Key,
+			Name:        def.Name,
+			Type:        def.Type,
+			Schema:      def.Schema,
+			Description: def.Description,
+			Overridable: def.Overridable,
+			Public:      def.Public,
+			Default:     json.RawMessage(def.Value),
+			Value:       json.RawMessage(def.Value),
+		}
+		if value, ok := overrides[def.Key]; ok && def.Overridable {
+			v.Value = json.RawMessage(value)
+			v.Overridden = true
+		}
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+// SetTenantValue 租户覆盖配置值，值按配置项的类型和规则校验
+func (s *Service) SetTenantValue(ctx context.Context, tenantID model.ID, key string, raw json.RawMessage) error {
+	err := s.DB.WithContext(db.WithTenant(ctx, tenantID)).Transaction(func(tx *gorm.DB) error {
+		return s.writeTenantValue(tx, tenantID, key, raw, true)
+	})
+	if err != nil {
+		return err
+	}
+	return s.ClearTenantCache(tenantID)
+}
+
+// InitTenantValue 在调用方的事务中写入租户的初始值，租户已经覆盖的值不修改
+// tx 需要带有该租户的上下文，调用方在事务提交后调用 ClearTenantCache
+func (s *Service) InitTenantValue(tx *gorm.DB, tenantID model.ID, key string, raw json.RawMessage) error {
+	return s.writeTenantValue(tx, tenantID, key, raw, false)
+}
+
+func (s *Service) writeTenantValue(tx *gorm.DB, tenantID model.ID, key string, raw json.RawMessage, overwrite bool) error {
+	if tenantID == 0 {
+		return ErrTenantRequired
+	}
+	def, err := s.definition(tx, key)
+	if err != nil {
+		return err
+	}
+	if !def.Overridable {
+		return ErrNotOverridable
+	}
+	value, err := Validate(def, raw)
+	if err != nil {
+		return err
+	}
+
+	var row model.TenantSetting
+	err = tx.Where("`key` = ?", key).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		row = model.TenantSetting{Key: key, Value: string(value)}
+		row.TenantID = tenantID
+		return tx.Create(&row).Error
+	}
+	if err != nil || !overwrite {
+		return err
+	}
+	return tx.Model(&row).Update("value", string(value)).Error
+}
+
+// ResetTenantValue 删除租户覆盖的值，恢复为默认值
+func (s *Service) ResetTenantValue(ctx context.Context, tenantID model.ID, key string) error {
+	if tenantID == 0 {
+		return ErrTenantRequired
+	}
+	err := s.DB.WithContext(db.WithTenant(ctx, tenantID)).Where("`key` = ?", key).Delete(&model.TenantSetting{}).Error
+	if err != nil {
+		return err
+	}
+	return s.ClearTenantCache(tenantID)
+}
+
+// definition 按 Key 获取配置项定义
+func (s *Service) definition(tx *gorm.DB, key string) (*model.Setting, error) {
+	var def model.Setting
+	if err := tx.Where("`key` = ?", key).First(&def).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSettingNotFound
+		}
+		return nil, err
+	}
+	return &def, nil
+}
+
+// load 读取所有配置项定义和租户覆盖的值，租户为 0 时只读取定义
+func (s *Service) load(ctx context.Context, tenantID model.ID) ([]model.Setting, map[string]string, error) {
+	var defs []model.Setting
+	if err := s.DB.WithContext(ctx).Find(&defs).Error; err != nil {
+		return nil, nil, err
+	}
+	overrides := map[string]string{}
+	if tenantID == 0 {
+		return defs, overrides, nil
+	}
+	var rows []model.TenantSetting
+	if err := s.DB.WithContext(db.WithTenant(ctx, tenantID)).Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, row := range rows {
+		overrides[row.Key] = row.Value
+	}
+	return defs, overrides, nil
+}
+
+// getCacheValues 获取租户生效的配置值，缓存10分钟
+func (s *Service) getCacheValues(tenantID model.ID) (map[string]cachedValue, error) {
+	var values map[string]cachedValue
+	err := global.Cache.Call(fmt.Sprintf(cacheKey, tenantID), &values, func() (any, error) {
+		defs, overrides, err := s.load(context.Background(), tenantID)
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[string]cachedValue, len(defs))
+		for _, def := range defs {
+			value := def.Value
+			if override, ok := overrides[def.Key]; ok && def.Overridable {
+				value = override
+			}
+			out[def.Key] = cachedValue{Value: json.RawMessage(value), Public: def.Public}
+		}
+		return out, nil
+	}, 10*time.Minute)
+	return values, err
+}
+
+// clearCache 配置项定义变化时清除所有租户的缓存
+func (s *Service) clearCache() error {
+	return global.Cache.DeletePrefix("setting:")
+}
+
+// ClearTenantCache 清除指定租户的配置缓存
+func (s *Service) ClearTenantCache(tenantID model.ID) error {
+	return global.Cache.Delete(fmt.Sprintf(cacheKey, tenantID))
+}
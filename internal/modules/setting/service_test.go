@@ -0,0 +1,61 @@
+package setting_test
+
+import (
+	"context"
+	"encoding/json"
+	"seedgo/internal/db"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/setting"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettingOverride(t *testing.T) {
+	tenantA, tenantB := dbtest.Tenant("A").ID, dbtest.Tenant("B").ID
+	alice, bob := dbtest.User(tenantA, "alice"), dbtest.User(tenantB, "bob")
+	s := setting.GetService()
+	ctx := context.Background()
+	require.NoError(t, s.Create(ctx, &model.Setting{Key: "test.title", Name: "标题", Type: model.SettingTypeString,
+		Value: `"Seedgo"`, Schema: `{"required":true,"max":10}`, Overridable: true, Public: true}))
+	require.NoError(t, s.Create(ctx, &model.Setting{Key: "test.limit", Name: "上限", Type: model.SettingTypeInt,
+		Value: `10`, Schema: `{"min":1,"max":100}`}))
+
+	// 非超级用户不能修改定义，默认值需要符合规则
+	assert.ErrorIs(t, s.Create(dbtest.UserCtx(alice), &model.Setting{Key: "test.x", Type: model.SettingTypeBool, Value: "true"}), setting.ErrSuperOnly)
+	assert.Error(t, s.Create(ctx, &model.Setting{Key: "test.bad", Type: model.SettingTypeInt, Value: `"x"`}))
+
+	// 先读一次，覆盖后缓存需要失效
+	title, err := setting.GetSetting[string](dbtest.UserCtx(alice), "test.title")
+	require.NoError(t, err)
+	assert.Equal(t, "Seedgo", title)
+
+	assert.Error(t, s.SetTenantValue(ctx, tenantA, "test.title", json.RawMessage(`"too long title"`)))
+	require.NoError(t, s.SetTenantValue(ctx, tenantA, "test.title", json.RawMessage(`"A 公司"`)))
+	assert.ErrorIs(t, s.SetTenantValue(ctx, tenantA, "test.limit", json.RawMessage(`5`)), setting.ErrNotOverridable)
+
+	title, err = setting.GetSetting[string](dbtest.UserCtx(alice), "test.title")
+	require.NoError(t, err)
+	assert.Equal(t, "A 公司", title)
+	title, err = setting.GetSetting[string](dbtest.UserCtx(bob), "test.title")
+	require.NoError(t, err)
+	assert.Equal(t, "Seedgo", title)
+	limit, err := setting.GetSetting[int](db.WithTenant(ctx, tenantA), "test.limit")
+	require.NoError(t, err)
+	assert.Equal(t, 10, limit)
+
+	public, err := s.Map(tenantA, true)
+	require.NoError(t, err)
+	assert.Contains(t, public, "test.title")
+	assert.NotContains(t, public, "test.limit")
+
+	require.NoError(t, s.ResetTenantValue(ctx, tenantA, "test.title"))
+	title, err = setting.GetSetting[string](dbtest.UserCtx(alice), "test.title")
+	require.NoError(t, err)
+	assert.Equal(t, "Seedgo", title)
+
+	_, err = setting.GetSetting[string](dbtest.UserCtx(alice), "test.missing")
+	assert.ErrorIs(t, err, setting.ErrSettingNotFound)
+}
@@ -0,0 +1,124 @@
+package setting
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"seedgo/internal/model"
+	"unicode/utf8"
+)
+
+// ParseSchema 解析校验规则，为空时返回空规则
+func ParseSchema(text string) (*model.SettingSchema, error) {
+	schema := &model.SettingSchema{}
+	if text == "" {
+		return schema, nil
+	}
+	if err := json.Unmarshal([]byte(text), schema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if schema.Pattern != "" {
+		if _, err := regexp.Compile(schema.Pattern); err != nil {
+			return nil, fmt.Errorf("invalid schema pattern: %w", err)
+		}
+	}
+	return schema, nil
+}
+
+// Validate 按配置项的类型和校验规则检查值，返回压缩后的 JSON
+func Validate(def *model.Setting, raw json.RawMessage) (json.RawMessage, error) {
+	schema, err := ParseSchema(def.Schema)
+	if err != nil {
+		return nil, err
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, fmt.Errorf("%s: value is required", def.Key)
+	}
+
+	var value any
+	switch def.Type {
+	case model.SettingTypeString:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("%s: must be a string", def.Key)
+		}
+		if schema.Required && s == "" {
+			return nil, fmt.Errorf("%s: value is required", def.Key)
+		}
+		if err := checkRange(def.Key, float64(utf8.RuneCountInString(s)), schema, "length"); err != nil {
+			return nil, err
+		}
+		if schema.Pattern != "" && !regexp.MustCompile(schema.Pattern).MatchString(s) {
+			return nil, fmt.Errorf("%s: must match %s", def.Key, schema.Pattern)
+		}
+		value = s
+	case model.SettingTypeInt:
+		var n int64
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return nil, fmt.Errorf("%s: must be an integer", def.Key)
+		}
+		if err := checkRange(def.Key, float64(n), schema, "value"); err != nil {
+			return nil, err
+		}
+		value = n
+	case model.SettingTypeBool:
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return nil, fmt.Errorf("%s: must be a boolean", def.Key)
+		}
+		value = b
+	case model.SettingTypeJSON:
+		if !json.Valid(raw) {
+			return nil, fmt.Errorf("%s: must be valid json", def.Key)
+		}
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, raw); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("%s: unknown type %q", def.Key, def.Type)
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(value, schema.Enum) {
+		return nil, fmt.Errorf("%s: must be one of %v", def.Key, schema.Enum)
+	}
+	return json.Marshal(value)
+}
+
+func checkRange(key string, n float64, schema *model.SettingSchema, what string) error {
+	if schema.Min != nil && n < *schema.Min {
+		return fmt.Errorf("%s: %s must be at least %v", key, what, *schema.Min)
+	}
+	if schema.Max != nil && n > *schema.Max {
+		return fmt.Errorf("%s: %s must be at most %v", key, what, *schema.Max)
+	}
+	return nil
+}
+
+// inEnum 比较时统一转为 JSON，避免数字类型不同（int64 和 float64）导致不相等
+func inEnum(value any, enum []any) bool {
+	v, _ := json.Marshal(value)
+	for _, item := range enum {
+		if e, _ := json.Marshal(item); bytes.Equal(v, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateDefinition 校验配置项定义，默认值需要符合自己的类型和规则
+func validateDefinition(def *model.Setting) error {
+	if def.Key == "" {
+		return errors.New("setting key is required")
+	}
+	value, err := Validate(def, json.RawMessage(def.Value))
+	if err != nil {
+		return err
+	}
+	def.Value = string(value)
+	return nil
+}
@@ -0,0 +1,466 @@
+package tenant
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"seedgo/internal/db"
+	"seedgo/internal/model"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ArchiveVersion 租户归档格式版本，格式不兼容时递增
+const ArchiveVersion = 1
+
+const (
+	manifestFile     = "manifest.json"
+	archiveBatchSize = 500
+)
+
+var errDryRun = errors.New("dry run")
+
+// 关联表没有 tenant_id，通过所属的租户表过滤：表名 -> [列, 所属表]
+var archiveOwners = map[string][2]string{
+	"user_role":       {"user_id", "user"},
+	"role_permission": {"role_id", "role"},
+}
+
+// 表中引用其他租户表的列，导入时替换为新 ID：表名 -> 列 -> 引用的表
+var archiveReferences = map[string]map[string]string{
+	"user_role":       {"user_id": "user", "role_id": "role"},
+	"role_permission": {"role_id": "role"},
+	"operation_logs":  {"user_id": "user"},
+	"access_snapshot": {"created_by": "user"},
+}
+
+// role_permission 中的权限是共享表，按 permission_code 导出，导入时按编码匹配目标环境的权限
+const permissionCodeColumn = "permission_code"
+
+// Manifest 归档清单
+type Manifest struct {
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exportedAt"`
+	Tenant     model.Tenant     `json:"tenant"`
+	Tables     []*ManifestTable `json:"tables"`
+}
+
+// ManifestTable 归档中的一张表，数据在 <Name>.jsonl 中，每行一条记录
+type ManifestTable struct {
+	Name   string `json:"name"`
+	Rows   int    `json:"rows"`
+	Sha256 string `json:"sha256"`
+}
+
+// ArchiveImportOptions 导入选项，Name、Code 为空时使用归档中的租户名称和编码
+type ArchiveImportOptions struct {
+	DryRun bool
+	Name   string
+	Code   string
+}
+
+// ArchiveImportReport 导入结果，DryRun 时数据已回滚，TenantID 没有意义
+type ArchiveImportReport struct {
+	TenantID model.ID       `json:"tenantId"`
+	Tables   map[string]int `json:"tables"`
+	Warnings []string       `json:"warnings,omitempty"`
+	Errors   []string       `json:"errors,omitempty"`
+	DryRun   bool           `json:"dryRun"`
+}
+
+// archiveTable 参与归档的租户表
+type archiveTable struct {
+	schema *schema.Schema
+	model  reflect.Type
+}
+
+func (t *archiveTable) name() string {
+	return t.schema.Table
+}
+
+func (t *archiveTable) new() reflect.Value {
+	return reflect.New(t.model)
+}
+
+// archiveTables 租户数据表和关联表，顺序即导入顺序，被引用的表在前
+func archiveTables(gdb *gorm.DB) ([]*archiveTable, error) {
+	var tables []*archiveTable
+	for _, m := range append(append([]any{}, db.TenantModels...), db.TenantJoinModels...) {
+		s, err := schema.Parse(m, &sync.Map{}, gdb.NamingStrategy)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, &archiveTable{schema: s, model: reflect.TypeOf(m).Elem()})
+	}
+	return tables, nil
+}
+
+// Export 导出租户的所有数据为 zip 归档，包含已软删除的记录
+func (s *TenantLogic) Export(ctx context.Context, tenantID model.ID, w io.Writer) (*Manifest, error) {
+	var tenant model.Tenant
+	if err := s.DB.WithContext(ctx).First(&tenant, tenantID).Error; err != nil {
+		return nil, err
+	}
+	tables, err := archiveTables(s.DB)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := s.permissionCodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := s.DB.WithContext(db.WithTenant(ctx, tenantID)).Unscoped().Session(&gorm.Session{})
+	manifest := &Manifest{Version: ArchiveVersion, ExportedAt: time.Now(), Tenant: tenant}
+	zw := zip.NewWriter(w)
+	for _, table := range tables {
+		file, err := zw.Create(table.name() + ".jsonl")
+		if err != nil {
+			return nil, err
+		}
+		hash := sha256.New()
+		enc := json.NewEncoder(io.MultiWriter(file, hash))
+		item := &ManifestTable{Name: table.name()}
+
+		write := func(rows reflect.Value) error {
+			for i := 0; i < rows.Len(); i++ {
+				row, err := encodeRow(ctx, table.schema, rows.Index(i))
+				if err != nil {
+					return err
+				}
+				if table.name() == "role_permission" {
+					var id model.ID
+					_ = json.Unmarshal(row["permission_id"], &id)
+					row[permissionCodeColumn], _ = json.Marshal(codes[id])
+					delete(row, "permission_id")
+				}
+				if err := enc.Encode(row); err != nil {
+					return err
+				}
+				item.Rows++
+			}
+			return nil
+		}
+
+		slice := reflect.New(reflect.SliceOf(table.model))
+		if owner, ok := archiveOwners[table.name()]; ok {
+			// 关联表是联合主键，不能分批，数据量和用户、角色数相当
+			err = tx.Table(table.name()).
+				Where(owner[0]+" IN (?)", tx.Table(owner[1]).Select("id").Where("tenant_id = ?", tenantID)).
+				Find(slice.Interface()).Error
+			if err == nil {
+				err = write(slice.Elem())
+			}
+		} else {
+			err = tx.Table(table.name()).Where("tenant_id = ?", tenantID).
+				FindInBatches(slice.Interface(), archiveBatchSize, func(_ *gorm.DB, _ int) error {
+					return write(slice.Elem())
+				}).Error
+		}
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", table.name(), err)
+		}
+		item.Sha256 = hex.EncodeToString(hash.Sum(nil))
+		manifest.Tables = append(manifest.Tables, item)
+	}
+
+	file, err := zw.Create(manifestFile)
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(file)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return nil, err
+	}
+	return manifest, zw.Close()
+}
+
+// Import 把归档导入为一个新租户，所有 ID 重新分配，引用的列同步替换
+// 用户名重复、权限编码不存在等问题记录在 Errors 中，有错误时不写入；DryRun 时校验后回滚
+func (s *TenantLogic) Import(ctx context.Context, r io.ReaderAt, size int64, opts ArchiveImportOptions) (*ArchiveImportReport, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	manifest, err := readManifest(files[manifestFile])
+	if err != nil {
+		return nil, err
+	}
+	tables, err := archiveTables(s.DB)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := s.permissionCodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	permissionIDs := make(map[string]model.ID, len(codes))
+	for id, code := range codes {
+		permissionIDs[code] = id
+	}
+
+	report := &ArchiveImportReport{Tables: map[string]int{}, DryRun: opts.DryRun}
+	expected := make(map[string]*ManifestTable, len(manifest.Tables))
+	for _, t := range manifest.Tables {
+		expected[t.Name] = t
+	}
+
+	// 导入时显式写入 tenant_id，不需要插件填充
+	ictx := db.WithoutTenant(ctx, "import tenant archive")
+	err = s.DB.WithContext(ictx).Transaction(func(tx *gorm.DB) error {
+		tenant := manifest.Tenant
+		tenant.ID = 0
+		tenant.Users = nil
+		tenant.PlanID = nil
+		if opts.Name != "" {
+			tenant.Name = opts.Name
+		}
+		if opts.Code != "" {
+			tenant.Code = &opts.Code
+		}
+		if err := tx.Omit("Users").Create(&tenant).Error; err != nil {
+			return fmt.Errorf("create tenant: %w", err)
+		}
+		report.TenantID = tenant.ID
+
+		ids := map[string]map[model.ID]model.ID{}
+		for _, table := range tables {
+			item, ok := expected[table.name()]
+			if !ok {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("table %s not in archive", table.name()))
+				continue
+			}
+			count, err := s.importTable(ictx, tx, table, files[table.name()+".jsonl"], item, tenant.ID, ids, permissionIDs, report)
+			if err != nil {
+				return fmt.Errorf("import %s: %w", table.name(), err)
+			}
+			report.Tables[table.name()] = count
+		}
+		if len(report.Errors) > 0 || opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return report, nil
+}
+
+// importTable 按批导入一张表，返回导入的行数
+func (s *TenantLogic) importTable(ctx context.Context, tx *gorm.DB, table *archiveTable, file *zip.File, item *ManifestTable,
+	tenantID model.ID, ids map[string]map[model.ID]model.ID, permissionIDs map[string]model.ID, report *ArchiveImportReport) (int, error) {
+	if file == nil {
+		return 0, errors.New("missing data file")
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	hash := sha256.New()
+	scanner := bufio.NewScanner(io.TeeReader(rc, hash))
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	idField := table.schema.LookUpField("ID")
+	tenantField := table.schema.LookUpField(model.FieldTenantID)
+	references := archiveReferences[table.name()]
+	ids[table.name()] = map[model.ID]model.ID{}
+
+	var (
+		batch   = reflect.MakeSlice(reflect.SliceOf(reflect.PointerTo(table.model)), 0, archiveBatchSize)
+		oldIDs  []model.ID
+		line    int
+		created int
+	)
+	flush := func() error {
+		if batch.Len() == 0 {
+			return nil
+		}
+		if err := tx.Create(batch.Interface()).Error; err != nil {
+			return err
+		}
+		if idField != nil {
+			for i := 0; i < batch.Len(); i++ {
+				id, _ := idField.ReflectValueOf(ctx, batch.Index(i).Elem()).Interface().(model.ID)
+				ids[table.name()][oldIDs[i]] = id
+			}
+		}
+		created += batch.Len()
+		batch = batch.Slice(0, 0)
+		oldIDs = oldIDs[:0]
+		return nil
+	}
+
+	for scanner.Scan() {
+		line++
+		var row map[string]json.RawMessage
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s line %d: %v", table.name(), line, err))
+			continue
+		}
+		if table.name() == "role_permission" {
+			var code string
+			_ = json.Unmarshal(row[permissionCodeColumn], &code)
+			id, ok := permissionIDs[code]
+			if !ok {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%s line %d: permission %q not found, skipped", table.name(), line, code))
+				continue
+			}
+			row["permission_id"], _ = json.Marshal(id)
+		}
+
+		entity := table.new()
+		if err := decodeRow(ctx, table.schema, entity.Elem(), row); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s line %d: %v", table.name(), line, err))
+			continue
+		}
+		if idField != nil {
+			old, _ := idField.ReflectValueOf(ctx, entity.Elem()).Interface().(model.ID)
+			oldIDs = append(oldIDs, old)
+			_ = idField.Set(ctx, entity.Elem(), model.ID(0))
+		}
+		if tenantField != nil {
+			_ = tenantField.Set(ctx, entity.Elem(), tenantID)
+		}
+		skip := false
+		for column, target := range references {
+			field := table.schema.LookUpField(column)
+			old, _ := field.ReflectValueOf(ctx, entity.Elem()).Interface().(model.ID)
+			if old == 0 {
+				continue
+			}
+			id, ok := ids[target][old]
+			if !ok {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%s line %d: %s %d not found", table.name(), line, column, old))
+				// 关联表的记录没有意义，日志等保留记录但清空引用
+				if _, join := archiveOwners[table.name()]; join {
+					skip = true
+					break
+				}
+			}
+			_ = field.Set(ctx, entity.Elem(), id)
+		}
+		if skip {
+			continue
+		}
+		if table.name() == "user" {
+			if err := checkUsername(tx, entity.Interface().(*model.User)); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s line %d: %v", table.name(), line, err))
+				continue
+			}
+		}
+
+		batch = reflect.Append(batch, entity)
+		if batch.Len() >= archiveBatchSize {
+			if err := flush(); err != nil {
+				return created, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return created, err
+	}
+	if err := flush(); err != nil {
+		return created, err
+	}
+
+	if line != item.Rows {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: expected %d rows, got %d", table.name(), item.Rows, line))
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != item.Sha256 {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: checksum mismatch", table.name()))
+	}
+	return created, nil
+}
+
+// checkUsername 用户名全局唯一，导入前检查目标环境是否已存在
+func checkUsername(tx *gorm.DB, user *model.User) error {
+	var count int64
+	if err := tx.Model(&model.User{}).Where("username = ?", user.Username).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("username %q already exists", user.Username)
+	}
+	return nil
+}
+
+// permissionCodes 权限 ID 到编码的映射
+func (s *TenantLogic) permissionCodes(ctx context.Context) (map[model.ID]string, error) {
+	var permissions []model.Permission
+	if err := s.DB.WithContext(ctx).Select("id", "permission_code").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	codes := make(map[model.ID]string, len(permissions))
+	for _, p := range permissions {
+		codes[p.ID] = p.PermissionCode
+	}
+	return codes, nil
+}
+
+func readManifest(file *zip.File) (*Manifest, error) {
+	if file == nil {
+		return nil, errors.New("invalid archive: missing manifest.json")
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	var manifest Manifest
+	if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if manifest.Version != ArchiveVersion {
+		return nil, fmt.Errorf("unsupported archive version %d", manifest.Version)
+	}
+	return &manifest, nil
+}
+
+// encodeRow 按数据库列导出记录，每列单独序列化，不受 json:"-" 影响（如 password_hash）
+func encodeRow(ctx context.Context, s *schema.Schema, value reflect.Value) (map[string]json.RawMessage, error) {
+	row := make(map[string]json.RawMessage, len(s.DBNames))
+	for _, name := range s.DBNames {
+		field := s.FieldsByDBName[name]
+		data, err := json.Marshal(field.ReflectValueOf(ctx, value).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", name, err)
+		}
+		row[name] = data
+	}
+	return row, nil
+}
+
+// decodeRow encodeRow 的逆过程，归档中没有的列保持零值
+func decodeRow(ctx context.Context, s *schema.Schema, value reflect.Value, row map[string]json.RawMessage) error {
+	for _, name := range s.DBNames {
+		data, ok := row[name]
+		if !ok {
+			continue
+		}
+		field := s.FieldsByDBName[name]
+		ptr := reflect.New(field.FieldType)
+		if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+			return fmt.Errorf("column %s: %w", name, err)
+		}
+		field.ReflectValueOf(ctx, value).Set(ptr.Elem())
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package tenant_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"seedgo/internal/db"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/tenant"
+	"seedgo/internal/shared"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestTenantArchiveRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := dbtest.Tenant("A")
+	alice := dbtest.User(source.ID, "alice")
+	page := &model.Permission{Name: "用户管理", PermissionCode: "system:user"}
+	require.NoError(t, dbtest.Seed().Create(page).Error)
+	r := &model.Role{Name: "role-a"}
+	r.TenantID = source.ID
+	require.NoError(t, dbtest.Seed().Omit("Permissions").Create(r).Error)
+	require.NoError(t, dbtest.Seed().Create(&model.RolePermission{RoleID: r.ID, PermissionID: page.ID, Effect: model.EffectAllow}).Error)
+	require.NoError(t, dbtest.Seed().Create(&model.UserRole{UserID: alice.ID, RoleID: r.ID}).Error)
+	l := &model.OperationLog{Method: "GET", Path: "/api/ping"}
+	l.TenantID = source.ID
+	require.NoError(t, dbtest.Seed().Create(l).Error)
+
+	var buf bytes.Buffer
+	manifest, err := tenant.GetService().Export(ctx, source.ID, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, tenant.ArchiveVersion, manifest.Version)
+
+	// 同一环境中用户名冲突，预演报告错误且不写入
+	var before int64
+	global.DB.Model(&model.Tenant{}).Count(&before)
+	report, err := tenant.GetService().Import(ctx, bytes.NewReader(buf.Bytes()), int64(buf.Len()), tenant.ArchiveImportOptions{DryRun: true, Code: "copy"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, report.Errors)
+	var after int64
+	global.DB.Model(&model.Tenant{}).Count(&after)
+	assert.Equal(t, before, after)
+
+	// 导入到另一个环境，只有 system:user 权限
+	target, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "target.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.Setup(target, true))
+	seedCtx := db.WithoutTenant(ctx, "seed archive target")
+	require.NoError(t, target.WithContext(seedCtx).AutoMigrate(
+		&model.Tenant{}, &model.User{}, &model.Role{}, &model.Permission{}, &model.RolePermission{},
+		&model.UserRole{}, &model.Policy{}, &model.OperationLog{}, &model.AccessSnapshot{},
+	))
+	target.Create(&model.Permission{Name: "other", PermissionCode: "other"})
+	target.Create(&model.Permission{Name: "用户管理", PermissionCode: "system:user"})
+
+	svc := &tenant.TenantLogic{BaseService: &shared.BaseService[model.Tenant]{DB: target}}
+	report, err = svc.Import(ctx, bytes.NewReader(buf.Bytes()), int64(buf.Len()), tenant.ArchiveImportOptions{Name: "A copy"})
+	require.NoError(t, err)
+	require.Empty(t, report.Errors)
+	assert.NotZero(t, report.TenantID)
+
+	rows := map[string]int{}
+	for _, table := range manifest.Tables {
+		rows[table.Name] = table.Rows
+	}
+	assert.Equal(t, rows["user"], report.Tables["user"])
+	assert.Equal(t, rows["operation_logs"], report.Tables["operation_logs"])
+	assert.NotZero(t, report.Tables["operation_logs"])
+
+	// ID 重新分配后关联关系保持，密码保留
+	tctx := db.WithTenant(ctx, report.TenantID)
+	var user model.User
+	require.NoError(t, target.WithContext(tctx).Preload("Roles.Permissions").Where("username = ?", "alice").First(&user).Error)
+	assert.Equal(t, alice.PasswordHash, user.PasswordHash)
+	require.Len(t, user.Roles, 1)
+	assert.Equal(t, "role-a", user.Roles[0].Name)
+	require.Len(t, user.Roles[0].Permissions, 1)
+	assert.Equal(t, "system:user", user.Roles[0].Permissions[0].PermissionCode)
+}
@@ -1,8 +1,14 @@
 package tenant
 
 import (
+	"fmt"
+	"log"
 	"seedgo/internal/model"
+	"seedgo/internal/scope"
 	"seedgo/internal/shared"
+	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
@@ -11,10 +17,164 @@ type Handler struct {
 }
 
 func NewHandler() *Handler {
-	logic := NewTenantLogic()
+	logic := GetService()
 	ctr := &Handler{
 		logic: logic,
 	}
 	ctr.BaseHandler = *shared.NewBaseHandler(logic, nil, ctr)
 	return ctr
 }
+
+func (h *Handler) Use(g *gin.RouterGroup) {
+	g.POST("/:id/suspend", h.Suspend)
+	g.POST("/:id/resume", h.Resume)
+	g.GET("/:id/export", h.Export)
+	g.POST("/import", h.Import)
+	g.GET("/deleted", h.ListDeleted)
+	g.GET("/purge-logs", h.ListPurgeLogs)
+	g.POST("/:id/restore", h.Restore)
+	g.POST("/:id/purge", h.Purge)
+	h.BaseHandler.Use(g)
+}
+
+// SuspendDTO 停用租户参数
+type SuspendDTO struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ResumeDTO 恢复租户参数，expiresAt 不为空时同时续期
+type ResumeDTO struct {
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// Suspend 停用租户，仅超级用户可用
+func (h *Handler) Suspend(ctx *gin.Context) {
+	if !scope.RequireSuper(ctx) {
+		return
+	}
+	var dto SuspendDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		scope.Fail(ctx, "Invalid parameters")
+		return
+	}
+	if err := h.logic.Suspend(ctx.Request.Context(), model.ToID(ctx.Param("id")), dto.Reason); err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.Ok(ctx)
+}
+
+// Resume 恢复租户，仅超级用户可用
+func (h *Handler) Resume(ctx *gin.Context) {
+	if !scope.RequireSuper(ctx) {
+		return
+	}
+	// 没有请求体时只恢复，不续期
+	var dto ResumeDTO
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&dto); err != nil {
+			scope.Fail(ctx, "Invalid parameters")
+			return
+		}
+	}
+	if err := h.logic.Resume(ctx.Request.Context(), model.ToID(ctx.Param("id")), dto.ExpiresAt); err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.Ok(ctx)
+}
+
+// Export 导出租户数据归档，仅超级用户可用
+func (h *Handler) Export(ctx *gin.Context) {
+	if !scope.RequireSuper(ctx) {
+		return
+	}
+	id := model.ToID(ctx.Param("id"))
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=tenant-%d-%s.zip", id, time.Now().Format("20060102150405")))
+	if _, err := h.logic.Export(ctx.Request.Context(), id, ctx.Writer); err != nil {
+		// 已经开始写入响应，只能记录错误，客户端拿到的是不完整的 zip
+		log.Printf("export tenant %d failed: %v", id, err)
+		_ = ctx.Error(err)
+	}
+}
+
+// Import 导入租户数据归档为新租户，上传文件字段为 file，支持 dryRun、name、code 参数，仅超级用户可用
+func (h *Handler) Import(ctx *gin.Context) {
+	if !scope.RequireSuper(ctx) {
+		return
+	}
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		scope.Fail(ctx, "Invalid parameters")
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	defer file.Close()
+
+	report, err := h.logic.Import(ctx.Request.Context(), file, header.Size, ArchiveImportOptions{
+		DryRun: ctx.Query("dryRun") == "true",
+		Name:   ctx.Query("name"),
+		Code:   ctx.Query("code"),
+	})
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, report)
+}
+
+// ListDeleted 保留期内已删除的租户，仅超级用户可用
+func (h *Handler) ListDeleted(ctx *gin.Context) {
+	if !scope.RequireSuper(ctx) {
+		return
+	}
+	tenants, err := h.logic.ListDeleted(ctx.Request.Context())
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, tenants)
+}
+
+// ListPurgeLogs 租户数据清除报告，仅超级用户可用
+func (h *Handler) ListPurgeLogs(ctx *gin.Context) {
+	if !scope.RequireSuper(ctx) {
+		return
+	}
+	logs, err := h.logic.ListPurgeLogs(ctx.Request.Context())
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, logs)
+}
+
+// Restore 恢复保留期内删除的租户，仅超级用户可用
+func (h *Handler) Restore(ctx *gin.Context) {
+	if !scope.RequireSuper(ctx) {
+		return
+	}
+	if err := h.logic.Restore(ctx.Request.Context(), model.ToID(ctx.Param("id"))); err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.Ok(ctx)
+}
+
+// Purge 立即清除已删除租户的数据，不等保留期结束，仅超级用户可用
+func (h *Handler) Purge(ctx *gin.Context) {
+	if !scope.RequireSuper(ctx) {
+		return
+	}
+	report, err := h.logic.Purge(ctx.Request.Context(), model.ToID(ctx.Param("id")), true)
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, report)
+}
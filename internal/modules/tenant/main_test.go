@@ -0,0 +1,15 @@
+package tenant_test
+
+import (
+	"os"
+	"seedgo/internal/db"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/model"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	models := []any{&model.Tenant{}, &model.Permission{}, &model.TenantPurgeLog{}}
+	dbtest.Open(append(append(models, db.TenantModels...), db.TenantJoinModels...)...)
+	os.Exit(m.Run())
+}
@@ -0,0 +1,256 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"seedgo/internal/db"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/perms"
+	"seedgo/internal/modules/policy"
+	"seedgo/internal/modules/setting"
+	"seedgo/internal/scope"
+	"seedgo/internal/shared"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 租户删除流程
+//
+// 删除：立即软删除租户并记录计划清除时间（PurgeAt），租户下的用户不能登录，已签发的 Token 在下一次请求时失效。
+// 保留期内可以恢复；保留期结束后由后台任务清除所有租户数据和缓存，校验没有剩余后彻底删除租户记录，并保存清除报告。
+var (
+	ErrTenantNotDeleted = errors.New("tenant is not deleted")
+	ErrRetentionPeriod  = errors.New("tenant is still in retention period")
+)
+
+// Delete 删除租户，进入保留期
+func (s *TenantLogic) Delete(ctx context.Context, id model.ID) error {
+	purgeAt := time.Now().Add(global.Config.Tenant.Retention)
+	err := s.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.Tenant{}).Where("id = ?", id).Update("purge_at", &purgeAt)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrTenantNotFound
+		}
+		return tx.Delete(&model.Tenant{}, id).Error
+	})
+	if err != nil {
+		return err
+	}
+	s.clearResolveCache()
+	return nil
+}
+
+// Restore 恢复保留期内删除的租户
+func (s *TenantLogic) Restore(ctx context.Context, id model.ID) error {
+	res := s.DB.WithContext(ctx).Unscoped().Model(&model.Tenant{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]any{"deleted_at": nil, "purge_at": nil})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrTenantNotDeleted
+	}
+	s.clearResolveCache()
+	return nil
+}
+
+// ListDeleted 保留期内已删除、等待清除的租户
+func (s *TenantLogic) ListDeleted(ctx context.Context) ([]*model.Tenant, error) {
+	var tenants []*model.Tenant
+	err := s.DB.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").Order("purge_at").Find(&tenants).Error
+	return tenants, err
+}
+
+// ListPurgeLogs 清除报告
+func (s *TenantLogic) ListPurgeLogs(ctx context.Context) ([]*model.TenantPurgeLog, error) {
+	var logs []*model.TenantPurgeLog
+	err := s.DB.WithContext(db.WithoutTenant(ctx, "list tenant purge logs")).Order("id DESC").Find(&logs).Error
+	return logs, err
+}
+
+// Purge 清除已删除租户的所有数据，force 为 true 时不等保留期结束
+// 各表分别删除，不在一个事务中，失败后可以重复执行；校验没有剩余数据后才删除租户记录
+func (s *TenantLogic) Purge(ctx context.Context, id model.ID, force bool) (*model.TenantPurgeLog, error) {
+	var tenant model.Tenant
+	if err := s.DB.WithContext(ctx).Unscoped().First(&tenant, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, err
+	}
+	if !tenant.DeletedAt.Valid {
+		return nil, ErrTenantNotDeleted
+	}
+	if !force && tenant.PurgeAt != nil && time.Now().Before(*tenant.PurgeAt) {
+		return nil, ErrRetentionPeriod
+	}
+
+	report := &model.TenantPurgeLog{
+		TenantID:   id,
+		TenantName: tenant.Name,
+		Deleted:    map[string]int64{},
+		Remaining:  map[string]int64{},
+	}
+	if user := scope.GetUserFromContext(ctx); user != nil {
+		report.PurgedBy = user.ID
+	}
+
+	tctx := db.WithTenant(ctx, id)
+	if err := s.purgeData(tctx, id, report); err != nil {
+		report.Error = err.Error()
+	} else if err := s.verifyPurge(tctx, id, report); err != nil {
+		report.Error = err.Error()
+	}
+
+	if report.Verified {
+		if err := s.DB.WithContext(ctx).Unscoped().Delete(&model.Tenant{}, id).Error; err != nil {
+			report.Error = err.Error()
+		}
+		if err := s.unregisterDatabase(ctx, id); err != nil {
+			report.Error = err.Error()
+		}
+		s.clearResolveCache()
+	}
+
+	if err := s.DB.WithContext(tctx).Create(report).Error; err != nil {
+		return report, err
+	}
+	log.Printf("清除租户 %d(%s) 的数据，校验结果 %v，删除 %v", id, tenant.Name, report.Verified, report.Deleted)
+	return report, nil
+}
+
+// purgeData 删除租户数据和缓存，关联表先于所属的表删除
+func (s *TenantLogic) purgeData(ctx context.Context, id model.ID, report *model.TenantPurgeLog) error {
+	tables, err := archiveTables(s.DB)
+	if err != nil {
+		return err
+	}
+	tx := s.DB.WithContext(ctx).Unscoped().Session(&gorm.Session{})
+
+	var userIDs []model.ID
+	if err := tx.Model(&model.User{}).Where("tenant_id = ?", id).Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		owner, ok := archiveOwners[table.name()]
+		if !ok {
+			continue
+		}
+		res := tx.Table(table.name()).
+			Where(owner[0]+" IN (?)", tx.Table(owner[1]).Select("id").Where("tenant_id = ?", id)).
+			Delete(table.new().Interface())
+		if res.Error != nil {
+			return fmt.Errorf("purge %s: %w", table.name(), res.Error)
+		}
+		report.Deleted[table.name()] = res.RowsAffected
+	}
+	for _, table := range tables {
+		if _, ok := archiveOwners[table.name()]; ok {
+			continue
+		}
+		res := tx.Where("tenant_id = ?", id).Delete(table.new().Interface())
+		if res.Error != nil {
+			return fmt.Errorf("purge %s: %w", table.name(), res.Error)
+		}
+		report.Deleted[table.name()] = res.RowsAffected
+	}
+
+	if err := perms.GetService().PurgeTenantCache(id, userIDs); err != nil {
+		return fmt.Errorf("purge permission cache: %w", err)
+	}
+	if err := policy.GetService().ClearTenantCache(id); err != nil {
+		return fmt.Errorf("purge policy cache: %w", err)
+	}
+	if err := setting.GetService().ClearTenantCache(id); err != nil {
+		return fmt.Errorf("purge setting cache: %w", err)
+	}
+	if err := os.RemoveAll(filepath.Join(shared.ExportDir(), id.String())); err != nil {
+		return fmt.Errorf("purge export files: %w", err)
+	}
+	return nil
+}
+
+// verifyPurge 校验各表没有剩余的租户数据
+func (s *TenantLogic) verifyPurge(ctx context.Context, id model.ID, report *model.TenantPurgeLog) error {
+	tables, err := archiveTables(s.DB)
+	if err != nil {
+		return err
+	}
+	tx := s.DB.WithContext(ctx).Unscoped().Session(&gorm.Session{})
+	report.Verified = true
+	for _, table := range tables {
+		var count int64
+		query := tx.Table(table.name())
+		if owner, ok := archiveOwners[table.name()]; ok {
+			// 所属的记录已经删除，剩余的关联记录无法按租户识别，只校验所属表
+			query = query.Where(owner[0]+" IN (?)", tx.Table(owner[1]).Select("id").Where("tenant_id = ?", id))
+		} else {
+			query = query.Where("tenant_id = ?", id)
+		}
+		if err := query.Count(&count).Error; err != nil {
+			return err
+		}
+		report.Remaining[table.name()] = count
+		if count > 0 {
+			report.Verified = false
+		}
+	}
+	return nil
+}
+
+// unregisterDatabase 取消租户独立数据库的登记，数据库本身需要手动删除
+func (s *TenantLogic) unregisterDatabase(ctx context.Context, id model.ID) error {
+	router := db.GetRouter()
+	if router == nil {
+		return nil
+	}
+	res := s.DB.WithContext(db.WithTenant(ctx, id)).Where("tenant_id = ?", id).Delete(&model.TenantDatabase{})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return res.Error
+	}
+	log.Printf("租户 %d 的独立数据库已取消登记，请手动删除数据库", id)
+	return router.Reload()
+}
+
+// PurgeDue 清除保留期已结束的租户
+func (s *TenantLogic) PurgeDue(ctx context.Context) {
+	var ids []model.ID
+	err := s.DB.WithContext(ctx).Unscoped().Model(&model.Tenant{}).
+		Where("deleted_at IS NOT NULL AND purge_at <= ?", time.Now()).Pluck("id", &ids).Error
+	if err != nil {
+		log.Printf("查询待清除租户失败: %v", err)
+		return
+	}
+	for _, id := range ids {
+		if _, err := s.Purge(ctx, id, false); err != nil {
+			log.Printf("清除租户 %d 失败: %v", id, err)
+		}
+	}
+}
+
+// StartPurgeWorker 启动后台清除任务，ctx 结束时退出
+func StartPurgeWorker(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				GetService().PurgeDue(ctx)
+			}
+		}
+	}()
+}
@@ -0,0 +1,73 @@
+package tenant_test
+
+import (
+	"context"
+	"seedgo/internal/db"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/tenant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantOffboarding(t *testing.T) {
+	global.Config.Tenant.Retention = time.Hour
+	defer func() { global.Config.Tenant.Retention = 0 }()
+
+	ctx := context.Background()
+	seedCtx := db.WithoutTenant(ctx, "seed offboarding test")
+	tx := global.DB.WithContext(seedCtx)
+	leaving := &model.Tenant{Name: "leaving", Status: 1}
+	require.NoError(t, tx.Create(leaving).Error)
+	u := &model.User{Username: "leaving-user", PasswordHash: "x"}
+	u.TenantID = leaving.ID
+	require.NoError(t, tx.Create(u).Error)
+	r := &model.Role{Name: "leaving-role"}
+	r.TenantID = leaving.ID
+	require.NoError(t, tx.Omit("Permissions").Create(r).Error)
+	require.NoError(t, tx.Create(&model.UserRole{UserID: u.ID, RoleID: r.ID}).Error)
+	l := &model.OperationLog{Method: "GET", Path: "/api/ping", UserID: u.ID}
+	l.TenantID = leaving.ID
+	require.NoError(t, tx.Create(l).Error)
+
+	svc := tenant.GetService()
+	_, err := svc.CheckTenant(ctx, leaving.ID)
+	require.NoError(t, err)
+
+	// 删除后立即锁定，保留期内可以恢复
+	require.NoError(t, svc.Delete(ctx, leaving.ID))
+	_, err = svc.CheckTenant(ctx, leaving.ID)
+	assert.ErrorIs(t, err, tenant.ErrTenantDeleted)
+	_, err = svc.Purge(ctx, leaving.ID, false)
+	assert.ErrorIs(t, err, tenant.ErrRetentionPeriod)
+
+	require.NoError(t, svc.Restore(ctx, leaving.ID))
+	_, err = svc.CheckTenant(ctx, leaving.ID)
+	assert.NoError(t, err)
+
+	// 清除后校验没有剩余数据，租户记录删除，报告保留
+	require.NoError(t, svc.Delete(ctx, leaving.ID))
+	report, err := svc.Purge(ctx, leaving.ID, true)
+	require.NoError(t, err)
+	assert.True(t, report.Verified)
+	assert.Empty(t, report.Error)
+	assert.Equal(t, int64(1), report.Deleted["user"])
+	assert.Equal(t, int64(1), report.Deleted["user_role"])
+	assert.Equal(t, int64(1), report.Deleted["operation_logs"])
+
+	var count int64
+	tx.Unscoped().Model(&model.User{}).Where("tenant_id = ?", leaving.ID).Count(&count)
+	assert.Zero(t, count)
+	tx.Unscoped().Model(&model.Tenant{}).Where("id = ?", leaving.ID).Count(&count)
+	assert.Zero(t, count)
+	_, err = svc.CheckTenant(ctx, leaving.ID)
+	assert.ErrorIs(t, err, tenant.ErrTenantNotFound)
+
+	logs, err := svc.ListPurgeLogs(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, logs)
+	assert.Equal(t, leaving.ID, logs[0].TenantID)
+}
@@ -3,9 +3,17 @@ package tenant
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"seedgo/internal/db"
+	"seedgo/internal/global"
 	"seedgo/internal/model"
+	"seedgo/internal/modules/provision"
 	"seedgo/internal/shared"
 	"seedgo/pkg"
+	"strconv"
+	"sync"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -15,14 +23,86 @@ type TenantLogic struct {
 }
 
 func NewTenantLogic() *TenantLogic {
-	return &TenantLogic{
+	s := &TenantLogic{
 		shared.NewBaseService[model.Tenant](),
 	}
+	s.Impl = s
+	return s
 }
+
+// 单例模式
+var (
+	instance *TenantLogic
+	once     sync.Once
+)
+
+// GetService 获取单例实例
+func GetService() *TenantLogic {
+	once.Do(func() {
+		instance = NewTenantLogic()
+	})
+	return instance
+}
+
+var resolveCacheKey = "tenant:resolve:%s"
+
+// Resolve 根据 ID 或编码查找租户并校验状态（停用、过期）
+func (s *TenantLogic) Resolve(ctx context.Context, key string) (*model.Tenant, error) {
+	tenant, err := s.Find(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := Check(tenant, time.Now()); err != nil {
+		return tenant, err
+	}
+	return tenant, nil
+}
+
+// Find 根据 ID 或编码查找租户，不校验状态，包含保留期内已删除的租户，结果缓存 5 分钟，租户修改或删除时清除
+func (s *TenantLogic) Find(ctx context.Context, key string) (*model.Tenant, error) {
+	var tenant model.Tenant
+	err := global.Cache.Call(fmt.Sprintf(resolveCacheKey, key), &tenant, func() (any, error) {
+		var t model.Tenant
+		query := s.DB.WithContext(ctx).Unscoped()
+		if id, err := strconv.ParseInt(key, 10, 64); err == nil {
+			query = query.Where("id = ?", id)
+		} else {
+			query = query.Where("code = ?", key)
+		}
+		if err := query.First(&t).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrTenantNotFound
+			}
+			return nil, err
+		}
+		return &t, nil
+	}, 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// clearResolveCache 清除租户识别缓存
+func (s *TenantLogic) clearResolveCache() {
+	if err := global.Cache.DeletePrefix("tenant:resolve:"); err != nil {
+		log.Printf("清除租户缓存失败: %v", err)
+	}
+}
+
+// Update 更新租户，清除租户识别缓存
+func (s *TenantLogic) Update(ctx context.Context, entity *model.Tenant) error {
+	if err := s.BaseService.Update(ctx, entity); err != nil {
+		return err
+	}
+	s.clearResolveCache()
+	return nil
+}
+
 func (s *TenantLogic) Create(ctx context.Context, entity *model.Tenant) error {
 	//入参：{"status":1,"username":"user_x7t46eus","password":"ydeux3agAa1!","phone":"15688979878","realName":"656","name":"123213"}
 	//判断用户名和手机号在用户表中是否存在，不存在就创建用户关联，存在了就抛出异常。
-	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return s.Conn(ctx).Transaction(func(tx *gorm.DB) error {
 		// 1. 检查用户名是否存在
 		var count int64
 		if err := tx.Model(&model.User{}).Where("username = ?", entity.Username).Count(&count).Error; err != nil {
@@ -78,16 +158,21 @@ func (s *TenantLogic) Create(ctx context.Context, entity *model.Tenant) error {
 			IsMain:  &isMain,
 			IsSuper: nil, // 默认为 false
 		}
-		// 显式跳过租户过滤，因为此时可能还没有上下文或是在创建新租户
-		// 但由于 User 创建依赖 TenantID，我们手动设置了 TenantID
-		// 这里的关键是确保 TenantPlugin 不会干扰或者正确处理
-		// 通常 User 创建时 TenantPlugin 会尝试填充 TenantID，但这里我们手动指定了，插件应该会尊重（取决于插件逻辑，通常是如果不为空则不覆盖）
-		// 不过看之前的插件逻辑：setTenantFieldValue 会覆盖。
-		// 所以这里可能需要 skip_tenant_filter
-		if err := tx.Set("skip_tenant_filter", true).Create(user).Error; err != nil {
+		// 主账号属于新租户，显式指定租户上下文，TenantPlugin 会按该租户填充 TenantID
+		if err := tx.WithContext(db.WithTenant(ctx, entity.ID)).Create(user).Error; err != nil {
 			return err
 		}
 
-		return nil
+		// 5. 按开通模板初始化角色、权限、字典和配置
+		if err := provision.GetService().Provision(ctx, tx, entity); err != nil {
+			return err
+		}
+		if entity.TemplateID == nil {
+			return nil
+		}
+		return tx.Model(entity).Updates(map[string]any{
+			"template_id":      entity.TemplateID,
+			"template_version": entity.TemplateVersion,
+		}).Error
 	})
 }
@@ -0,0 +1,43 @@
+package tenant_test
+
+import (
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/tenant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateStatusRequiresSuper(t *testing.T) {
+	entity := dbtest.Tenant("状态特权")
+	member := dbtest.User(entity.ID, "status-member")
+	super := dbtest.Super(entity.ID, "status-super")
+	svc := tenant.GetService()
+	expiresAt := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	// 租户用户不能修改状态和到期时间，其他字段照常写入
+	loaded, err := svc.Get(dbtest.UserCtx(member), entity.ID)
+	require.NoError(t, err)
+	loaded.Name = "改名"
+	loaded.Status = tenant.StatusDisabled
+	loaded.ExpiresAt = &expiresAt
+	require.NoError(t, svc.Update(dbtest.UserCtx(member), loaded))
+
+	var saved model.Tenant
+	require.NoError(t, dbtest.Seed().First(&saved, entity.ID).Error)
+	assert.Equal(t, "改名", saved.Name)
+	assert.Equal(t, tenant.StatusEnabled, saved.Status)
+	assert.Nil(t, saved.ExpiresAt)
+
+	// 超级用户可以修改
+	saved.Status = tenant.StatusDisabled
+	saved.ExpiresAt = &expiresAt
+	require.NoError(t, svc.Update(dbtest.UserCtx(super), &saved))
+	require.NoError(t, dbtest.Seed().First(&saved, entity.ID).Error)
+	assert.Equal(t, tenant.StatusDisabled, saved.Status)
+	require.NotNil(t, saved.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*saved.ExpiresAt))
+}
@@ -0,0 +1,107 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+	"time"
+)
+
+// 租户状态
+const (
+	StatusDisabled = 0
+	StatusEnabled  = 1
+)
+
+var (
+	ErrTenantNotFound  = errors.New("tenant not found")
+	ErrTenantSuspended = errors.New("tenant is suspended")
+	ErrTenantExpired   = errors.New("tenant has expired")
+	ErrTenantDeleted   = errors.New("tenant has been deleted")
+)
+
+// Check 校验租户状态：删除、停用立即拒绝，过期超过宽限期后拒绝
+func Check(t *model.Tenant, now time.Time) error {
+	// DeletedAt 不参与 JSON，缓存中的租户通过 PurgeAt 判断
+	if t.DeletedAt.Valid || t.PurgeAt != nil {
+		return ErrTenantDeleted
+	}
+	if t.Status != StatusEnabled {
+		if t.SuspendReason != "" {
+			return fmt.Errorf("%w: %s", ErrTenantSuspended, t.SuspendReason)
+		}
+		return ErrTenantSuspended
+	}
+	if t.ExpiresAt != nil && now.After(t.ExpiresAt.Add(global.Config.Tenant.GracePeriod)) {
+		return ErrTenantExpired
+	}
+	return nil
+}
+
+// InGracePeriod 租户已过期但仍在宽限期内
+func InGracePeriod(t *model.Tenant, now time.Time) bool {
+	return t.ExpiresAt != nil && now.After(*t.ExpiresAt) && !now.After(t.ExpiresAt.Add(global.Config.Tenant.GracePeriod))
+}
+
+// StatusCode 租户状态错误对应的业务码，其他错误返回 0
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrTenantSuspended):
+		return scope.TenantSuspendedCode
+	case errors.Is(err, ErrTenantExpired):
+		return scope.TenantExpiredCode
+	case errors.Is(err, ErrTenantDeleted):
+		return scope.TenantDeletedCode
+	}
+	return 0
+}
+
+// CheckTenant 按 ID 校验租户状态，用于登录和 AuthMiddleware
+func (s *TenantLogic) CheckTenant(ctx context.Context, id model.ID) (*model.Tenant, error) {
+	return s.Resolve(ctx, id.String())
+}
+
+// Suspend 停用租户，已登录用户的 Token 在下一次请求时失效
+func (s *TenantLogic) Suspend(ctx context.Context, id model.ID, reason string) error {
+	if reason == "" {
+		return errors.New("suspend reason is required")
+	}
+	now := time.Now()
+	res := s.DB.WithContext(ctx).Model(&model.Tenant{}).Where("id = ?", id).Updates(map[string]any{
+		"status":         StatusDisabled,
+		"suspend_reason": reason,
+		"suspended_at":   &now,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrTenantNotFound
+	}
+	s.clearResolveCache()
+	return nil
+}
+
+// Resume 恢复租户，expiresAt 不为空时同时续期
+func (s *TenantLogic) Resume(ctx context.Context, id model.ID, expiresAt *time.Time) error {
+	values := map[string]any{
+		"status":         StatusEnabled,
+		"suspend_reason": "",
+		"suspended_at":   nil,
+	}
+	if expiresAt != nil {
+		values["expires_at"] = expiresAt
+	}
+	res := s.DB.WithContext(ctx).Model(&model.Tenant{}).Where("id = ?", id).Updates(values)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrTenantNotFound
+	}
+	s.clearResolveCache()
+	return nil
+}
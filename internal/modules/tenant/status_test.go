@@ -0,0 +1,36 @@
+package tenant
+
+import (
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	global.Config.Tenant.GracePeriod = 72 * time.Hour
+	defer func() { global.Config.Tenant.GracePeriod = 0 }()
+	now := time.Now()
+	expired := now.Add(-24 * time.Hour)
+	longExpired := now.Add(-96 * time.Hour)
+
+	assert.NoError(t, Check(&model.Tenant{Status: StatusEnabled}, now))
+
+	// 宽限期内仍然可以访问
+	inGrace := &model.Tenant{Status: StatusEnabled, ExpiresAt: &expired}
+	assert.NoError(t, Check(inGrace, now))
+	assert.True(t, InGracePeriod(inGrace, now))
+
+	err := Check(&model.Tenant{Status: StatusEnabled, ExpiresAt: &longExpired}, now)
+	assert.ErrorIs(t, err, ErrTenantExpired)
+	assert.Equal(t, scope.TenantExpiredCode, StatusCode(err))
+
+	// 停用立即生效，不受宽限期影响
+	err = Check(&model.Tenant{Status: StatusDisabled, SuspendReason: "payment overdue"}, now)
+	assert.ErrorIs(t, err, ErrTenantSuspended)
+	assert.Contains(t, err.Error(), "payment overdue")
+	assert.Equal(t, scope.TenantSuspendedCode, StatusCode(err))
+}
@@ -2,7 +2,10 @@ package user
 
 import (
 	"seedgo/internal/model"
+	"seedgo/internal/scope"
 	"seedgo/internal/shared"
+	"seedgo/pkg"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -19,16 +22,35 @@ func NewHandler() *Handler {
 		logic: logic,
 	}
 	ctrl.BaseHandler = *shared.NewBaseHandler[model.User](logic, nil, ctrl)
+	ctrl.RequireVersion = true
 	return ctrl
 }
 
-// BeforeList 重写BeforeList
+func (c *Handler) Use(g *gin.RouterGroup) {
+	g.GET("/role-expirations", c.RoleExpirations)
+	c.BaseHandler.Use(g)
+}
+
+// RoleExpirations 即将到期的角色分配，days 默认 7 天
+func (c *Handler) RoleExpirations(ctx *gin.Context) {
+	days := pkg.QueryInt(ctx, "days", 7)
+	list, err := c.logic.UpcomingExpirations(ctx.Request.Context(), time.Duration(days)*24*time.Hour)
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, list)
+}
+
+// BeforeList 默认预加载角色和租户，带 fields 或 expand 时只按参数查询
 func (c *Handler) BeforeList(ctx *gin.Context) []func(*gorm.DB) *gorm.DB {
+	if ctx.Query("fields") != "" || ctx.Query("expand") != "" {
+		return nil
+	}
 	return []func(*gorm.DB) *gorm.DB{
 		func(d *gorm.DB) *gorm.DB {
-			return d.Preload("Roles", func(db *gorm.DB) *gorm.DB {
-				return db.Set("skip_tenant_filter", true)
-			}).Preload("Tenant")
+			// 用户只能分配本租户的角色，角色按租户过滤即可
+			return d.Preload("Roles").Preload("Tenant")
 		},
 	}
 }
@@ -0,0 +1,59 @@
+package user_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/user"
+	"seedgo/internal/scope"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPreloads(t *testing.T) {
+	entity := dbtest.Tenant("列表预加载")
+	u := dbtest.User(entity.ID, "preload-user")
+	r := &model.Role{Name: "预加载"}
+	r.TenantID = entity.ID
+	require.NoError(t, dbtest.Seed().Omit("Permissions").Create(r).Error)
+	require.NoError(t, dbtest.Seed().Create(&model.UserRole{UserID: u.ID, RoleID: r.ID}).Error)
+	ctx := dbtest.UserCtx(u)
+
+	router := gin.New()
+	g := router.Group("/users", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(ctx)
+		c.Set("user", scope.GetUserFromContext(ctx))
+	})
+	user.NewHandler().Use(g)
+	list := func(query string) map[string]any {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/users?username=preload-user"+query, nil))
+		var res struct {
+			Code int `json:"code"`
+			Data struct {
+				Items []map[string]any `json:"items"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Equal(t, scope.SuccessCode, res.Code)
+		require.Len(t, res.Data.Items, 1)
+		return res.Data.Items[0]
+	}
+
+	// 默认带角色和租户
+	item := list("")
+	assert.Len(t, item["roles"], 1)
+	assert.NotNil(t, item["tenant"])
+
+	// 带 fields、expand 时只返回请求的字段和关联
+	item = list("&fields=id,username")
+	assert.NotContains(t, item, "roles")
+	assert.NotContains(t, item, "tenant")
+	item = list("&expand=tenant")
+	assert.NotNil(t, item["tenant"])
+	assert.Empty(t, item["roles"])
+}
@@ -0,0 +1,83 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"seedgo/internal/db"
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+	"seedgo/internal/shared"
+	"seedgo/pkg"
+	"strings"
+)
+
+// ImportExtraColumns 导入用户时填写初始密码和角色，多个角色用逗号分隔
+// 密码必填，没有密码的用户无法登录
+func (s *Service) ImportExtraColumns() []shared.ImportColumn {
+	return []shared.ImportColumn{
+		{Key: "password", Title: "密码", Required: true},
+		{Key: "roles", Title: "角色"},
+	}
+}
+
+// BeforeImport 加密初始密码，按名称查找用户所属租户的角色，同一租户内有重名角色时拒绝
+func (s *Service) BeforeImport(ctx context.Context, entity *model.User, row map[string]string) error {
+	if password := row["password"]; password != "" {
+		hash, err := pkg.HashPassword(password)
+		if err != nil {
+			return err
+		}
+		entity.PasswordHash = hash
+	}
+
+	names := strings.FieldsFunc(row["roles"], func(r rune) bool {
+		return r == ',' || r == '，'
+	})
+	if len(names) == 0 {
+		return nil
+	}
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+	tenantID := importTenant(ctx, entity)
+	if tenantID == 0 {
+		return errors.New("tenant is required to resolve roles")
+	}
+	var roles []*model.Role
+	err := s.Conn(db.WithTenant(ctx, tenantID)).Select("id", "name").
+		Where("tenant_id = ? AND name IN ?", tenantID, names).Find(&roles).Error
+	if err != nil {
+		return err
+	}
+	byName := make(map[string][]model.ID, len(roles))
+	for _, r := range roles {
+		byName[r.Name] = append(byName[r.Name], r.ID)
+	}
+	ids := make([]model.ID, 0, len(names))
+	for _, name := range names {
+		switch matched := byName[name]; len(matched) {
+		case 0:
+			return fmt.Errorf("role %s not found", name)
+		case 1:
+			ids = append(ids, matched[0])
+		default:
+			return fmt.Errorf("role %s is ambiguous", name)
+		}
+	}
+	entity.RoleIds = &ids
+	return nil
+}
+
+// importTenant 导入的用户所属的租户，与创建时 TenantPlugin 填充 TenantID 的规则一致：
+// 普通用户固定为上下文中的租户，超级用户可以指定 tenantId，没有指定时使用选择的租户
+func importTenant(ctx context.Context, entity *model.User) model.ID {
+	tenantID, filter, _ := db.TenantScope(ctx)
+	if !filter {
+		return entity.TenantID
+	}
+	if user := scope.GetUserFromContext(ctx); user != nil && user.IsSuper && entity.TenantID != 0 {
+		return entity.TenantID
+	}
+	return tenantID
+}
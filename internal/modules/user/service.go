@@ -3,9 +3,13 @@ package user
 import (
 	"context"
 	"errors"
+	"reflect"
+	"seedgo/internal/db"
 	"seedgo/internal/form"
 	"seedgo/internal/model"
 	"seedgo/internal/modules/perms"
+	"seedgo/internal/modules/tenant"
+	"seedgo/internal/scope"
 	"seedgo/internal/shared"
 	"seedgo/pkg"
 	"sync"
@@ -14,14 +18,27 @@ import (
 	"gorm.io/gorm"
 )
 
+// 租户主账号规则：每个租户只有一个主账号，只能通过转让变更；
+// 主账号不能删除，其他用户（超级用户除外）不能修改、停用主账号或调整它的角色
+var (
+	ErrMainAccountDelete    = errors.New("main account cannot be deleted, transfer ownership first")
+	ErrMainAccountProtected = errors.New("main account can only be modified by its owner")
+	ErrMainAccountChange    = errors.New("main account can only be changed by ownership transfer")
+	ErrNotOwner             = errors.New("only the main account can transfer ownership")
+	ErrInvalidPassword      = errors.New("invalid password")
+	ErrInvalidTransferee    = errors.New("ownership can only be transferred to another enabled user of the tenant")
+)
+
 type Service struct {
 	*shared.BaseService[model.User]
 }
 
 func NewService() *Service {
-	return &Service{
+	s := &Service{
 		BaseService: shared.NewBaseService[model.User](),
 	}
+	s.Impl = s
+	return s
 }
 
 // 单例模式
@@ -39,7 +56,14 @@ func GetService() *Service {
 }
 
 func (s *Service) Login(ctx context.Context, dto form.LoginDTO) (*form.LoginVO, error) {
-	user, err := s.FindByUsername(dto.Username)
+	// 识别出租户时只在该租户内查找用户
+	var user *model.User
+	var err error
+	if t := scope.GetTenantFromContext(ctx); t != nil {
+		user, err = s.FindByTenantUsername(t.ID, dto.Username)
+	} else {
+		user, err = s.FindByUsername(dto.Username)
+	}
 	if err != nil {
 		return nil, errors.New("invalid username or password")
 	}
@@ -52,11 +76,22 @@ func (s *Service) Login(ctx context.Context, dto form.LoginDTO) (*form.LoginVO,
 		return nil, errors.New("user is disabled")
 	}
 
+	// 租户停用或过期（超过宽限期）不能登录，超级用户除外
+	if user.IsSuper == nil || !*user.IsSuper {
+		if _, err := tenant.GetService().CheckTenant(ctx, user.TenantID); err != nil {
+			return nil, err
+		}
+	}
+
+	// 登录时还没有用户上下文，按用户所在租户更新
+	ctx = db.WithTenant(ctx, user.TenantID)
+
 	// Update login info
 	now := time.Now()
 	user.LastLoginAt = &now
 	// user.LastLoginIP = ... // context is needed to get IP, or passed in DTO
-	err = s.Update(ctx, user)
+	// 登录信息不是可写字段，单独更新，也不改变乐观锁版本号
+	err = s.Conn(ctx).Model(user).Update("last_login_at", user.LastLoginAt).Error
 	if err != nil {
 		return nil, err
 	}
@@ -77,8 +112,8 @@ func (s *Service) Login(ctx context.Context, dto form.LoginDTO) (*form.LoginVO,
 	}, nil
 }
 
-func (s *Service) GetProfile(userID model.ID) (*model.User, error) {
-	return s.FindByIdWithRoles(userID)
+func (s *Service) GetProfile(ctx context.Context, userID model.ID) (*model.User, error) {
+	return s.FindByIdWithRoles(ctx, userID)
 }
 
 func (s *Service) UpdateProfile(ctx context.Context, uid model.ID, dto form.UpdateProfileDTO) error {
@@ -105,7 +140,8 @@ func (s *Service) ChangePassword(ctx context.Context, uid model.ID, dto form.Cha
 		return err
 	}
 	user.PasswordHash = hash
-	return s.Update(ctx, user)
+	// 密码不是可写字段，单独更新
+	return s.Conn(ctx).Model(user).Update("password_hash", hash).Error
 }
 
 func (s *Service) Logout(uid model.ID) error {
@@ -114,7 +150,14 @@ func (s *Service) Logout(uid model.ID) error {
 
 // Create 创建
 func (s *Service) Create(ctx context.Context, entity *model.User) error {
-	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	// 主账号在创建租户时生成，之后只能转让
+	if isMain(entity) {
+		return ErrMainAccountChange
+	}
+	return s.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.RunCreateHooks(ctx, tx, entity); err != nil {
+			return err
+		}
 		// 处理关联角色
 		if entity.RoleIds != nil && len(*entity.RoleIds) > 0 {
 			var roles []*model.Role
@@ -127,25 +170,42 @@ func (s *Service) Create(ctx context.Context, entity *model.User) error {
 		if err := tx.Create(entity).Error; err != nil {
 			return err
 		}
+
+		// 处理带有效期的角色分配
+		if entity.RoleAssignments != nil {
+			return saveRoleAssignments(tx, entity.ID, *entity.RoleAssignments)
+		}
 		return nil
 	})
 }
 
 // Update 更新
 func (s *Service) Update(ctx context.Context, entity *model.User) error {
-	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 更新基本信息
-		txChain := tx.Omit("created_at")
-		// 如果密码为空，不更新密码
-		if entity.PasswordHash == "" {
-			txChain = txChain.Omit("password_hash")
+	columns, err := s.WritableColumns(ctx, entity)
+	if err != nil {
+		return err
+	}
+	err = s.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// 关联表没有租户字段，先确认用户属于当前租户
+		var current model.User
+		if err := tx.Select("id", "is_main").First(&current, entity.ID).Error; err != nil {
+			return err
 		}
-
-		if err := txChain.Model(entity).Updates(entity).Error; err != nil {
+		if err := checkMainUpdate(ctx, &current, entity); err != nil {
+			return err
+		}
+		// 更新基本信息，只写入可写的列，和 Updates 一样跳过没有值的字段
+		err := s.Versioned(tx, entity, func(tx *gorm.DB) *gorm.DB {
+			return tx.Model(entity).Select(s.changedColumns(entity, columns)).Updates(entity)
+		})
+		if err != nil {
 			return err
 		}
 
-		// 处理关联角色
+		// 处理关联角色，带有效期的角色分配优先
+		if entity.RoleAssignments != nil {
+			return saveRoleAssignments(tx, entity.ID, *entity.RoleAssignments)
+		}
 		if entity.RoleIds != nil {
 			var roles []*model.Role
 			if len(*entity.RoleIds) > 0 {
@@ -160,16 +220,215 @@ func (s *Service) Update(ctx context.Context, entity *model.User) error {
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	// 角色变化后清除该用户的权限缓存，在外层事务中（如批量更新）时等提交后再清除
+	if entity.RoleIds != nil || entity.RoleAssignments != nil {
+		return db.AfterCommit(ctx, func() error {
+			return perms.GetService().InvalidateUsers(entity.ID)
+		})
+	}
+	return nil
+}
+
+// changedColumns 可写列中有值的列，Select 后 Updates 会写入零值，需要先去掉没有提交的字段
+func (s *Service) changedColumns(entity *model.User, columns []string) []string {
+	stmt := &gorm.Statement{DB: s.DB}
+	if err := stmt.Parse(entity); err != nil {
+		return columns
+	}
+	value := reflect.ValueOf(entity).Elem()
+	changed := []string{"updated_at"}
+	for _, name := range columns {
+		field := stmt.Schema.LookUpField(name)
+		if _, zero := field.ValueOf(context.Background(), value); !zero {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+func isMain(u *model.User) bool {
+	return u.IsMain != nil && *u.IsMain == 1
+}
+
+// checkMainUpdate 主账号标记只能通过转让修改，主账号只有自己和超级用户可以修改
+// 没有登录用户的上下文（登录、后台任务）视为系统调用
+func checkMainUpdate(ctx context.Context, current, entity *model.User) error {
+	if entity.IsMain != nil && isMain(entity) != isMain(current) {
+		return ErrMainAccountChange
+	}
+	if !isMain(current) {
+		return nil
+	}
+	if user := scope.GetUserFromContext(ctx); user != nil && !user.IsSuper && user.ID != current.ID {
+		return ErrMainAccountProtected
+	}
+	return nil
+}
+
+// Delete 删除用户，主账号不能删除
+func (s *Service) Delete(ctx context.Context, id model.ID) error {
+	var mains int64
+	if err := s.Conn(ctx).Model(&model.User{}).Where("id = ? AND is_main = ?", id, 1).Count(&mains).Error; err != nil {
+		return err
+	}
+	if mains > 0 {
+		return ErrMainAccountDelete
+	}
+	return s.BaseService.Delete(ctx, id)
+}
+
+// TransferOwnership 转让租户主账号，需要当前操作用户再次输入密码
+// 主账号转让给本租户的其他用户；超级用户可以转让任意租户的主账号（如主账号无法登录时），租户为目标用户所在的租户
+func (s *Service) TransferOwnership(ctx context.Context, operator *scope.UserContext, dto form.TransferOwnershipDTO) error {
+	var self model.User
+	selfCtx := db.WithTenant(ctx, operator.TenantID)
+	if err := s.DB.WithContext(selfCtx).First(&self, operator.ID).Error; err != nil {
+		return err
+	}
+	if !pkg.CheckPasswordHash(dto.Password, self.PasswordHash) {
+		return ErrInvalidPassword
+	}
+
+	tenantID := operator.TenantID
+	if operator.IsSuper {
+		var target model.User
+		err := s.DB.WithContext(db.WithoutTenant(ctx, "find ownership transferee")).Select("id", "tenant_id").First(&target, dto.UserID).Error
+		if err != nil {
+			return ErrInvalidTransferee
+		}
+		tenantID = target.TenantID
+	} else if !isMain(&self) {
+		return ErrNotOwner
+	}
+
+	var previous []model.ID
+	err := s.DB.WithContext(db.WithTenant(ctx, tenantID)).Transaction(func(tx *gorm.DB) error {
+		var target model.User
+		if err := tx.Select("id", "is_main", "status").First(&target, dto.UserID).Error; err != nil {
+			return ErrInvalidTransferee
+		}
+		if isMain(&target) || (target.Status != nil && *target.Status == 0) {
+			return ErrInvalidTransferee
+		}
+		if err := tx.Model(&model.User{}).Where("is_main = ?", 1).Pluck("id", &previous).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.User{}).Where("is_main = ?", 1).Update("is_main", 0).Error; err != nil {
+			return err
+		}
+		return tx.Model(&target).Update("is_main", 1).Error
+	})
+	if err != nil {
+		return err
+	}
+	// 主账号的权限不依赖角色，转让双方的权限缓存都需要失效
+	return perms.GetService().InvalidateUsers(append(previous, dto.UserID)...)
 }
 
+// saveRoleAssignments 全量替换用户的角色分配（含有效期）
+func saveRoleAssignments(tx *gorm.DB, userID model.ID, assignments []model.UserRole) error {
+	var roleIDs []model.ID
+	for _, a := range assignments {
+		if a.ValidFrom != nil && a.ValidUntil != nil && !a.ValidUntil.After(*a.ValidFrom) {
+			return errors.New("validUntil must be after validFrom")
+		}
+		roleIDs = append(roleIDs, a.RoleID)
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&model.UserRole{}).Error; err != nil {
+		return err
+	}
+	if len(roleIDs) == 0 {
+		return nil
+	}
+
+	// 只保留当前租户下存在的角色
+	var valid []model.ID
+	if err := tx.Model(&model.Role{}).Where("id IN ?", roleIDs).Pluck("id", &valid).Error; err != nil {
+		return err
+	}
+	exists := make(map[model.ID]bool)
+	for _, id := range valid {
+		exists[id] = true
+	}
+
+	var rows []*model.UserRole
+	for _, a := range assignments {
+		if !exists[a.RoleID] {
+			continue
+		}
+		exists[a.RoleID] = false // 同一角色只保留第一条
+		rows = append(rows, &model.UserRole{
+			UserID:     userID,
+			RoleID:     a.RoleID,
+			ValidFrom:  a.ValidFrom,
+			ValidUntil: a.ValidUntil,
+		})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
+
+// RoleExpiration 即将到期的角色分配
+type RoleExpiration struct {
+	UserID     model.ID  `json:"userId"`
+	Username   string    `json:"username"`
+	RoleID     model.ID  `json:"roleId"`
+	RoleName   string    `json:"roleName"`
+	ValidUntil time.Time `json:"validUntil"`
+}
+
+// UpcomingExpirations 获取指定时间内即将到期的角色分配，按到期时间排序
+func (s *Service) UpcomingExpirations(ctx context.Context, within time.Duration) ([]*RoleExpiration, error) {
+	now := time.Now()
+	var list []*RoleExpiration
+	err := s.DB.WithContext(ctx).Model(&model.User{}).
+		Select("user.id AS user_id, user.username, role.id AS role_id, role.name AS role_name, user_role.valid_until").
+		Joins("JOIN user_role ON user_role.user_id = user.id").
+		// 已删除的角色不再提醒
+		Joins("JOIN role ON role.id = user_role.role_id AND role.deleted_at IS NULL").
+		Where("user_role.valid_until > ? AND user_role.valid_until <= ?", now, now.Add(within)).
+		Order("user_role.valid_until").
+		Find(&list).Error
+	return list, err
+}
+
+// FindByUsername 按用户名查找用户，登录时还不知道租户，需要跨租户查找
 func (s *Service) FindByUsername(username string) (*model.User, error) {
 	var user model.User
-	err := s.DB.Where("username = ?", username).Preload("Roles").First(&user).Error
+	ctx := db.WithoutTenant(context.Background(), "find user by username for login")
+	err := s.DB.WithContext(ctx).Scopes(db.ActiveRoles).Where("username = ?", username).Preload("Roles").First(&user).Error
+	return &user, err
+}
+
+// FindByTenantUsername 在指定租户内按用户名查找用户
+func (s *Service) FindByTenantUsername(tenantID model.ID, username string) (*model.User, error) {
+	var user model.User
+	ctx := db.WithTenant(context.Background(), tenantID)
+	err := s.DB.WithContext(ctx).Scopes(db.ActiveRoles).Where("username = ?", username).Preload("Roles").First(&user).Error
 	return &user, err
 }
 
-func (s *Service) FindByIdWithRoles(id model.ID) (*model.User, error) {
+// FieldsScope 展开的角色只包含有效期内的分配，和权限计算一致
+func (s *Service) FieldsScope(fields, expand []string, required ...string) (func(*gorm.DB) *gorm.DB, error) {
+	fieldsScope, err := s.BaseService.FieldsScope(fields, expand, required...)
+	if err != nil {
+		return nil, err
+	}
+	return func(tx *gorm.DB) *gorm.DB {
+		return fieldsScope(db.ActiveRoles(tx))
+	}, nil
+}
+
+// FindByIdWithRoles 查询用户和有效期内的角色
+func (s *Service) FindByIdWithRoles(ctx context.Context, id model.ID) (*model.User, error) {
 	var user model.User
-	err := s.DB.Preload("Roles").Omit("passwordHash").First(&user, id).Error
+	err := s.DB.WithContext(ctx).Scopes(db.ActiveRoles).Preload("Roles").Omit("passwordHash").First(&user, id).Error
 	return &user, err
 }
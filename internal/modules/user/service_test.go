@@ -0,0 +1,234 @@
+package user_test
+
+import (
+	"context"
+	"seedgo/internal/db"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/form"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/perms"
+	"seedgo/internal/modules/role"
+	"seedgo/internal/modules/tenant"
+	"seedgo/internal/modules/user"
+	"seedgo/internal/scope"
+	"seedgo/pkg"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMainAccountOwnership(t *testing.T) {
+	page := &model.Permission{Name: "用户管理", PermissionUrls: "/system/users", PermissionCode: "system:user"}
+	require.NoError(t, dbtest.Seed().Create(page).Error)
+	require.NoError(t, dbtest.Seed().Create(&model.Permission{ParentID: &page.ID, Name: "删除", PermissionCode: "system:user:delete"}).Error)
+	super := dbtest.UserCtx(dbtest.Super(dbtest.Tenant("platform").ID, "admin"))
+	entity := &model.Tenant{Name: "主账号租户", Status: 1, Username: "shop-owner", Password: "owner-pass"}
+	require.NoError(t, tenant.GetService().Create(super, entity))
+
+	svc := user.GetService()
+	tctx := db.WithTenant(context.Background(), entity.ID)
+	var owner model.User
+	require.NoError(t, svc.DB.WithContext(tctx).Where("username = ?", "shop-owner").First(&owner).Error)
+	hash, _ := pkg.HashPassword("member-pass")
+	member := &model.User{Username: "shop-member", PasswordHash: hash}
+	member.TenantID = entity.ID
+	require.NoError(t, svc.DB.WithContext(tctx).Create(member).Error)
+
+	// 只能有一个主账号，主账号不能删除，其他用户不能修改
+	isMain := int8(1)
+	assert.ErrorIs(t, svc.Create(tctx, &model.User{Username: "second-main", PasswordHash: hash, IsMain: &isMain}), user.ErrMainAccountChange)
+	memberCtx := dbtest.UserCtx(member)
+	assert.ErrorIs(t, svc.Delete(memberCtx, owner.ID), user.ErrMainAccountDelete)
+	disabled := int8(0)
+	assert.ErrorIs(t, svc.Update(memberCtx, &model.User{BaseTenantModel: owner.BaseTenantModel, Status: &disabled}), user.ErrMainAccountProtected)
+	ownerCtx := dbtest.UserCtx(&owner)
+	realName := "店主"
+	assert.NoError(t, svc.Update(ownerCtx, &model.User{BaseTenantModel: owner.BaseTenantModel, RealName: &realName}))
+
+	// 主账号没有角色也拥有平台权限以外的所有权限
+	global.Config.Permission.PlatformCodes = []string{"system:user:delete"}
+	defer func() { global.Config.Permission.PlatformCodes = nil }()
+	ownerPerms, err := perms.GetService().GetUserPerms(&scope.UserContext{ID: owner.ID, TenantID: entity.ID})
+	require.NoError(t, err)
+	require.Len(t, ownerPerms.Tree, 1)
+	assert.Equal(t, "system:user", ownerPerms.Tree[0].PermissionCode)
+	assert.Empty(t, ownerPerms.Tree[0].Children)
+
+	// 转让需要主账号本人并确认密码
+	operator := &scope.UserContext{ID: member.ID, TenantID: entity.ID}
+	assert.ErrorIs(t, svc.TransferOwnership(memberCtx, operator, form.TransferOwnershipDTO{UserID: member.ID, Password: "member-pass"}), user.ErrNotOwner)
+	operator = &scope.UserContext{ID: owner.ID, TenantID: entity.ID}
+	assert.ErrorIs(t, svc.TransferOwnership(ownerCtx, operator, form.TransferOwnershipDTO{UserID: member.ID, Password: "wrong"}), user.ErrInvalidPassword)
+	require.NoError(t, svc.TransferOwnership(ownerCtx, operator, form.TransferOwnershipDTO{UserID: member.ID, Password: "owner-pass"}))
+
+	var mains []model.ID
+	svc.DB.WithContext(tctx).Model(&model.User{}).Where("is_main = ?", 1).Pluck("id", &mains)
+	assert.Equal(t, []model.ID{member.ID}, mains)
+	assert.NoError(t, svc.Delete(memberCtx, owner.ID))
+}
+
+func TestUpdateWritableColumns(t *testing.T) {
+	entity := dbtest.Tenant("可写字段")
+	u := dbtest.User(entity.ID, "writable-user")
+	svc := user.GetService()
+	ctx := dbtest.UserCtx(u)
+
+	// 传入完整的实体时，不可写的列（密码、登录时间）和特权列同样不写入
+	loaded, err := svc.Get(ctx, u.ID)
+	require.NoError(t, err)
+	now := time.Now()
+	isSuper := true
+	realName := "张三"
+	loaded.PasswordHash = "changed"
+	loaded.LastLoginAt = &now
+	loaded.IsSuper = &isSuper
+	loaded.RealName = &realName
+	require.NoError(t, svc.Update(ctx, loaded))
+
+	var saved model.User
+	require.NoError(t, dbtest.Seed().First(&saved, u.ID).Error)
+	assert.Equal(t, u.PasswordHash, saved.PasswordHash)
+	assert.Nil(t, saved.LastLoginAt)
+	assert.False(t, *saved.IsSuper)
+	assert.Equal(t, "张三", *saved.RealName)
+
+	// 没有提交的字段保持不变
+	phone := "13800000000"
+	partial := &model.User{Phone: &phone}
+	partial.ID = u.ID
+	require.NoError(t, svc.Update(ctx, partial))
+	require.NoError(t, dbtest.Seed().First(&saved, u.ID).Error)
+	assert.Equal(t, "writable-user", saved.Username)
+	assert.Equal(t, "张三", *saved.RealName)
+	assert.Equal(t, phone, *saved.Phone)
+
+	// 密码和登录信息单独更新
+	require.NoError(t, svc.ChangePassword(ctx, u.ID, form.ChangePasswordDTO{OldPassword: dbtest.Password, NewPassword: "new-pass"}))
+	_, err = svc.Login(context.Background(), form.LoginDTO{Username: "writable-user", Password: "new-pass"})
+	require.NoError(t, err)
+	require.NoError(t, dbtest.Seed().First(&saved, u.ID).Error)
+	assert.NotNil(t, saved.LastLoginAt)
+}
+
+func TestRolesWithinValidity(t *testing.T) {
+	entity := dbtest.Tenant("角色有效期")
+	u := dbtest.User(entity.ID, "validity-user")
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	newRole := func(name string, from, until *time.Time) *model.Role {
+		r := &model.Role{Name: name}
+		r.TenantID = entity.ID
+		require.NoError(t, dbtest.Seed().Omit("Permissions").Create(r).Error)
+		require.NoError(t, dbtest.Seed().Create(&model.UserRole{UserID: u.ID, RoleID: r.ID, ValidFrom: from, ValidUntil: until}).Error)
+		return r
+	}
+	active := newRole("生效中", &past, &future)
+	newRole("已过期", nil, &past)
+	newRole("未生效", &future, nil)
+	roleNames := func(roles []*model.Role) []string {
+		names := make([]string, 0, len(roles))
+		for _, r := range roles {
+			names = append(names, r.Name)
+		}
+		return names
+	}
+
+	// 个人信息、登录返回和列表展开都只包含生效中的角色
+	svc := user.GetService()
+	ctx := dbtest.UserCtx(u)
+	profile, err := svc.GetProfile(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"生效中"}, roleNames(profile.Roles))
+
+	vo, err := svc.Login(context.Background(), form.LoginDTO{Username: "validity-user", Password: dbtest.Password})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"生效中"}, roleNames(vo.User.Roles))
+
+	fieldsScope, err := svc.FieldsScope(nil, []string{"roles"})
+	require.NoError(t, err)
+	expanded, err := svc.Get(ctx, u.ID, fieldsScope)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"生效中"}, roleNames(expanded.Roles))
+
+	// 角色展开用户时同样只包含有效期内的分配
+	roleScope, err := role.Instance().FieldsScope(nil, []string{"users"})
+	require.NoError(t, err)
+	withUsers, err := role.Instance().Get(ctx, active.ID, roleScope)
+	require.NoError(t, err)
+	require.Len(t, withUsers.Users, 1)
+	var expired model.Role
+	require.NoError(t, dbtest.Seed().Where("name = ?", "已过期").First(&expired).Error)
+	withUsers, err = role.Instance().Get(ctx, expired.ID, roleScope)
+	require.NoError(t, err)
+	assert.Empty(t, withUsers.Users)
+}
+
+func TestImportRolesByTenant(t *testing.T) {
+	tenantA, tenantB := dbtest.Tenant("导入A"), dbtest.Tenant("导入B")
+	newRole := func(tenantID model.ID, name string) *model.Role {
+		r := &model.Role{Name: name}
+		r.TenantID = tenantID
+		require.NoError(t, dbtest.Seed().Create(r).Error)
+		return r
+	}
+	opsA, opsB := newRole(tenantA.ID, "运营"), newRole(tenantB.ID, "运营")
+	newRole(tenantB.ID, "重复")
+	newRole(tenantB.ID, "重复")
+
+	svc := user.GetService()
+	importRoles := func(ctx context.Context, tenantID model.ID, roles string) ([]model.ID, error) {
+		entity := &model.User{Username: "import-user"}
+		entity.TenantID = tenantID
+		if err := svc.BeforeImport(ctx, entity, map[string]string{"roles": roles}); err != nil {
+			return nil, err
+		}
+		return *entity.RoleIds, nil
+	}
+
+	// 超级用户按行中的租户查找角色
+	super := dbtest.UserCtx(dbtest.Super(tenantA.ID, "import-admin"))
+	ids, err := importRoles(super, tenantB.ID, "运营")
+	require.NoError(t, err)
+	assert.Equal(t, []model.ID{opsB.ID}, ids)
+
+	// 普通用户只能使用本租户的角色，行中的租户被忽略
+	member := dbtest.UserCtx(dbtest.User(tenantA.ID, "import-member"))
+	ids, err = importRoles(member, tenantB.ID, "运营")
+	require.NoError(t, err)
+	assert.Equal(t, []model.ID{opsA.ID}, ids)
+
+	// 同一租户内重名的角色无法确定，拒绝导入
+	_, err = importRoles(super, tenantB.ID, "重复")
+	assert.EqualError(t, err, "role 重复 is ambiguous")
+
+	// 没有租户时无法查找角色
+	_, err = importRoles(super, 0, "运营")
+	assert.Error(t, err)
+}
+
+func TestUpcomingExpirations(t *testing.T) {
+	entity := dbtest.Tenant("即将到期")
+	u := dbtest.User(entity.ID, "expiring-user")
+	soon, later := time.Now().Add(time.Hour), time.Now().Add(30*24*time.Hour)
+	newRole := func(name string, until time.Time) *model.Role {
+		r := &model.Role{Name: name}
+		r.TenantID = entity.ID
+		require.NoError(t, dbtest.Seed().Omit("Permissions").Create(r).Error)
+		require.NoError(t, dbtest.Seed().Create(&model.UserRole{UserID: u.ID, RoleID: r.ID, ValidUntil: &until}).Error)
+		return r
+	}
+	expiring := newRole("即将到期", soon)
+	newRole("还早", later)
+	deleted := newRole("已删除", soon)
+	require.NoError(t, dbtest.Seed().Delete(deleted).Error)
+
+	// 只包含时间范围内、角色没有删除的分配
+	list, err := user.GetService().UpcomingExpirations(dbtest.UserCtx(u), 24*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, expiring.ID, list[0].RoleID)
+	assert.Equal(t, "即将到期", list[0].RoleName)
+}
@@ -1,6 +1,7 @@
 package scope
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -14,9 +15,12 @@ type Response struct {
 }
 
 // PageResult 分页数据结构
+// 游标分页时 NextCursor 为下一页的游标，为空表示没有更多数据；不统计总数时 Total 为 -1，估算时 Estimated 为 true
 type PageResult struct {
-	Items interface{} `json:"items"`
-	Total int64       `json:"total"`
+	Items      interface{} `json:"items"`
+	Total      int64       `json:"total"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+	Estimated  bool        `json:"estimated,omitempty"`
 }
 
 // 预定义业务码 (ERP 常用)
@@ -24,6 +28,12 @@ const (
 	SuccessCode       = 0
 	ErrorCode         = 70001 // 通用错误
 	InvalidParamsCode = 70002 // 参数错误
+	ConflictCode      = 70003 // 记录已被其他人修改
+
+	TenantSuspendedCode = 70101 // 租户已停用
+	TenantExpiredCode   = 70102 // 租户已过期
+	QuotaExceededCode   = 70103 // 超出租户配额
+	TenantDeletedCode   = 70104 // 租户已删除
 )
 
 // Result 统一调用入口
@@ -56,3 +66,30 @@ func Fail(c *gin.Context, msg string) {
 func FailWithCode(c *gin.Context, code int, msg string) {
 	Result(code, nil, msg, c)
 }
+
+// CodedError 携带业务码的错误
+type CodedError interface {
+	error
+	Code() int
+}
+
+// DataError 携带业务码和数据的错误，如乐观锁冲突时返回服务器上的当前记录
+type DataError interface {
+	CodedError
+	Data() interface{}
+}
+
+// FailWithError 返回错误信息，错误链中有 CodedError 时使用它的业务码，有 DataError 时同时返回数据
+func FailWithError(c *gin.Context, err error) {
+	var withData DataError
+	if errors.As(err, &withData) {
+		Result(withData.Code(), withData.Data(), err.Error(), c)
+		return
+	}
+	var coded CodedError
+	if errors.As(err, &coded) {
+		FailWithCode(c, coded.Code(), err.Error())
+		return
+	}
+	Fail(c, err.Error())
+}
@@ -0,0 +1,41 @@
+package scope
+
+import (
+	"context"
+	"seedgo/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantContext 由 TenantResolver 从请求中识别出的租户
+type TenantContext struct {
+	ID     model.ID `json:"id"`
+	Name   string   `json:"name"`
+	Code   string   `json:"code"`
+	Source string   `json:"source"` // 识别来源：header、subdomain、path
+}
+
+// GetCurrentTenant 从 gin.Context 中获取识别出的租户，没有返回 nil
+func GetCurrentTenant(c *gin.Context) *TenantContext {
+	obj, exists := c.Get("tenant")
+	if !exists {
+		return nil
+	}
+	tenant, ok := obj.(*TenantContext)
+	if !ok {
+		return nil
+	}
+	return tenant
+}
+
+// GetTenantFromContext 从 context.Context 中获取识别出的租户，没有返回 nil
+func GetTenantFromContext(ctx context.Context) *TenantContext {
+	if ctx == nil {
+		return nil
+	}
+	tenant, ok := ctx.Value("tenant").(*TenantContext)
+	if !ok {
+		return nil
+	}
+	return tenant
+}
@@ -1,6 +1,8 @@
 package scope
 
 import (
+	"context"
+	"net/http"
 	"seedgo/internal/model"
 
 	"github.com/gin-gonic/gin"
@@ -12,6 +14,16 @@ type UserContext struct {
 	TenantID model.ID      `json:"tenantId"`
 	IsSuper  bool          `json:"isSuper"`
 	Roles    []*model.Role `json:"roles"`
+	// ActingTenantID 超级用户选择的租户，不为 0 时按该租户过滤数据，创建的数据归属该租户
+	ActingTenantID model.ID `json:"actingTenantId,omitempty"`
+}
+
+// CurrentTenantID 当前操作的租户：超级用户选择了租户时为该租户，否则为用户所在的租户
+func (u *UserContext) CurrentTenantID() model.ID {
+	if u.IsSuper && u.ActingTenantID != 0 {
+		return u.ActingTenantID
+	}
+	return u.TenantID
 }
 
 // GetCurrentUser 从 Context 中获取当前登录用户
@@ -31,3 +43,25 @@ func GetCurrentUser(c *gin.Context) *UserContext {
 
 	return user
 }
+
+// RequireSuper 要求当前用户为超级用户，不是时返回 403 并返回 false，调用方直接 return
+func RequireSuper(c *gin.Context) bool {
+	if user := GetCurrentUser(c); user != nil && user.IsSuper {
+		return true
+	}
+	FailWithCode(c, http.StatusForbidden, "Forbidden")
+	return false
+}
+
+// GetUserFromContext 从 context.Context 中获取当前登录用户，用于 service、db 等没有 gin.Context 的层
+// 后台任务等没有登录用户的场景返回 nil
+func GetUserFromContext(ctx context.Context) *UserContext {
+	if ctx == nil {
+		return nil
+	}
+	user, ok := ctx.Value("user").(*UserContext)
+	if !ok {
+		return nil
+	}
+	return user
+}
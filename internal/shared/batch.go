@@ -0,0 +1,158 @@
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"seedgo/internal/db"
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+	"seedgo/pkg"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// MaxBatchSize 单次批量操作的最大记录数
+const MaxBatchSize = 1000
+
+var (
+	ErrEmptyBatch    = errors.New("ids is required")
+	ErrBatchTooLarge = fmt.Errorf("batch size exceeds %d", MaxBatchSize)
+	ErrBatchFailed   = errors.New("batch operation failed, all changes rolled back")
+	ErrNoFields      = errors.New("fields is required")
+)
+
+// BatchResult 批量操作中单条记录的结果
+// 批次失败时整个事务回滚，Success 为 true 的记录表示该记录本身执行成功，但同样没有生效
+type BatchResult struct {
+	ID      model.ID `json:"id"`
+	Success bool     `json:"success"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// impl 批量操作调用的 service，模块设置了 Impl 时使用模块重写的方法
+func (s *BaseService[T]) impl() IBaseService[T] {
+	if s.Impl != nil {
+		return s.Impl
+	}
+	return s
+}
+
+// RunBatch 在一个事务中对每个 id 执行 fn，重复的 id 只执行一次
+// fn 收到的 context 携带事务，通过 Conn 取得连接即加入事务；每个 id 在独立的保存点中执行，
+// 失败后继续执行其他 id 以收集全部结果，有失败时整个事务回滚并返回 ErrBatchFailed
+// fn 通过 db.AfterCommit 登记的缓存失效等副作用在事务提交后执行，回滚时不执行
+func (s *BaseService[T]) RunBatch(ctx context.Context, ids []model.ID, fn func(ctx context.Context, id model.ID) error) ([]*BatchResult, error) {
+	if len(ids) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if len(ids) > MaxBatchSize {
+		return nil, ErrBatchTooLarge
+	}
+
+	ctx, afterCommit := db.WithAfterCommit(ctx)
+	results := make([]*BatchResult, 0, len(ids))
+	err := s.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		seen := make(map[model.ID]bool, len(ids))
+		failed := false
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			result := &BatchResult{ID: id, Success: true}
+			err := tx.Transaction(func(sp *gorm.DB) error {
+				return fn(db.WithTx(ctx, sp), id)
+			})
+			if err != nil {
+				result.Success = false
+				result.Error = err.Error()
+				failed = true
+			}
+			results = append(results, result)
+		}
+		if failed {
+			return ErrBatchFailed
+		}
+		return nil
+	})
+	if err != nil {
+		return results, err
+	}
+	return results, afterCommit()
+}
+
+// BatchDelete 批量删除，逐个调用模块的 Delete，和单条删除执行相同的检查和缓存失效
+func (s *BaseService[T]) BatchDelete(ctx context.Context, ids []model.ID) ([]*BatchResult, error) {
+	return s.RunBatch(ctx, ids, s.impl().Delete)
+}
+
+// BatchUpdate 批量更新相同的字段，如批量启用、停用，逐个调用 UpdateFields
+func (s *BaseService[T]) BatchUpdate(ctx context.Context, ids []model.ID, fields map[string]any) ([]*BatchResult, error) {
+	// 字段不合法时所有记录都会失败，提前返回
+	if err := s.CheckFields(ctx, fields); err != nil {
+		return nil, err
+	}
+	return s.RunBatch(ctx, ids, func(ctx context.Context, id model.ID) error {
+		return s.impl().UpdateFields(ctx, id, fields)
+	})
+}
+
+// UpdateFields 更新单条记录的部分字段
+// 读取记录后把字段合并进去，再调用模块的 Update，和单条更新执行相同的校验和缓存失效
+// 记录不存在或不属于当前租户时返回 gorm.ErrRecordNotFound
+func (s *BaseService[T]) UpdateFields(ctx context.Context, id model.ID, fields map[string]any) error {
+	if err := s.CheckFields(ctx, fields); err != nil {
+		return err
+	}
+	entity := new(T)
+	if err := s.Conn(ctx).First(entity, id).Error; err != nil {
+		return err
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, entity); err != nil {
+		return err
+	}
+	return s.impl().Update(ctx, entity)
+}
+
+// CheckFields 检查按 JSON 字段名提交的字段都是当前用户可写的列（seedgo:"writable"）
+// 不可写的字段返回错误而不是忽略，避免调用方误以为已经修改；关联和 gorm:"-" 字段不能批量修改
+func (s *BaseService[T]) CheckFields(ctx context.Context, fields map[string]any) error {
+	if len(fields) == 0 {
+		return ErrNoFields
+	}
+	entity := new(T)
+	stmt := &gorm.Statement{DB: s.DB}
+	if err := stmt.Parse(entity); err != nil {
+		return err
+	}
+	user := scope.GetUserFromContext(ctx)
+	writable := make(map[string]bool)
+	for _, name := range pkg.WritableFields(entity, user == nil || user.IsSuper) {
+		writable[name] = true
+	}
+	byJSON := make(map[string]string)
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		byJSON[name] = field.Name
+	}
+	for key := range fields {
+		if name, ok := byJSON[key]; !ok || !writable[name] {
+			return fmt.Errorf("field %s is not writable", key)
+		}
+	}
+	return nil
+}
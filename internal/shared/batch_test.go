@@ -0,0 +1,112 @@
+package shared_test
+
+import (
+	"context"
+	"seedgo/internal/db"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/user"
+	"seedgo/internal/shared"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchOperations(t *testing.T) {
+	svc := user.GetService()
+	entity := dbtest.Tenant("批量操作")
+	tctx := db.WithTenant(context.Background(), entity.ID)
+	t.Cleanup(func() {
+		svc.DB.WithContext(tctx).Unscoped().Where("tenant_id = ?", entity.ID).Delete(&model.User{})
+	})
+	newUser := func(name string, main bool) *model.User {
+		u := &model.User{Username: name, PasswordHash: "x"}
+		u.TenantID = entity.ID
+		if main {
+			isMain := int8(1)
+			u.IsMain = &isMain
+		}
+		require.NoError(t, svc.DB.WithContext(tctx).Create(u).Error)
+		return u
+	}
+	first, second, owner := newUser("batch-1", false), newUser("batch-2", false), newUser("batch-owner", true)
+	bob := dbtest.User(dbtest.Tenant("其他租户").ID, "batch-other")
+	ctx := dbtest.UserCtx(newUser("batch-operator", false))
+
+	// 主账号不能删除，整个批次回滚，返回每个 id 的结果
+	results, err := svc.BatchDelete(ctx, []model.ID{first.ID, owner.ID})
+	assert.ErrorIs(t, err, shared.ErrBatchFailed)
+	require.Len(t, results, 2)
+	assert.True(t, results[0].Success)
+	assert.False(t, results[1].Success)
+	assert.Equal(t, user.ErrMainAccountDelete.Error(), results[1].Error)
+	var count int64
+	svc.DB.WithContext(tctx).Model(&model.User{}).Where("id = ?", first.ID).Count(&count)
+	assert.EqualValues(t, 1, count)
+
+	// 批量更新经过模块的 Update，其他租户的记录不存在
+	disabled := 0
+	_, err = svc.BatchUpdate(ctx, []model.ID{first.ID}, map[string]any{"isMain": 1})
+	assert.Error(t, err)
+	results, err = svc.BatchUpdate(ctx, []model.ID{first.ID, owner.ID, bob.ID}, map[string]any{"status": disabled})
+	assert.ErrorIs(t, err, shared.ErrBatchFailed)
+	assert.Equal(t, user.ErrMainAccountProtected.Error(), results[1].Error)
+	assert.False(t, results[2].Success)
+	results, err = svc.BatchUpdate(ctx, []model.ID{first.ID, second.ID, first.ID}, map[string]any{"status": disabled})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	var status []int8
+	svc.DB.WithContext(tctx).Model(&model.User{}).Where("id IN ?", []model.ID{first.ID, second.ID}).Pluck("status", &status)
+	assert.Equal(t, []int8{0, 0}, status)
+
+	// 删除成功时全部生效
+	_, err = svc.BatchDelete(ctx, []model.ID{first.ID, second.ID})
+	require.NoError(t, err)
+	svc.DB.WithContext(tctx).Model(&model.User{}).Where("id IN ?", []model.ID{first.ID, second.ID}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestBatchAfterCommit(t *testing.T) {
+	svc := user.GetService()
+	entity := dbtest.Tenant("批量提交后")
+	u := dbtest.User(entity.ID, "after-commit")
+	ctx := dbtest.UserCtx(u)
+
+	// 登记的操作在提交后执行，能读到提交后的数据；批次回滚时不执行
+	var seen []string
+	register := func(name string, fail bool) func(ctx context.Context, id model.ID) error {
+		return func(ctx context.Context, id model.ID) error {
+			if err := svc.Conn(ctx).Model(&model.User{}).Where("id = ?", id).Update("real_name", name).Error; err != nil {
+				return err
+			}
+			if err := db.AfterCommit(ctx, func() error {
+				var saved model.User
+				require.NoError(t, dbtest.Seed().First(&saved, id).Error)
+				seen = append(seen, *saved.RealName)
+				return nil
+			}); err != nil {
+				return err
+			}
+			if fail {
+				return shared.ErrBatchFailed
+			}
+			return nil
+		}
+	}
+	_, err := svc.RunBatch(ctx, []model.ID{u.ID}, register("回滚", true))
+	assert.ErrorIs(t, err, shared.ErrBatchFailed)
+	assert.Empty(t, seen)
+
+	_, err = svc.RunBatch(ctx, []model.ID{u.ID}, register("提交", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"提交"}, seen)
+
+	// 不在批量操作中时立即执行
+	called := false
+	require.NoError(t, db.AfterCommit(ctx, func() error {
+		called = true
+		return nil
+	}))
+	assert.True(t, called)
+}
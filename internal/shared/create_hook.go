@@ -0,0 +1,28 @@
+package shared
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// CreateHook 创建实体前的检查，返回错误时终止创建
+// tx 为创建所在的事务
+type CreateHook func(ctx context.Context, tx *gorm.DB, entity any) error
+
+var createHooks []CreateHook
+
+// RegisterCreateHook 注册创建钩子，在启动时调用，非并发安全
+func RegisterCreateHook(hook CreateHook) {
+	createHooks = append(createHooks, hook)
+}
+
+// RunCreateHooks 执行创建钩子，重写了 Create 的模块需要在事务中创建前调用
+func (s *BaseService[T]) RunCreateHooks(ctx context.Context, tx *gorm.DB, entity *T) error {
+	for _, hook := range createHooks {
+		if err := hook(ctx, tx, entity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package shared
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"seedgo/internal/scope"
+	"seedgo/pkg"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrCountMode     = errors.New("count must be exact, estimate or none")
+)
+
+// Paginate 列表接口的分页查询
+// query.Cursor 不为 nil 时按游标分页，否则按页码分页；query.Count 为总数的统计方式，
+// 页码分页默认精确统计，游标分页默认不统计
+func (s *BaseService[T]) Paginate(ctx context.Context, query pkg.QueryPage, scopes ...func(*gorm.DB) *gorm.DB) (*scope.PageResult, error) {
+	result := &scope.PageResult{}
+	mode := query.Count
+	var items []T
+	var err error
+	if query.Cursor != nil {
+		if mode == "" {
+			mode = pkg.CountNone
+		}
+		items, result.NextCursor, err = s.CursorPage(ctx, query, scopes...)
+	} else {
+		if mode == "" {
+			mode = pkg.CountExact
+		}
+		items, err = s.offsetPage(ctx, query, scopes...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Items = items
+	result.Total, result.Estimated, err = s.Total(ctx, mode, scopes...)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Total 按统计方式统计符合条件的记录数，返回是否为估算值，不统计时返回 -1
+// 估算使用 MySQL 的 EXPLAIN，扫描行数乘以过滤比例，其他数据库精确统计
+func (s *BaseService[T]) Total(ctx context.Context, mode string, scopes ...func(*gorm.DB) *gorm.DB) (int64, bool, error) {
+	switch mode {
+	case pkg.CountNone:
+		return -1, false, nil
+	case pkg.CountEstimate:
+		if s.DB.Dialector.Name() == "mysql" {
+			total, err := s.estimate(ctx, scopes...)
+			return total, true, err
+		}
+	case pkg.CountExact:
+	default:
+		return 0, false, ErrCountMode
+	}
+	total, err := s.Count(ctx, scopes...)
+	return total, false, err
+}
+
+func (s *BaseService[T]) estimate(ctx context.Context, scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var entities []T
+	stmt := s.Conn(ctx).Session(&gorm.Session{DryRun: true}).Model(new(T)).Scopes(scopes...).Find(&entities).Statement
+	if stmt.Error != nil {
+		return 0, stmt.Error
+	}
+	var plan []map[string]any
+	if err := s.Conn(ctx).Raw("EXPLAIN "+stmt.SQL.String(), stmt.Vars...).Scan(&plan).Error; err != nil {
+		return 0, err
+	}
+	if len(plan) == 0 {
+		return 0, nil
+	}
+	rows, _ := strconv.ParseFloat(fmt.Sprint(plan[0]["rows"]), 64)
+	filtered, err := strconv.ParseFloat(fmt.Sprint(plan[0]["filtered"]), 64)
+	if err != nil {
+		filtered = 100
+	}
+	return int64(rows * filtered / 100), nil
+}
+
+// CursorPage 游标（keyset）分页，按 排序字段 + 主键 定位，不使用 OFFSET，翻页速度和页数无关
+// 排序字段必须是模型的数据库列，返回下一页的游标，没有更多数据时为空；
+// 排序字段为 NULL 的记录按 MySQL 的规则排列：升序在最前，降序在最后
+func (s *BaseService[T]) CursorPage(ctx context.Context, query pkg.QueryPage, scopes ...func(*gorm.DB) *gorm.DB) ([]T, string, error) {
+	stmt := &gorm.Statement{DB: s.DB}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, "", err
+	}
+	sort := stmt.Schema.LookUpField(*query.SortBy)
+	pk := stmt.Schema.PrioritizedPrimaryField
+	if sort == nil || sort.DBName == "" || pk == nil {
+		return nil, "", fmt.Errorf("sort field %s is not supported", *query.SortBy)
+	}
+	desc := !strings.EqualFold(*query.SortDesc, "asc")
+
+	tx := s.Conn(ctx).Model(new(T)).Scopes(scopes...)
+	if *query.Cursor != "" {
+		c, err := decodeCursor(*query.Cursor)
+		if err != nil || c.Sort != sort.DBName || c.Desc != desc {
+			return nil, "", ErrInvalidCursor
+		}
+		tx = tx.Where(keyset(sort, pk, c))
+	}
+	columns := []clause.OrderByColumn{{Column: clause.Column{Table: clause.CurrentTable, Name: sort.DBName}, Desc: desc}}
+	if sort != pk {
+		columns = append(columns, clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Desc: desc})
+	}
+	// 多查一条判断是否还有下一页
+	var entities []T
+	size := *query.PageSize
+	if err := tx.Order(clause.OrderBy{Columns: columns}).Limit(size + 1).Find(&entities).Error; err != nil {
+		return nil, "", err
+	}
+	if len(entities) <= size {
+		return entities, "", nil
+	}
+	entities = entities[:size]
+	last := reflect.ValueOf(&entities[size-1]).Elem()
+	c := &cursor{Sort: sort.DBName, Desc: desc}
+	c.ID, _ = cursorValue(ctx, pk, last)
+	c.Value, c.Time = cursorValue(ctx, sort, last)
+	next, err := c.encode()
+	return entities, next, err
+}
+
+// keyset 游标之后的记录，降序时：sort < v OR (sort = v AND id < id) OR sort IS NULL
+func keyset(sort, pk *schema.Field, c *cursor) clause.Expression {
+	col := clause.Column{Table: clause.CurrentTable, Name: sort.DBName}
+	id := clause.Column{Table: clause.CurrentTable, Name: pk.DBName}
+	op := ">"
+	if c.Desc {
+		op = "<"
+	}
+	if sort == pk {
+		return gorm.Expr("? "+op+" ?", id, c.ID)
+	}
+	value := c.value()
+	switch {
+	case value == nil && c.Desc:
+		return gorm.Expr("(? IS NULL AND ? < ?)", col, id, c.ID)
+	case value == nil:
+		return gorm.Expr("((? IS NULL AND ? > ?) OR ? IS NOT NULL)", col, id, c.ID, col)
+	case c.Desc:
+		return gorm.Expr("(? < ? OR (? = ? AND ? < ?) OR ? IS NULL)", col, value, col, value, id, c.ID, col)
+	}
+	return gorm.Expr("(? > ? OR (? = ? AND ? > ?))", col, value, col, value, id, c.ID)
+}
+
+// cursor 游标内容，记录排序方式，排序和生成游标时不同的请求视为无效
+type cursor struct {
+	Sort  string     `json:"s"`
+	Desc  bool       `json:"d"`
+	Value any        `json:"v"`
+	Time  *time.Time `json:"t,omitempty"` // 时间单独保存，避免 JSON 转换后精度和类型丢失
+	ID    any        `json:"i"`
+}
+
+func (c *cursor) value() any {
+	if c.Time != nil {
+		return *c.Time
+	}
+	return c.Value
+}
+
+func (c *cursor) encode() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var c cursor
+	if err := decoder.Decode(&c); err != nil {
+		return nil, err
+	}
+	c.Value, c.ID = jsonNumber(c.Value), jsonNumber(c.ID)
+	if c.ID == nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// jsonNumber 把 JSON 数字还原为整数或浮点数，和数据库中的数值比较
+func jsonNumber(v any) any {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// cursorValue 读取记录中字段的数据库值，时间类型单独返回
+func cursorValue(ctx context.Context, field *schema.Field, entity reflect.Value) (any, *time.Time) {
+	value, zero := field.ValueOf(ctx, entity)
+	if zero && value == nil {
+		return nil, nil
+	}
+	if valuer, ok := value.(driver.Valuer); ok {
+		v := reflect.ValueOf(valuer)
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, nil
+		}
+		var err error
+		if value, err = valuer.Value(); err != nil {
+			return nil, nil
+		}
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return nil, &t
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), nil
+	}
+	return v.Interface(), nil
+}
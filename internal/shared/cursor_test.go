@@ -0,0 +1,91 @@
+package shared_test
+
+import (
+	"context"
+	"seedgo/internal/db"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/log"
+	"seedgo/internal/shared"
+	"seedgo/pkg"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorPagination(t *testing.T) {
+	entity := dbtest.Tenant("游标分页")
+	ctx := db.WithTenant(context.Background(), entity.ID)
+	t.Cleanup(func() {
+		global.DB.WithContext(ctx).Unscoped().Where("tenant_id = ?", entity.ID).Delete(&model.OperationLog{})
+	})
+
+	// 操作时间有重复和空值，按 操作时间 + ID 排序才能稳定翻页
+	base := time.Date(2026, 1, 1, 8, 0, 0, 0, time.Local)
+	for _, minutes := range []int{3, 1, 3, -1, 2, 3, -1, 0} {
+		entry := &model.OperationLog{Username: "cursor", Path: "/api/test"}
+		entry.TenantID = entity.ID
+		if minutes >= 0 {
+			at := model.DateTime(base.Add(time.Duration(minutes) * time.Minute))
+			entry.OperationTime = &at
+		}
+		require.NoError(t, global.DB.WithContext(ctx).Create(entry).Error)
+	}
+
+	svc := log.GetService()
+	walk := func(sortBy, order string) []model.ID {
+		var ids []model.ID
+		cursor := ""
+		for pages := 0; pages < 10; pages++ {
+			size := 3
+			query := pkg.QueryPage{PageSize: &size, SortBy: &sortBy, SortDesc: &order, Cursor: &cursor}
+			result, err := svc.Paginate(ctx, query)
+			require.NoError(t, err)
+			assert.EqualValues(t, -1, result.Total, "游标分页默认不统计总数")
+			for _, item := range result.Items.([]model.OperationLog) {
+				ids = append(ids, item.ID)
+			}
+			if result.NextCursor == "" {
+				return ids
+			}
+			cursor = result.NextCursor
+		}
+		t.Fatal("cursor pagination did not finish")
+		return nil
+	}
+	expected := func(order string) []model.ID {
+		var ids []model.ID
+		global.DB.WithContext(ctx).Model(&model.OperationLog{}).Order("operation_time "+order+", id "+order).Pluck("id", &ids)
+		return ids
+	}
+	assert.Equal(t, expected("desc"), walk("operation_time", "desc"))
+	assert.Equal(t, expected("asc"), walk("operation_time", "asc"))
+	byID := walk("id", "desc")
+	require.Len(t, byID, 8)
+	assert.Greater(t, byID[0], byID[7])
+
+	// 游标和排序方式不一致时无效，排序字段必须是数据库列
+	size, sortBy, order, cursor := 3, "operation_time", "desc", ""
+	result, err := svc.Paginate(ctx, pkg.QueryPage{PageSize: &size, SortBy: &sortBy, SortDesc: &order, Cursor: &cursor, Count: pkg.CountExact})
+	require.NoError(t, err)
+	assert.EqualValues(t, 8, result.Total)
+	asc := "asc"
+	_, err = svc.Paginate(ctx, pkg.QueryPage{PageSize: &size, SortBy: &sortBy, SortDesc: &asc, Cursor: &result.NextCursor})
+	assert.ErrorIs(t, err, shared.ErrInvalidCursor)
+	invalid := "roles"
+	_, err = svc.Paginate(ctx, pkg.QueryPage{PageSize: &size, SortBy: &invalid, SortDesc: &order, Cursor: &cursor})
+	assert.Error(t, err)
+
+	// 页码分页默认精确统计，不支持估算的数据库也精确统计
+	page := 3
+	result, err = svc.Paginate(ctx, pkg.QueryPage{Page: &page, PageSize: &size, SortBy: &sortBy, SortDesc: &order, Count: pkg.CountEstimate})
+	require.NoError(t, err)
+	assert.EqualValues(t, 8, result.Total)
+	assert.False(t, result.Estimated)
+	assert.Len(t, result.Items, 2)
+	_, err = svc.Paginate(ctx, pkg.QueryPage{Page: &page, PageSize: &size, SortBy: &sortBy, SortDesc: &order, Count: "all"})
+	assert.ErrorIs(t, err, shared.ErrCountMode)
+}
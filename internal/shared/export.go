@@ -0,0 +1,242 @@
+package shared
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/pkg"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// ExportFormat 导出文件格式
+type ExportFormat string
+
+const (
+	ExportCSV  ExportFormat = "csv"
+	ExportXLSX ExportFormat = "xlsx"
+)
+
+var (
+	ErrExportFormat    = errors.New("export format must be csv or xlsx")
+	ErrNoExportColumns = errors.New("no exportable columns")
+)
+
+// Valid 是否为支持的格式
+func (f ExportFormat) Valid() bool {
+	return f == ExportCSV || f == ExportXLSX
+}
+
+// ContentType 下载时的 Content-Type
+func (f ExportFormat) ContentType() string {
+	if f == ExportCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+// Count 统计符合条件的记录数
+func (s *BaseService[T]) Count(ctx context.Context, scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var total int64
+	err := s.Conn(ctx).Model(new(T)).Scopes(scopes...).Count(&total).Error
+	return total, err
+}
+
+// Each 按 query 的排序分批读取符合条件的记录，每批最多 query.PageSize 条，用于导出等需要遍历大量数据的场景
+// 使用游标分页（排序字段 + 主键）读取，顺序和列表一致，不会因为 OFFSET 变慢
+func (s *BaseService[T]) Each(ctx context.Context, query pkg.QueryPage, fn func(batch []T) error, scopes ...func(*gorm.DB) *gorm.DB) error {
+	cursor := ""
+	query.Cursor = &cursor
+	for {
+		batch, next, err := s.CursorPage(ctx, query, scopes...)
+		if err != nil {
+			return err
+		}
+		if len(batch) > 0 {
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// Exporter 按列表的查询条件和排序导出记录
+type Exporter[T any] struct {
+	Logic   IBaseService[T]
+	Columns []pkg.ExportColumn
+	Scopes  []func(*gorm.DB) *gorm.DB
+	// SortBy 排序的数据库列，为空按主键排序；SortDesc 是否降序
+	SortBy   string
+	SortDesc bool
+}
+
+// Write 分批读取记录写入 w，返回导出的记录数
+func (e *Exporter[T]) Write(ctx context.Context, w io.Writer, format ExportFormat) (int64, error) {
+	rw, err := newRowWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+	labels, err := dictLabels(ctx, e.Columns)
+	if err != nil {
+		return 0, err
+	}
+
+	header := make([]any, len(e.Columns))
+	for i, c := range e.Columns {
+		header[i] = c.Title
+	}
+	if err := rw.Write(header); err != nil {
+		return 0, err
+	}
+
+	size := global.Config.Export.BatchSize
+	if size <= 0 {
+		size = 500
+	}
+	sortBy, order := e.SortBy, "asc"
+	if sortBy == "" {
+		sortBy = "id"
+	}
+	if e.SortDesc {
+		order = "desc"
+	}
+	query := pkg.QueryPage{PageSize: &size, SortBy: &sortBy, SortDesc: &order}
+
+	var rows int64
+	err = e.Logic.Each(ctx, query, func(batch []T) error {
+		for i := range batch {
+			row := make([]any, len(e.Columns))
+			for n, c := range e.Columns {
+				row[n] = c.Format(&batch[i])
+				if c.Dict != "" {
+					if label, ok := labels[c.Dict][fmt.Sprint(row[n])]; ok {
+						row[n] = label
+					}
+				}
+				row[n] = escapeFormula(row[n])
+			}
+			if err := rw.Write(row); err != nil {
+				return err
+			}
+			rows++
+		}
+		return nil
+	}, e.Scopes...)
+	if err != nil {
+		return rows, err
+	}
+	return rows, rw.Close()
+}
+
+// escapeFormula 以 = + - @ 开头的文本在 Excel 中会被当作公式执行，前面加上单引号按文本显示
+func escapeFormula(v any) any {
+	s, ok := v.(string)
+	if ok && s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return v
+}
+
+// dictLabels 读取导出列使用的字典，返回 字典编码 -> 值 -> 标签
+func dictLabels(ctx context.Context, columns []pkg.ExportColumn) (map[string]map[string]string, error) {
+	var codes []string
+	for _, c := range columns {
+		if c.Dict != "" {
+			codes = append(codes, c.Dict)
+		}
+	}
+	labels := make(map[string]map[string]string)
+	if len(codes) == 0 {
+		return labels, nil
+	}
+	var dicts []model.Dict
+	if err := global.DB.WithContext(ctx).Preload("Items").Where("code IN ?", codes).Find(&dicts).Error; err != nil {
+		return nil, err
+	}
+	for _, d := range dicts {
+		items := make(map[string]string, len(d.Items))
+		for _, item := range d.Items {
+			items[item.Value] = item.Label
+		}
+		labels[d.Code] = items
+	}
+	return labels, nil
+}
+
+// rowWriter 按行写入导出文件
+type rowWriter interface {
+	Write(row []any) error
+	Close() error
+}
+
+func newRowWriter(w io.Writer, format ExportFormat) (rowWriter, error) {
+	switch format {
+	case ExportCSV:
+		// 带 BOM 方便 Excel 打开中文
+		if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return nil, err
+		}
+		return &csvRowWriter{w: csv.NewWriter(w)}, nil
+	case ExportXLSX:
+		f := excelize.NewFile()
+		sw, err := f.NewStreamWriter("Sheet1")
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return &xlsxRowWriter{file: f, sw: sw, out: w}, nil
+	}
+	return nil, ErrExportFormat
+}
+
+type csvRowWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvRowWriter) Write(row []any) error {
+	record := make([]string, len(row))
+	for i, v := range row {
+		record[i] = fmt.Sprint(v)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvRowWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// xlsxRowWriter 使用流式写入，行数多时 excelize 把数据暂存到临时文件
+type xlsxRowWriter struct {
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	out  io.Writer
+	row  int
+}
+
+func (x *xlsxRowWriter) Write(row []any) error {
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.sw.SetRow(cell, row)
+}
+
+func (x *xlsxRowWriter) Close() error {
+	defer x.file.Close()
+	if err := x.sw.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.out)
+}
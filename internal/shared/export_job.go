@@ -0,0 +1,79 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+	"time"
+)
+
+// ExportDir 后台导出文件的目录
+func ExportDir() string {
+	if dir := global.Config.Export.Dir; dir != "" {
+		return dir
+	}
+	return "storage/exports"
+}
+
+// StartExportJob 创建后台导出任务并在后台写入文件，任务归属发起的用户
+func StartExportJob[T any](ctx context.Context, name string, format ExportFormat, total int64, exporter *Exporter[T]) (*model.ExportJob, error) {
+	job := &model.ExportJob{Name: name, Format: string(format), Status: model.ExportPending, Total: total}
+	if user := scope.GetUserFromContext(ctx); user != nil {
+		job.UserID = user.ID
+	}
+	if err := global.DB.WithContext(ctx).Create(job).Error; err != nil {
+		return nil, err
+	}
+	// 请求结束后 context 会被取消，后台任务保留其中的用户和租户
+	go runExportJob(context.WithoutCancel(ctx), job, exporter)
+	return job, nil
+}
+
+// runExportJob 执行导出，文件保存为 {目录}/{租户ID}/{任务ID}.{格式}，失败时删除文件并记录错误
+func runExportJob[T any](ctx context.Context, job *model.ExportJob, exporter *Exporter[T]) {
+	update := func(values map[string]any) {
+		if err := global.DB.WithContext(ctx).Model(job).Updates(values).Error; err != nil {
+			log.Printf("更新导出任务 %d 失败: %v", job.ID, err)
+		}
+	}
+	fail := func(err error) {
+		now := model.DateTime(time.Now())
+		update(map[string]any{"status": model.ExportFailed, "error": err.Error(), "finished_at": &now})
+		log.Printf("导出任务 %d 失败: %v", job.ID, err)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			fail(fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	update(map[string]any{"status": model.ExportRunning})
+	dir := filepath.Join(ExportDir(), job.TenantID.String())
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fail(err)
+		return
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%d.%s", job.ID, job.Format))
+	file, err := os.Create(path)
+	if err != nil {
+		fail(err)
+		return
+	}
+	rows, err := exporter.Write(ctx, file, ExportFormat(job.Format))
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		fail(err)
+		return
+	}
+
+	now := model.DateTime(time.Now())
+	update(map[string]any{"status": model.ExportSuccess, "rows": rows, "file": path, "finished_at": &now})
+}
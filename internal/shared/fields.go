@@ -0,0 +1,119 @@
+package shared
+
+import (
+	"encoding/json"
+	"fmt"
+	"seedgo/internal/model"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// FieldsScope 按 JSON 字段名只查询需要的列，并预加载模型允许展开（model.Expandable）的关联
+// 主键、展开关联需要的键和 required 中的列（如游标分页的排序字段）总是查询；fields 为空时查询所有列
+func (s *BaseService[T]) FieldsScope(fields, expand []string, required ...string) (func(*gorm.DB) *gorm.DB, error) {
+	stmt := &gorm.Statement{DB: s.DB}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	byJSON := make(map[string]*schema.Field)
+	for _, field := range stmt.Schema.Fields {
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		byJSON[name] = field
+	}
+
+	var allowed []string
+	if e, ok := any(new(T)).(model.Expandable); ok {
+		allowed = e.ExpandFields()
+	}
+	var columns []string
+	var preloads []string
+	for _, name := range expand {
+		var rel *schema.Relationship
+		if field, ok := byJSON[name]; ok {
+			rel = stmt.Schema.Relationships.Relations[field.Name]
+		}
+		if rel == nil || !slices.Contains(allowed, name) {
+			return nil, fmt.Errorf("relation %s cannot be expanded", name)
+		}
+		preloads = append(preloads, rel.Name)
+		// 预加载按关联的键查询，这些列必须查询出来
+		for _, ref := range rel.References {
+			if ref.OwnPrimaryKey {
+				columns = append(columns, ref.PrimaryKey.DBName)
+			} else if ref.ForeignKey.Schema == stmt.Schema {
+				columns = append(columns, ref.ForeignKey.DBName)
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		for _, name := range fields {
+			field, ok := byJSON[name]
+			if !ok || field.DBName == "" {
+				return nil, fmt.Errorf("field %s is not selectable", name)
+			}
+			columns = append(columns, field.DBName)
+		}
+		for _, pk := range stmt.Schema.PrimaryFields {
+			columns = append(columns, pk.DBName)
+		}
+		for _, name := range required {
+			if field := stmt.Schema.LookUpField(name); field != nil && field.DBName != "" {
+				columns = append(columns, field.DBName)
+			}
+		}
+		slices.Sort(columns)
+		columns = slices.Compact(columns)
+	}
+
+	return func(tx *gorm.DB) *gorm.DB {
+		// 统计总数时使用相同的 scopes，scopes 在执行时才调用，这时 Count 已经把 Dest 设置为 *int64
+		if _, counting := tx.Statement.Dest.(*int64); len(fields) > 0 && !counting {
+			tx = tx.Select(columns)
+		}
+		for _, name := range preloads {
+			tx = tx.Preload(name)
+		}
+		return tx
+	}, nil
+}
+
+// PickFields 只保留 JSON 中指定的字段，v 为结构体或切片
+// 查询时没有选择的列是零值，返回前去掉，避免前端误以为字段为空
+func PickFields(v any, keys []string) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	pick := func(item map[string]json.RawMessage) map[string]json.RawMessage {
+		picked := make(map[string]json.RawMessage, len(keys))
+		for _, key := range keys {
+			if value, ok := item[key]; ok {
+				picked[key] = value
+			}
+		}
+		return picked
+	}
+	if len(data) > 0 && data[0] == '[' {
+		var items []map[string]json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+		picked := make([]map[string]json.RawMessage, len(items))
+		for i, item := range items {
+			picked[i] = pick(item)
+		}
+		return picked, nil
+	}
+	var item map[string]json.RawMessage
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	return pick(item), nil
+}
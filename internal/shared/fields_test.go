@@ -0,0 +1,85 @@
+package shared_test
+
+import (
+	"encoding/json"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/role"
+	"seedgo/internal/modules/user"
+	"seedgo/internal/shared"
+	"seedgo/pkg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestFieldsAndExpand(t *testing.T) {
+	alice := dbtest.User(dbtest.Tenant("A").ID, "alice")
+	roleA, roleB := newRole(t, alice.TenantID, "role-a"), newRole(t, dbtest.Tenant("B").ID, "role-b")
+	require.NoError(t, dbtest.Seed().Create(&model.UserRole{UserID: alice.ID, RoleID: roleA.ID}).Error)
+	ctx := dbtest.UserCtx(alice)
+	svc := user.GetService()
+	onlyAlice := func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("username = ?", "alice")
+	}
+
+	// 只查询需要的列，展开的关联按外键预加载，关联仍然按租户隔离
+	fieldsScope, err := svc.FieldsScope([]string{"username"}, []string{"roles", "tenant"}, "created_at")
+	require.NoError(t, err)
+	size, page, sortBy, order := 10, 1, "id", "desc"
+	result, err := svc.Paginate(ctx, pkg.QueryPage{Page: &page, PageSize: &size, SortBy: &sortBy, SortDesc: &order}, onlyAlice, fieldsScope)
+	require.NoError(t, err)
+	items := result.Items.([]model.User)
+	require.Len(t, items, 1)
+	assert.Equal(t, "alice", items[0].Username)
+	assert.Nil(t, items[0].Status, "没有选择的列不查询")
+	assert.NotNil(t, items[0].CreatedAt, "required 中的列总是查询")
+	require.Len(t, items[0].Roles, 1)
+	assert.Equal(t, roleA.ID, items[0].Roles[0].ID)
+	require.NotNil(t, items[0].Tenant)
+	assert.Equal(t, "A", items[0].Tenant.Name)
+
+	// 返回时去掉没有选择的字段
+	picked, err := shared.PickFields(items, []string{"username", "roles", "tenant"})
+	require.NoError(t, err)
+	data, err := json.Marshal(picked)
+	require.NoError(t, err)
+	var fields []map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	require.Len(t, fields, 1)
+	assert.Len(t, fields[0], 3)
+	assert.Equal(t, "alice", fields[0]["username"])
+
+	// 不展开时不预加载
+	result, err = svc.Paginate(ctx, pkg.QueryPage{Page: &page, PageSize: &size, SortBy: &sortBy, SortDesc: &order}, onlyAlice)
+	require.NoError(t, err)
+	assert.Nil(t, result.Items.([]model.User)[0].Roles)
+
+	// 详情同样支持，只能展开模型允许的关联
+	roles := role.Instance()
+	fieldsScope, err = roles.FieldsScope(nil, []string{"users"})
+	require.NoError(t, err)
+	entity, err := roles.Get(ctx, roleA.ID, fieldsScope)
+	require.NoError(t, err)
+	require.Len(t, entity.Users, 1)
+	assert.Equal(t, alice.ID, entity.Users[0].ID)
+	assert.NotNil(t, entity.PermissionIds, "经过角色模块重写的 Get，带出授权")
+	_, err = roles.Get(ctx, roleB.ID, fieldsScope)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	_, err = roles.FieldsScope(nil, []string{"permissions"})
+	assert.EqualError(t, err, "relation permissions cannot be expanded")
+	_, err = svc.FieldsScope([]string{"passwordHash"}, nil)
+	assert.EqualError(t, err, "field passwordHash is not selectable")
+	_, err = svc.FieldsScope([]string{"roles"}, nil)
+	assert.Error(t, err, "关联通过 expand 加载")
+}
+
+func newRole(t *testing.T, tenantID model.ID, name string) *model.Role {
+	r := &model.Role{Name: name}
+	r.TenantID = tenantID
+	require.NoError(t, dbtest.Seed().Omit("Permissions").Create(r).Error)
+	return r
+}
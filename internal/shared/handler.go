@@ -1,10 +1,18 @@
 package shared
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"path"
+	"seedgo/internal/form"
+	"seedgo/internal/global"
 	"seedgo/internal/model"
 	"seedgo/internal/scope"
 	"seedgo/pkg"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -20,12 +28,19 @@ type IHandler interface {
 	// BeforeList 给List查询之前添加条件
 	BeforeList(ctx *gin.Context) []func(*gorm.DB) *gorm.DB
 	BatchDelete(ctx *gin.Context)
+	BatchUpdate(ctx *gin.Context)
+	ExportList(ctx *gin.Context)
+	ImportTemplate(ctx *gin.Context)
+	ImportColumns(ctx *gin.Context)
+	ImportList(ctx *gin.Context)
 }
 
 type BaseHandler[T any] struct {
 	Logic IBaseService[T]
 	Hook  HandlerHook[T]
 	Impl  IHandler
+	// RequireVersion 更新接口必须提交版本号，没有提交时拒绝，避免覆盖其他人的修改，见 Versioned
+	RequireVersion bool
 }
 
 // NewBaseHandler 创建一个NewBase的方法
@@ -44,6 +59,11 @@ func NewBaseHandler[T any](logic IBaseService[T], hook HandlerHook[T], impl IHan
 func (c *BaseHandler[T]) Use(g *gin.RouterGroup) {
 
 	g.POST("/batch-delete", c.Impl.BatchDelete)
+	g.POST("/batch-update", c.Impl.BatchUpdate)
+	g.GET("/export/:format", c.Impl.ExportList)
+	g.GET("/import/template/:format", c.Impl.ImportTemplate)
+	g.GET("/import/columns", c.Impl.ImportColumns)
+	g.POST("/import/upload", c.Impl.ImportList)
 	g.POST("", c.Impl.Create)
 	g.PUT("/:id", c.Impl.Update)
 	g.DELETE("/:id", c.Impl.Delete)
@@ -54,14 +74,14 @@ func (c *BaseHandler[T]) Use(g *gin.RouterGroup) {
 func (c *BaseHandler[T]) Create(ctx *gin.Context) {
 
 	var entity T
-	if err := ctx.ShouldBindJSON(&entity); err != nil {
+	if err := c.BindWritable(ctx, &entity); err != nil {
 		scope.Fail(ctx, "Invalid parameters")
 		return
 	}
 
 	if err := c.Logic.Create(ctx.Request.Context(), &entity); err != nil {
 		log.Printf("errors:%s", err.Error())
-		scope.Fail(ctx, err.Error())
+		scope.FailWithError(ctx, err)
 		return
 	}
 	scope.Ok(ctx)
@@ -70,7 +90,7 @@ func (c *BaseHandler[T]) Create(ctx *gin.Context) {
 func (c *BaseHandler[T]) Update(ctx *gin.Context) {
 	id := model.ToID(ctx.Param("id"))
 	var entity T
-	if err := ctx.ShouldBindJSON(&entity); err != nil {
+	if err := c.BindWritable(ctx, &entity); err != nil {
 		scope.Fail(ctx, "Invalid parameters")
 		return
 	}
@@ -79,14 +99,29 @@ func (c *BaseHandler[T]) Update(ctx *gin.Context) {
 		scope.Fail(ctx, err.Error())
 		return
 	}
+	if version := versionOf(&entity); c.RequireVersion && version != nil && *version <= 0 {
+		scope.FailWithCode(ctx, scope.InvalidParamsCode, ErrVersionRequired.Error())
+		return
+	}
 
 	if err := c.Logic.Update(ctx.Request.Context(), &entity); err != nil {
-		scope.Fail(ctx, err.Error())
+		scope.FailWithError(ctx, err)
 		return
 	}
 	scope.Ok(ctx)
 }
 
+// BindWritable 绑定请求参数，并重置模型未声明为可写的字段，防止提交 isSuper、tenantId 等特权字段
+// 模块重写 Create、Update 时同样使用它绑定实体
+func (c *BaseHandler[T]) BindWritable(ctx *gin.Context, entity *T) error {
+	if err := ctx.ShouldBindJSON(entity); err != nil {
+		return err
+	}
+	user := scope.GetCurrentUser(ctx)
+	pkg.ResetUnwritable(entity, user != nil && user.IsSuper)
+	return nil
+}
+
 func (c *BaseHandler[T]) Delete(ctx *gin.Context) {
 	id := model.ToID(ctx.Param("id"))
 	if err := c.Logic.Delete(ctx.Request.Context(), id); err != nil {
@@ -96,14 +131,45 @@ func (c *BaseHandler[T]) Delete(ctx *gin.Context) {
 	scope.Ok(ctx)
 }
 
+// Get 详情，带 fields 或 expand 参数时把查询条件传给模块的 Get，模块重写的逻辑同样生效
 func (c *BaseHandler[T]) Get(ctx *gin.Context) {
 	id := model.ToID(ctx.Param("id"))
-	entity, err := c.Logic.Get(ctx.Request.Context(), id)
+	fields, expand := queryList(ctx, "fields"), queryList(ctx, "expand")
+	var scopes []func(*gorm.DB) *gorm.DB
+	if len(fields) > 0 || len(expand) > 0 {
+		fieldsScope, err := c.Logic.FieldsScope(fields, expand)
+		if err != nil {
+			scope.Fail(ctx, err.Error())
+			return
+		}
+		scopes = append(scopes, fieldsScope)
+	}
+	entity, err := c.Logic.Get(ctx.Request.Context(), id, scopes...)
 	if err != nil {
 		scope.Fail(ctx, "Not found")
 		return
 	}
-	scope.OkWithData(ctx, entity)
+	if len(fields) == 0 {
+		scope.OkWithData(ctx, entity)
+		return
+	}
+	data, err := PickFields(entity, append(fields, expand...))
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	scope.OkWithData(ctx, data)
+}
+
+// queryList 逗号分隔的查询参数
+func queryList(ctx *gin.Context, key string) []string {
+	var list []string
+	for _, item := range strings.Split(ctx.Query(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
 }
 func (c *BaseHandler[T]) BeforeList(ctx *gin.Context) []func(*gorm.DB) *gorm.DB {
 	return []func(*gorm.DB) *gorm.DB{}
@@ -116,28 +182,195 @@ func (c *BaseHandler[T]) List(ctx *gin.Context) {
 	scopes = append(scopes, pkg.BuildQueryScope(ctx, &entity))
 
 	queryPage := pkg.BindQuery(ctx)
-	if items, total, err := c.Logic.Page(ctx.Request.Context(), *queryPage, scopes...); err != nil {
+	// fields 只查询需要的列，expand 预加载关联
+	fields, expand := queryList(ctx, "fields"), queryList(ctx, "expand")
+	if len(fields) > 0 || len(expand) > 0 {
+		fieldsScope, err := c.Logic.FieldsScope(fields, expand, *queryPage.SortBy)
+		if err != nil {
+			scope.Fail(ctx, err.Error())
+			return
+		}
+		scopes = append(scopes, fieldsScope)
+	}
+
+	result, err := c.Logic.Paginate(ctx.Request.Context(), *queryPage, scopes...)
+	if err == nil && len(fields) > 0 {
+		result.Items, err = PickFields(result.Items, append(fields, expand...))
+	}
+	if err != nil {
 		scope.Fail(ctx, err.Error())
 		return
-	} else {
-		scope.OkWithData(ctx, scope.PageResult{
-			Items: items,
-			Total: total,
-		})
 	}
+	scope.OkWithData(ctx, result)
 }
 
+// BatchDelete 批量删除，返回每个 id 的结果，有失败时全部回滚
 func (c *BaseHandler[T]) BatchDelete(ctx *gin.Context) {
-	//post ids
-	type Post struct {
-		ids []string
+	var dto form.BatchDeleteDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		scope.Fail(ctx, "Invalid parameters")
+		return
+	}
+	results, err := c.Logic.BatchDelete(ctx.Request.Context(), dto.IDs)
+	batchResult(ctx, results, err)
+}
+
+// BatchUpdate 批量更新部分字段，返回每个 id 的结果，有失败时全部回滚
+func (c *BaseHandler[T]) BatchUpdate(ctx *gin.Context) {
+	var dto form.BatchUpdateDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		scope.Fail(ctx, "Invalid parameters")
+		return
+	}
+	results, err := c.Logic.BatchUpdate(ctx.Request.Context(), dto.IDs, dto.Fields)
+	batchResult(ctx, results, err)
+}
+
+// batchResult 批次失败时返回错误码和每个 id 的结果，前端据此提示失败的记录
+func batchResult(ctx *gin.Context, results []*BatchResult, err error) {
+	if errors.Is(err, ErrBatchFailed) {
+		scope.Result(scope.ErrorCode, results, err.Error(), ctx)
+		return
+	}
+	if err != nil {
+		scope.FailWithError(ctx, err)
+		return
+	}
+	scope.OkWithData(ctx, results)
+}
+
+// ExportList 按列表的查询条件导出，路径参数 format 为 csv 或 xlsx，columns 为逗号分隔的 JSON 字段名，为空导出所有导出列
+// 记录数超过 export.async_threshold 或 async=true 时创建后台导出任务并返回任务，完成后通过 /common/exports 下载
+func (c *BaseHandler[T]) ExportList(ctx *gin.Context) {
+	format := ExportFormat(ctx.Param("format"))
+	if !format.Valid() {
+		scope.Fail(ctx, ErrExportFormat.Error())
+		return
+	}
+	var entity T
+	var keys []string
+	if columns := ctx.Query("columns"); columns != "" {
+		keys = strings.Split(columns, ",")
+	}
+	columns := pkg.ExportColumns(&entity, keys)
+	if len(columns) == 0 {
+		scope.Fail(ctx, ErrNoExportColumns.Error())
+		return
+	}
+
+	// 后台任务在请求结束后执行，查询条件从请求的副本读取
+	req := ctx.Copy()
+	scopes := append(c.Impl.BeforeList(req), pkg.BuildQueryScope(req, &entity))
+	queryPage := pkg.BindQuery(req)
+	exporter := &Exporter[T]{
+		Logic:    c.Logic,
+		Columns:  columns,
+		Scopes:   scopes,
+		SortBy:   *queryPage.SortBy,
+		SortDesc: !strings.EqualFold(*queryPage.SortDesc, "asc"),
 	}
-	post := &Post{}
-	if err := ctx.ShouldBindJSON(&post); err != nil {
+	reqCtx := ctx.Request.Context()
+	total, err := c.Logic.Count(reqCtx, scopes...)
+	if err != nil {
 		scope.Fail(ctx, err.Error())
 		return
 	}
 
-	//组装id 用id in 删除
-	scope.Ok(ctx)
+	// 模块名取路由分组的最后一段，如 /api/system/users/export/:format 为 users
+	name := path.Base(path.Dir(path.Dir(ctx.FullPath())))
+	threshold := global.Config.Export.AsyncThreshold
+	if threshold <= 0 {
+		threshold = 5000
+	}
+	if ctx.Query("async") == "true" || total > threshold {
+		job, err := StartExportJob(reqCtx, name, format, total, exporter)
+		if err != nil {
+			scope.Fail(ctx, err.Error())
+			return
+		}
+		scope.OkWithData(ctx, job)
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102150405"), format)
+	ctx.Header("Content-Type", format.ContentType())
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	if _, err := exporter.Write(reqCtx, ctx.Writer, format); err != nil {
+		_ = ctx.Error(err)
+	}
+}
+
+// ImportTemplate 下载导入模板，路径参数 format 为 csv 或 xlsx
+func (c *BaseHandler[T]) ImportTemplate(ctx *gin.Context) {
+	format := ExportFormat(ctx.Param("format"))
+	if !format.Valid() {
+		scope.Fail(ctx, ErrExportFormat.Error())
+		return
+	}
+	columns := c.Logic.ImportColumns(ctx.Request.Context())
+	if len(columns) == 0 {
+		scope.Fail(ctx, ErrNoExportColumns.Error())
+		return
+	}
+	// 模块名取路由分组的最后一段，如 /api/system/users/import/template/:format 为 users
+	name := path.Base(path.Dir(path.Dir(path.Dir(ctx.FullPath()))))
+	ctx.Header("Content-Type", format.ContentType())
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-template.%s", name, format))
+	if err := WriteImportTemplate(ctx.Writer, format, columns); err != nil {
+		_ = ctx.Error(err)
+	}
+}
+
+// ImportColumns 可以导入的列，前端据此让用户调整表头和字段的对应关系
+func (c *BaseHandler[T]) ImportColumns(ctx *gin.Context) {
+	scope.OkWithData(ctx, c.Logic.ImportColumns(ctx.Request.Context()))
+}
+
+// ImportList 上传 CSV 或 XLSX 文件导入，表单参数：
+// file 文件，按扩展名识别格式；mode 为 atomic（默认）或 best-effort；dryRun=true 只预览不写入；
+// mapping 为 JSON 对象，表头 -> 字段名
+// 返回每行的结果，atomic 模式有失败的行时返回错误码和报告
+func (c *BaseHandler[T]) ImportList(ctx *gin.Context) {
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		scope.Fail(ctx, "file is required")
+		return
+	}
+	format := ExportFormat(strings.ToLower(strings.TrimPrefix(path.Ext(header.Filename), ".")))
+	if !format.Valid() {
+		scope.Fail(ctx, ErrExportFormat.Error())
+		return
+	}
+	opts := ImportOptions{
+		Mode:   ImportMode(ctx.PostForm("mode")),
+		DryRun: ctx.PostForm("dryRun") == "true",
+	}
+	if mapping := ctx.PostForm("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &opts.Mapping); err != nil {
+			scope.Fail(ctx, "Invalid parameters")
+			return
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	defer file.Close()
+	records, err := ReadImportFile(file, format)
+	if err != nil {
+		scope.Fail(ctx, err.Error())
+		return
+	}
+	report, err := c.Logic.ImportRecords(ctx.Request.Context(), records, opts)
+	if errors.Is(err, ErrImportFailed) {
+		scope.Result(scope.ErrorCode, report, err.Error(), ctx)
+		return
+	}
+	if err != nil {
+		scope.FailWithError(ctx, err)
+		return
+	}
+	scope.OkWithData(ctx, report)
 }
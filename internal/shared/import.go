@@ -0,0 +1,323 @@
+package shared
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"seedgo/internal/db"
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+	"seedgo/pkg"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// MaxImportRows 单次导入的最大行数
+const MaxImportRows = 5000
+
+// ImportMode 导入的提交方式
+type ImportMode string
+
+const (
+	// ImportAtomic 所有行都成功才提交，有失败时全部回滚
+	ImportAtomic ImportMode = "atomic"
+	// ImportBestEffort 提交成功的行，跳过失败的行
+	ImportBestEffort ImportMode = "best-effort"
+)
+
+var (
+	ErrImportMode     = errors.New("import mode must be atomic or best-effort")
+	ErrImportEmpty    = errors.New("import file has no data rows")
+	ErrImportTooLarge = fmt.Errorf("import rows exceed %d", MaxImportRows)
+	ErrImportFailed   = errors.New("import failed, all rows rolled back")
+
+	// errImportDryRun 预览时回滚事务
+	errImportDryRun = errors.New("import dry run")
+)
+
+// ImportColumn 导入列，模板的表头为 Title，上传的表头按 Title 或 Key 匹配
+type ImportColumn struct {
+	Key      string `json:"key"`
+	Title    string `json:"title"`
+	Required bool   `json:"required"`
+	Dict     string `json:"dict,omitempty"`
+
+	field *pkg.ExportColumn // 模型字段，模块增加的列为 nil
+}
+
+// ImportHook 模块的 service 实现该接口时，可以增加模型之外的导入列，并在创建前处理这些列，
+// 如用户的初始密码、按名称查找角色
+type ImportHook[T any] interface {
+	ImportExtraColumns() []ImportColumn
+	// BeforeImport 在调用 Create 之前执行，row 为 JSON 字段名 -> 单元格文本，返回的错误记录到该行
+	BeforeImport(ctx context.Context, entity *T, row map[string]string) error
+}
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	Mode   ImportMode
+	DryRun bool
+	// Mapping 上传文件的表头 -> JSON 字段名，未指定的表头按列标题或字段名自动匹配，值为空表示忽略该列
+	Mapping map[string]string
+}
+
+// ImportRowResult 单行的导入结果，Row 为文件中的行号（表头为第 1 行）
+type ImportRowResult struct {
+	Row     int               `json:"row"`
+	Success bool              `json:"success"`
+	ID      model.ID          `json:"id,omitempty"`
+	Errors  []string          `json:"errors,omitempty"`
+	Data    map[string]string `json:"data"`
+}
+
+// ImportReport 导入报告，DryRun 时所有行都已回滚，Committed 表示数据已经写入
+type ImportReport struct {
+	Mode      ImportMode         `json:"mode"`
+	DryRun    bool               `json:"dryRun"`
+	Committed bool               `json:"committed"`
+	Total     int                `json:"total"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Mapping   map[string]string  `json:"mapping"` // 实际使用的 表头 -> JSON 字段名
+	Ignored   []string           `json:"ignored"` // 没有匹配到导入列的表头
+	Rows      []*ImportRowResult `json:"rows"`
+}
+
+// ImportColumns 当前用户可以导入的列：模型中可写的导出列，加上模块增加的列
+func (s *BaseService[T]) ImportColumns(ctx context.Context) []ImportColumn {
+	user := scope.GetUserFromContext(ctx)
+	var columns []ImportColumn
+	for _, c := range pkg.ImportColumns(new(T), user == nil || user.IsSuper) {
+		field := c
+		columns = append(columns, ImportColumn{Key: c.Key, Title: c.Title, Required: c.Required, Dict: c.Dict, field: &field})
+	}
+	if hook, ok := s.impl().(ImportHook[T]); ok {
+		columns = append(columns, hook.ImportExtraColumns()...)
+	}
+	return columns
+}
+
+// ImportRecords 导入表格数据，records 的第一行为表头
+// 所有行在一个事务中执行，每行在独立的保存点中解析、校验并调用模块的 Create，和单条创建执行相同的检查；
+// 预览（DryRun）同样执行创建以发现唯一索引、配额等错误，最后回滚
+// atomic 模式有失败的行时整个事务回滚并返回 ErrImportFailed，best-effort 模式只提交成功的行
+func (s *BaseService[T]) ImportRecords(ctx context.Context, records [][]string, opts ImportOptions) (*ImportReport, error) {
+	if opts.Mode == "" {
+		opts.Mode = ImportAtomic
+	}
+	if opts.Mode != ImportAtomic && opts.Mode != ImportBestEffort {
+		return nil, ErrImportMode
+	}
+	if len(records) == 0 {
+		return nil, ErrImportEmpty
+	}
+
+	report := &ImportReport{Mode: opts.Mode, DryRun: opts.DryRun, Mapping: make(map[string]string), Ignored: []string{}}
+	columns, err := s.mapImportColumns(ctx, records[0], opts.Mapping, report)
+	if err != nil {
+		return nil, err
+	}
+	type importRow struct {
+		line  int
+		cells []string
+	}
+	var rows []importRow
+	for i, cells := range records[1:] {
+		if strings.TrimSpace(strings.Join(cells, "")) != "" {
+			rows = append(rows, importRow{line: i + 2, cells: cells})
+		}
+	}
+	if len(rows) == 0 {
+		return nil, ErrImportEmpty
+	}
+	if len(rows) > MaxImportRows {
+		return nil, ErrImportTooLarge
+	}
+	values, err := dictValues(ctx, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	hook, _ := s.impl().(ImportHook[T])
+	err = s.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, row := range rows {
+			result := &ImportRowResult{Row: row.line, Data: make(map[string]string)}
+			err := tx.Transaction(func(sp *gorm.DB) error {
+				entity := new(T)
+				for i, c := range columns {
+					if c == nil {
+						continue
+					}
+					var raw string
+					if i < len(row.cells) {
+						raw = strings.TrimSpace(row.cells[i])
+					}
+					if value, ok := values[c.Dict][raw]; ok {
+						raw = value
+					}
+					result.Data[c.Key] = raw
+					if raw == "" {
+						if c.Required {
+							result.Errors = append(result.Errors, fmt.Sprintf("%s: is required", c.Title))
+						}
+						continue
+					}
+					if c.field == nil {
+						continue
+					}
+					if err := c.field.Parse(entity, raw); err != nil {
+						result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", c.Title, err))
+					}
+				}
+				if len(result.Errors) > 0 {
+					return errors.New("invalid row")
+				}
+
+				rctx := db.WithTx(ctx, sp)
+				if hook != nil {
+					if err := hook.BeforeImport(rctx, entity, result.Data); err != nil {
+						result.Errors = append(result.Errors, err.Error())
+						return err
+					}
+				}
+				if err := s.impl().Create(rctx, entity); err != nil {
+					result.Errors = append(result.Errors, err.Error())
+					return err
+				}
+				if id, err := pkg.GetFieldValue(entity, "ID"); err == nil {
+					result.ID, _ = id.(model.ID)
+				}
+				return nil
+			})
+			result.Success = err == nil
+			if result.Success {
+				report.Succeeded++
+			} else {
+				report.Failed++
+			}
+			report.Rows = append(report.Rows, result)
+		}
+		report.Total = len(report.Rows)
+
+		if opts.DryRun {
+			return errImportDryRun
+		}
+		if opts.Mode == ImportAtomic && report.Failed > 0 {
+			return ErrImportFailed
+		}
+		return nil
+	})
+	if errors.Is(err, errImportDryRun) {
+		return report, nil
+	}
+	if err != nil {
+		return report, err
+	}
+	report.Committed = true
+	return report, nil
+}
+
+// mapImportColumns 按表头找到每一列对应的导入列，未匹配的列为 nil，缺少必填列时返回错误
+func (s *BaseService[T]) mapImportColumns(ctx context.Context, header []string, mapping map[string]string, report *ImportReport) ([]*ImportColumn, error) {
+	all := s.ImportColumns(ctx)
+	byKey := make(map[string]*ImportColumn, len(all))
+	byTitle := make(map[string]*ImportColumn, len(all))
+	for i := range all {
+		byKey[all[i].Key] = &all[i]
+		byTitle[all[i].Title] = &all[i]
+	}
+
+	columns := make([]*ImportColumn, len(header))
+	used := make(map[string]bool)
+	for i, h := range header {
+		h = strings.TrimSpace(strings.TrimPrefix(h, "\xEF\xBB\xBF"))
+		var c *ImportColumn
+		if key, ok := mapping[h]; ok {
+			if key != "" {
+				if c = byKey[key]; c == nil {
+					return nil, fmt.Errorf("column %s cannot be imported", key)
+				}
+			}
+		} else if c = byTitle[h]; c == nil {
+			c = byKey[h]
+		}
+		if c == nil || used[c.Key] {
+			report.Ignored = append(report.Ignored, h)
+			continue
+		}
+		used[c.Key] = true
+		columns[i] = c
+		report.Mapping[h] = c.Key
+	}
+	for _, c := range all {
+		if c.Required && !used[c.Key] {
+			return nil, fmt.Errorf("required column %s is missing", c.Title)
+		}
+	}
+	return columns, nil
+}
+
+// dictValues 读取导入列使用的字典，返回 字典编码 -> 标签 -> 值，单元格可以填写标签或值
+func dictValues(ctx context.Context, columns []*ImportColumn) (map[string]map[string]string, error) {
+	var fields []pkg.ExportColumn
+	for _, c := range columns {
+		if c != nil && c.Dict != "" {
+			fields = append(fields, pkg.ExportColumn{Dict: c.Dict})
+		}
+	}
+	labels, err := dictLabels(ctx, fields)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]map[string]string, len(labels))
+	for code, items := range labels {
+		values[code] = make(map[string]string, len(items))
+		for value, label := range items {
+			values[code][label] = value
+		}
+	}
+	return values, nil
+}
+
+// ReadImportFile 读取上传的 CSV 或 XLSX 文件（第一个工作表），返回所有行
+func ReadImportFile(r io.Reader, format ExportFormat) ([][]string, error) {
+	switch format {
+	case ExportCSV:
+		reader := csv.NewReader(r)
+		// 允许各行列数不同，缺少的单元格按空值处理
+		reader.FieldsPerRecord = -1
+		return reader.ReadAll()
+	case ExportXLSX:
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, ErrImportEmpty
+		}
+		return f.GetRows(sheets[0])
+	}
+	return nil, ErrExportFormat
+}
+
+// WriteImportTemplate 写入只有表头的导入模板
+func WriteImportTemplate(w io.Writer, format ExportFormat, columns []ImportColumn) error {
+	rw, err := newRowWriter(w, format)
+	if err != nil {
+		return err
+	}
+	header := make([]any, len(columns))
+	for i, c := range columns {
+		header[i] = c.Title
+	}
+	if err := rw.Write(header); err != nil {
+		return err
+	}
+	return rw.Close()
+}
@@ -0,0 +1,105 @@
+package shared_test
+
+import (
+	"context"
+	"seedgo/internal/db"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/user"
+	"seedgo/internal/shared"
+	"seedgo/pkg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportRecords(t *testing.T) {
+	svc := user.GetService()
+	seedCtx := db.WithoutTenant(context.Background(), "seed import data")
+	dict := &model.Dict{Code: "common_status", Name: "通用状态", Items: []*model.DictItem{{Label: "启用", Value: "1"}, {Label: "停用", Value: "0"}}}
+	require.NoError(t, global.DB.WithContext(seedCtx).Create(dict).Error)
+	defer global.DB.WithContext(seedCtx).Select("Items").Delete(dict)
+
+	entity := dbtest.Tenant("批量导入")
+	tctx := db.WithTenant(context.Background(), entity.ID)
+	role := &model.Role{Name: "运营"}
+	role.TenantID = entity.ID
+	require.NoError(t, global.DB.WithContext(tctx).Create(role).Error)
+	operator := &model.User{Username: "import-operator", PasswordHash: "x"}
+	operator.TenantID = entity.ID
+	require.NoError(t, global.DB.WithContext(tctx).Create(operator).Error)
+	t.Cleanup(func() {
+		global.DB.WithContext(tctx).Exec("DELETE FROM user_role WHERE role_id = ?", role.ID)
+		global.DB.WithContext(tctx).Unscoped().Where("tenant_id = ?", entity.ID).Delete(&model.User{})
+		global.DB.WithContext(tctx).Unscoped().Delete(role)
+	})
+	ctx := dbtest.UserCtx(operator)
+
+	records := [][]string{
+		{"\xEF\xBB\xBF用户名", "姓名", "状态", "角色", "密码", "备注"},
+		{"import-1", "张三", "停用", "运营", "secret", "x"},
+		{"", "李四", "", "", "secret"},
+		{},
+		{"import-2", "", "启用", "不存在", "secret"},
+		{"import-1", "重复", "", "", "secret"},
+		{"import-4", "王五"},
+	}
+	imported := func() int64 {
+		var count int64
+		global.DB.WithContext(tctx).Model(&model.User{}).Where("username LIKE ?", "import-%").Where("id <> ?", operator.ID).Count(&count)
+		return count
+	}
+
+	// 表头按列标题匹配，模型之外的列由模块处理，未匹配的列忽略
+	columns := svc.ImportColumns(ctx)
+	keys := make([]string, len(columns))
+	for i, c := range columns {
+		keys[i] = c.Key
+	}
+	assert.Equal(t, []string{"username", "phone", "email", "realName", "status", "password", "roles"}, keys)
+
+	// 预览执行创建后回滚，返回每行的错误
+	report, err := svc.ImportRecords(ctx, records, shared.ImportOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.False(t, report.Committed)
+	assert.Equal(t, []string{"备注"}, report.Ignored)
+	assert.Equal(t, "roles", report.Mapping["角色"])
+	require.Len(t, report.Rows, 5)
+	assert.Equal(t, 1, report.Succeeded)
+	assert.Equal(t, []int{2, 3, 5, 6, 7}, []int{report.Rows[0].Row, report.Rows[1].Row, report.Rows[2].Row, report.Rows[3].Row, report.Rows[4].Row})
+	assert.Equal(t, []string{"用户名: is required"}, report.Rows[1].Errors)
+	assert.Equal(t, []string{"role 不存在 not found"}, report.Rows[2].Errors)
+	assert.False(t, report.Rows[3].Success, "重复的用户名违反唯一索引")
+	assert.Equal(t, []string{"密码: is required"}, report.Rows[4].Errors, "没有密码的用户无法登录")
+	assert.Zero(t, imported())
+
+	// atomic 有失败时全部回滚
+	report, err = svc.ImportRecords(ctx, records, shared.ImportOptions{Mode: shared.ImportAtomic})
+	assert.ErrorIs(t, err, shared.ErrImportFailed)
+	assert.Equal(t, 4, report.Failed)
+	assert.Zero(t, imported())
+
+	// best-effort 提交成功的行
+	report, err = svc.ImportRecords(ctx, records, shared.ImportOptions{Mode: shared.ImportBestEffort})
+	require.NoError(t, err)
+	assert.True(t, report.Committed)
+	assert.EqualValues(t, 1, imported())
+	var created model.User
+	require.NoError(t, global.DB.WithContext(tctx).Preload("Roles").First(&created, report.Rows[0].ID).Error)
+	assert.Equal(t, "张三", *created.RealName)
+	assert.EqualValues(t, 0, *created.Status)
+	assert.True(t, pkg.CheckPasswordHash("secret", created.PasswordHash))
+	require.Len(t, created.Roles, 1)
+	assert.Equal(t, role.ID, created.Roles[0].ID)
+
+	// 手动指定对应关系，缺少必填列时返回错误
+	_, err = svc.ImportRecords(ctx, records, shared.ImportOptions{DryRun: true, Mapping: map[string]string{"用户名": ""}})
+	assert.EqualError(t, err, "required column 用户名 is missing")
+	_, err = svc.ImportRecords(ctx, [][]string{{"用户名"}, {"import-3"}}, shared.ImportOptions{DryRun: true})
+	assert.EqualError(t, err, "required column 密码 is missing")
+	report, err = svc.ImportRecords(ctx, [][]string{{"账号", "密码"}, {"import-3", "secret"}}, shared.ImportOptions{DryRun: true, Mapping: map[string]string{"账号": "username"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Succeeded)
+}
@@ -2,9 +2,12 @@ package shared
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"seedgo/internal/db"
 	"seedgo/internal/global"
 	"seedgo/internal/model"
+	"seedgo/internal/scope"
 	"seedgo/pkg"
 
 	"gorm.io/gorm"
@@ -14,17 +17,43 @@ type IBaseService[T any] interface {
 	Create(ctx context.Context, entity *T) error
 	Update(ctx context.Context, entity *T) error
 	Delete(ctx context.Context, id model.ID) error
-	Get(ctx context.Context, id model.ID) (*T, error)
+	// Get 获取单条记录，scopes 用于详情接口的 fields、expand，重写 Get 的模块需要应用到查询上
+	Get(ctx context.Context, id model.ID, scopes ...func(*gorm.DB) *gorm.DB) (*T, error)
 	List(ctx context.Context) ([]T, error)
 	Page(ctx context.Context, query pkg.QueryPage, scopes ...func(*gorm.DB) *gorm.DB) ([]T, int64, error)
 
 	// Options 获取下拉框选项，默认返回ID和String()
 	Options(ctx context.Context, query pkg.QueryPage, scopes ...func(*gorm.DB) *gorm.DB) ([]T, int64, error)
+	// FieldsScope 只查询指定的列并预加载模型允许展开的关联
+	FieldsScope(fields, expand []string, required ...string) (func(*gorm.DB) *gorm.DB, error)
+	// Paginate 列表接口的分页查询，支持页码分页和游标分页，总数可以精确统计、估算或不统计
+	Paginate(ctx context.Context, query pkg.QueryPage, scopes ...func(*gorm.DB) *gorm.DB) (*scope.PageResult, error)
+
+	// UpdateFields 按 JSON 字段名更新单条记录的部分字段，只允许可写字段，通过 Update 保存
+	UpdateFields(ctx context.Context, id model.ID, fields map[string]any) error
+	// BatchDelete 在一个事务中逐个调用 Delete，任一失败时全部回滚
+	BatchDelete(ctx context.Context, ids []model.ID) ([]*BatchResult, error)
+	// BatchUpdate 在一个事务中逐个调用 UpdateFields，任一失败时全部回滚
+	BatchUpdate(ctx context.Context, ids []model.ID, fields map[string]any) ([]*BatchResult, error)
+
+	// Count 统计符合条件的记录数
+	Count(ctx context.Context, scopes ...func(*gorm.DB) *gorm.DB) (int64, error)
+	// Each 按排序分批读取符合条件的记录，用于导出
+	Each(ctx context.Context, query pkg.QueryPage, fn func(batch []T) error, scopes ...func(*gorm.DB) *gorm.DB) error
+
+	// ImportColumns 当前用户可以导入的列
+	ImportColumns(ctx context.Context) []ImportColumn
+	// ImportRecords 逐行调用 Create 导入表格数据，返回每行的结果
+	ImportRecords(ctx context.Context, records [][]string, opts ImportOptions) (*ImportReport, error)
 }
 
 // BaseService 约束 T 必须实现 model.Entity 接口
 type BaseService[T any] struct {
 	DB *gorm.DB
+
+	// Impl 模块的 service，批量操作通过它调用模块重写的 Delete、Update
+	// 重写了这两个方法的模块需要在构造时设置，未设置时使用 BaseService 自身的实现
+	Impl IBaseService[T]
 }
 
 func NewBaseService[T any]() *BaseService[T] {
@@ -33,45 +62,99 @@ func NewBaseService[T any]() *BaseService[T] {
 	}
 }
 
+// Conn 获取数据库连接，context 中有事务（db.WithTx）时加入该事务
+func (s *BaseService[T]) Conn(ctx context.Context) *gorm.DB {
+	if tx := db.TxFromContext(ctx); tx != nil {
+		return tx.WithContext(ctx)
+	}
+	return s.DB.WithContext(ctx)
+}
+
+// Create 创建实体，创建前执行 CreateHook（如配额检查）
 func (s *BaseService[T]) Create(ctx context.Context, entity *T) error {
-	return s.DB.WithContext(ctx).Create(entity).Error
+	return s.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.RunCreateHooks(ctx, tx, entity); err != nil {
+			return err
+		}
+		return tx.Create(entity).Error
+	})
 }
 
+// Update 更新实体，只写入模型声明为 seedgo:"writable" 的列，特权列仅超级用户可写
+// 提交了版本号时按乐观锁更新，见 Versioned
 func (s *BaseService[T]) Update(ctx context.Context, entity *T) error {
-	return s.DB.WithContext(ctx).Omit("created_at").Save(entity).Error
+	columns, err := s.WritableColumns(ctx, entity)
+	if err != nil {
+		return err
+	}
+	return s.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.Versioned(tx, entity, func(tx *gorm.DB) *gorm.DB {
+			return tx.Select(columns).Omit("created_at").Save(entity)
+		})
+	})
+}
+
+// WritableColumns 获取当前用户对实体可写的数据库列
+// 没有登录用户的上下文（后台任务、内部调用）视为系统调用，可写特权列
+func (s *BaseService[T]) WritableColumns(ctx context.Context, entity *T) ([]string, error) {
+	user := scope.GetUserFromContext(ctx)
+	super := user == nil || user.IsSuper
+
+	stmt := &gorm.Statement{DB: s.DB}
+	if err := stmt.Parse(entity); err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	for _, name := range pkg.WritableFields(entity, super) {
+		// 关联和 gorm:"-" 字段没有列，不参与 Select，避免触发关联保存
+		if field := stmt.Schema.LookUpField(name); field != nil && field.DBName != "" {
+			columns = append(columns, field.DBName)
+		}
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("no writable fields")
+	}
+	return columns, nil
 }
 
 func (s *BaseService[T]) Delete(ctx context.Context, id model.ID) error {
 	var entity T
-	return s.DB.WithContext(ctx).Delete(&entity, id).Error
+	return s.Conn(ctx).Delete(&entity, id).Error
 }
 
-func (s *BaseService[T]) Get(ctx context.Context, id model.ID) (*T, error) {
+func (s *BaseService[T]) Get(ctx context.Context, id model.ID, scopes ...func(*gorm.DB) *gorm.DB) (*T, error) {
 	var entity T
-	err := s.DB.WithContext(ctx).First(&entity, id).Error
+	err := s.Conn(ctx).Scopes(scopes...).First(&entity, id).Error
 	return &entity, err
 }
 
 func (s *BaseService[T]) List(ctx context.Context) ([]T, error) {
 	var entities []T
-	err := s.DB.WithContext(ctx).Find(&entities).Error
+	err := s.Conn(ctx).Find(&entities).Error
 	return entities, err
 }
 
 func (s *BaseService[T]) Page(ctx context.Context, query pkg.QueryPage, scopes ...func(*gorm.DB) *gorm.DB) ([]T, int64, error) {
-	var entities []T
 	var total int64
 
 	var entity T
 	// Count 只需要 Where 条件，Preload 会被忽略，但为了保持一致性我们还是带上 scopes
-	s.DB.WithContext(ctx).Model(&entity).Scopes(scopes...).Count(&total)
+	s.Conn(ctx).Model(&entity).Scopes(scopes...).Count(&total)
+
+	entities, err := s.offsetPage(ctx, query, scopes...)
+	return entities, total, err
+}
 
+// offsetPage 按页码查询一页记录
+func (s *BaseService[T]) offsetPage(ctx context.Context, query pkg.QueryPage, scopes ...func(*gorm.DB) *gorm.DB) ([]T, error) {
+	var entities []T
+	var entity T
 	offset := (*query.Page - 1) * *query.PageSize
 	// Find 需要 Preload，显式开启 Session 确保无状态残留
 	order := fmt.Sprintf("%s %s", *query.SortBy, *query.SortDesc)
-	err := s.DB.WithContext(ctx).Model(&entity).Scopes(scopes...).Order(order).Offset(offset).Limit(*query.PageSize).Session(&gorm.Session{}).Find(&entities).Error
-
-	return entities, total, err
+	err := s.Conn(ctx).Model(&entity).Scopes(scopes...).Order(order).Offset(offset).Limit(*query.PageSize).Session(&gorm.Session{}).Find(&entities).Error
+	return entities, err
 }
 
 // Options 获取下拉框选项，默认返回ID和String()
@@ -0,0 +1,80 @@
+package shared
+
+import (
+	"errors"
+	"reflect"
+	"seedgo/internal/model"
+	"seedgo/internal/scope"
+
+	"gorm.io/gorm"
+)
+
+// ErrVersionRequired 开启 RequireVersion 的接口更新时没有提交版本号
+var ErrVersionRequired = errors.New("version is required, reload and try again")
+
+// ConflictError 乐观锁冲突，Current 为服务器上的当前记录，前端据此合并或重新加载
+type ConflictError struct {
+	Current any
+}
+
+func (e *ConflictError) Error() string {
+	return "record has been modified by someone else, reload and try again"
+}
+
+// Code 业务码，供 scope.FailWithError 使用
+func (e *ConflictError) Code() int {
+	return scope.ConflictCode
+}
+
+// Data 冲突时返回当前记录
+func (e *ConflictError) Data() any {
+	return e.Current
+}
+
+var versionType = reflect.TypeOf(model.Version(0))
+
+// versionOf 实体的乐观锁版本号，模型没有 model.Version 类型的 Version 字段时返回 nil
+func versionOf(entity any) *model.Version {
+	v := reflect.ValueOf(entity).Elem()
+	field := v.FieldByName("Version")
+	if !field.IsValid() || field.Type() != versionType {
+		return nil
+	}
+	return field.Addr().Interface().(*model.Version)
+}
+
+// Versioned 按乐观锁写入实体，write 写入时需要包含 version 列（WritableColumns 已经包含）
+// 提交了版本号（大于 0）时只更新版本号一致的记录，不一致时返回 *ConflictError；
+// 没有提交版本号时不检查，兼容不带版本号的调用方；两种情况写入的版本号都加 1
+func (s *BaseService[T]) Versioned(tx *gorm.DB, entity *T, write func(tx *gorm.DB) *gorm.DB) error {
+	version := versionOf(entity)
+	if version == nil {
+		return write(tx).Error
+	}
+	expected := *version
+	id := reflect.ValueOf(entity).Elem().FieldByName("ID").Interface()
+
+	if expected <= 0 {
+		var current model.Version
+		if err := tx.Session(&gorm.Session{NewDB: true}).Model(new(T)).Select("version").Where("id = ?", id).Scan(&current).Error; err != nil {
+			return err
+		}
+		*version = current + 1
+		return write(tx).Error
+	}
+
+	*version = expected + 1
+	result := write(tx.Where("version = ?", expected))
+	if result.Error == nil && result.RowsAffected > 0 {
+		return nil
+	}
+	*version = expected
+	if result.Error != nil {
+		return result.Error
+	}
+	current := new(T)
+	if err := tx.Session(&gorm.Session{NewDB: true}).First(current, id).Error; err != nil {
+		return err
+	}
+	return &ConflictError{Current: current}
+}
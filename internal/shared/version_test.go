@@ -0,0 +1,114 @@
+package shared_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"seedgo/internal/db"
+	"seedgo/internal/db/dbtest"
+	"seedgo/internal/global"
+	"seedgo/internal/model"
+	"seedgo/internal/modules/policy"
+	"seedgo/internal/modules/role"
+	"seedgo/internal/scope"
+	"seedgo/internal/shared"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptimisticLock(t *testing.T) {
+	entity := dbtest.Tenant("乐观锁")
+	tctx := db.WithTenant(context.Background(), entity.ID)
+	operator := &model.User{Username: "lock-operator", PasswordHash: "x"}
+	operator.TenantID = entity.ID
+	require.NoError(t, global.DB.WithContext(tctx).Create(operator).Error)
+	t.Cleanup(func() {
+		global.DB.WithContext(tctx).Unscoped().Where("tenant_id = ?", entity.ID).Delete(&model.Role{})
+		global.DB.WithContext(tctx).Unscoped().Where("tenant_id = ?", entity.ID).Delete(&model.Policy{})
+		global.DB.WithContext(tctx).Unscoped().Delete(operator)
+	})
+	ctx := dbtest.UserCtx(operator)
+
+	svc := role.Instance()
+	created := &model.Role{Name: "编辑"}
+	require.NoError(t, svc.Create(ctx, created))
+	load := func() *model.Role {
+		var r model.Role
+		require.NoError(t, global.DB.WithContext(tctx).First(&r, created.ID).Error)
+		return &r
+	}
+	first, second := load(), load()
+	require.EqualValues(t, 1, first.Version)
+
+	// 两个人编辑同一条记录，后提交的冲突，返回服务器上的当前记录
+	first.Name = "编辑-1"
+	require.NoError(t, svc.Update(ctx, first))
+	assert.EqualValues(t, 2, first.Version)
+	second.Name = "编辑-2"
+	err := svc.Update(ctx, second)
+	var conflict *shared.ConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, scope.ConflictCode, conflict.Code())
+	current := conflict.Current.(*model.Role)
+	assert.Equal(t, "编辑-1", current.Name)
+	assert.EqualValues(t, 2, current.Version)
+	assert.EqualValues(t, 1, second.Version, "冲突时保留提交的版本号")
+	assert.Equal(t, "编辑-1", load().Name)
+
+	// 使用最新的版本号重新提交成功；不提交版本号时不检查，版本号同样加 1
+	second.Version = current.Version
+	require.NoError(t, svc.Update(ctx, second))
+	second.Version = 0
+	second.Name = "编辑-3"
+	require.NoError(t, svc.Update(ctx, second))
+	latest := load()
+	assert.Equal(t, "编辑-3", latest.Name)
+	assert.EqualValues(t, 4, latest.Version)
+
+	// BaseService.Update 和批量更新同样检查
+	policies := policy.GetService()
+	p := &model.Policy{Name: "p", Action: "order:approve", Effect: model.EffectAllow, Expression: "true", Status: 1}
+	require.NoError(t, policies.Create(ctx, p))
+	stale := *p
+	stale.Version = 1
+	p.Version = 1
+	p.Name = "p-1"
+	require.NoError(t, policies.Update(ctx, p))
+	stale.Name = "p-2"
+	assert.ErrorAs(t, policies.Update(ctx, &stale), &conflict)
+	_, err = policies.BatchUpdate(ctx, []model.ID{p.ID}, map[string]any{"status": 0, "version": 1})
+	assert.ErrorIs(t, err, shared.ErrBatchFailed)
+	_, err = policies.BatchUpdate(ctx, []model.ID{p.ID}, map[string]any{"status": 0, "version": 2})
+	require.NoError(t, err)
+}
+
+func TestRequireVersion(t *testing.T) {
+	entity := dbtest.Tenant("必须提交版本号")
+	operator := dbtest.User(entity.ID, "version-operator")
+	ctx := dbtest.UserCtx(operator)
+	created := &model.Role{Name: "运营"}
+	require.NoError(t, role.Instance().Create(ctx, created))
+
+	r := gin.New()
+	g := r.Group("/roles", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(ctx)
+		c.Set("user", scope.GetUserFromContext(ctx))
+	})
+	role.NewHandler().Use(g)
+	update := func(body string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("PUT", "/roles/"+created.ID.String(), strings.NewReader(body)))
+		var res scope.Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		return res.Code
+	}
+
+	// 没有提交版本号时拒绝，提交后按乐观锁更新
+	assert.Equal(t, scope.InvalidParamsCode, update(`{"name":"运营-1"}`))
+	assert.Equal(t, scope.SuccessCode, update(`{"name":"运营-1","version":1}`))
+	assert.Equal(t, scope.ConflictCode, update(`{"name":"运营-2","version":1}`))
+}
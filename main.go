@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"seedgo/internal/api"
 	"seedgo/internal/db"
 	"seedgo/internal/global"
+	"seedgo/internal/modules/export"
+	"seedgo/internal/modules/tenant"
 	"seedgo/pkg/cache"
 )
 
@@ -19,17 +23,27 @@ func main() {
 	// 3. 初始化缓存
 	global.Cache = cache.Use(cache.NewMemoryCache())
 
-	// 4. 初始化路由
+	// 4. 后台清除保留期结束的已删除租户
+	if interval := global.Config.Tenant.PurgeInterval; interval > 0 {
+		tenant.StartPurgeWorker(context.Background(), interval)
+	}
+
+	// 5. 后台清理过期的导出文件
+	if retention := global.Config.Export.Retention; retention > 0 {
+		export.StartCleanWorker(context.Background(), retention)
+	}
+
+	// 6. 初始化路由
 	r := api.InitRouter()
 
-	// 4. 启动服务
+	// 7. 启动服务
 	port := global.Config.Server.Port
 	if port == 0 {
 		port = 3000
 	}
 	addr := fmt.Sprintf(":%d", port)
 	log.Printf("Server starting on %s", addr)
-	if err := r.Run(addr); err != nil {
+	if err := http.ListenAndServe(addr, r); err != nil {
 		log.Fatalf("Server startup failed: %v", err)
 	}
 	log.Println("Server started on port", port)
@@ -38,6 +38,9 @@ type Cache interface {
 	// Ttl 获取剩余过期时间
 	Ttl(key string) time.Duration
 
+	// Incr 原子自增整数值并返回自增后的值，key 不存在时从 0 开始，不改变过期时间
+	Incr(key string) (int64, error)
+
 	// Call 获取缓存，如果不存在则执行回调函数并设置缓存
 	// dest: 接收返回值的指针
 	Call(key string, dest any, f func() (any, error), ttl ...time.Duration) error
@@ -103,3 +103,25 @@ func TestMemoryCache_JSON(t *testing.T) {
 	// assert.IsType(t, map[string]interface{}{}, anyVar)
 	// 注意：json.Unmarshal 数字默认是 float64
 }
+
+func TestMemoryCache_Incr(t *testing.T) {
+	c := NewMemoryCache()
+	defer c.Close()
+
+	v, err := c.Incr("counter")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), v)
+
+	v, _ = c.Incr("counter")
+	assert.Equal(t, int64(2), v)
+
+	var out int64
+	assert.NoError(t, c.Get("counter", &out))
+	assert.Equal(t, int64(2), out)
+
+	// 不改变过期时间
+	c.Set("ttl_counter", 10, time.Second)
+	v, _ = c.Incr("ttl_counter")
+	assert.Equal(t, int64(11), v)
+	assert.True(t, c.Ttl("ttl_counter") > 0)
+}
@@ -197,6 +197,36 @@ func (c *MemoryCache) Ttl(key string) time.Duration {
 	return time.Duration(item.ExpiresAt - now)
 }
 
+func (c *MemoryCache) Incr(key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var val int64
+	it, ok := c.items[key]
+	if ok && it.ExpiresAt > 0 && time.Now().UnixNano() > it.ExpiresAt {
+		ok = false
+	}
+	if ok {
+		if err := json.Unmarshal(it.Value, &val); err != nil {
+			c.lastErr = err
+			return 0, err
+		}
+	} else {
+		it = &item{}
+		c.items[key] = it
+	}
+
+	val++
+	data, err := json.Marshal(val)
+	if err != nil {
+		c.lastErr = err
+		return 0, err
+	}
+	it.Value = data
+	c.lastErr = nil
+	return val, nil
+}
+
 func (c *MemoryCache) Call(key string, dest any, f func() (any, error), ttl ...time.Duration) error {
 	// 1. Check if key exists
 	if c.Has(key) {
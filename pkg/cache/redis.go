@@ -117,6 +117,12 @@ func (c *RedisCache) Ttl(key string) time.Duration {
 	return d
 }
 
+func (c *RedisCache) Incr(key string) (int64, error) {
+	val, err := c.client.Incr(context.Background(), key).Result()
+	c.lastErr = err
+	return val, err
+}
+
 func (c *RedisCache) Call(key string, dest any, f func() (any, error), ttl ...time.Duration) error {
 	// 1. Check if key exists
 	if c.Has(key) {
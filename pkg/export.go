@@ -0,0 +1,257 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TagExport 模型字段的导出标签，值为列标题
+//
+//	export:"用户名"                   导出列
+//	export:"状态,dict=common_status"  值按字典编码翻译为字典标签
+//	export:"用户名,required"          导入时必填
+const TagExport = "export"
+
+// ExportColumn 导出列
+type ExportColumn struct {
+	Key   string // JSON 字段名，请求参数 columns 按它选择列
+	Title string // 列标题
+	Dict  string // 字典编码，为空不翻译
+	// Required 导入时必填
+	Required bool
+
+	name  string
+	index []int
+}
+
+// exportCache 缓存模型的导出列，避免重复反射
+var exportCache = sync.Map{}
+
+func getExportColumns(t reflect.Type) []ExportColumn {
+	if cache, ok := exportCache.Load(t); ok {
+		return cache.([]ExportColumn)
+	}
+
+	var columns []ExportColumn
+	parseExport(t, nil, &columns)
+
+	exportCache.Store(t, columns)
+	return columns
+}
+
+func parseExport(t reflect.Type, parent []int, columns *[]ExportColumn) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		index := append(append([]int{}, parent...), i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			parseExport(field.Type, index, columns)
+			continue
+		}
+
+		tag := field.Tag.Get(TagExport)
+		if tag == "" || tag == "-" {
+			continue
+		}
+		column := ExportColumn{Key: field.Name, name: field.Name, index: index}
+		if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+			column.Key = name
+		}
+		for n, opt := range strings.Split(tag, ",") {
+			opt = strings.TrimSpace(opt)
+			if n == 0 {
+				column.Title = opt
+			} else if strings.HasPrefix(opt, "dict=") {
+				column.Dict = strings.TrimPrefix(opt, "dict=")
+			} else if opt == "required" {
+				column.Required = true
+			}
+		}
+		*columns = append(*columns, column)
+	}
+}
+
+// ExportColumns 获取模型的导出列
+// @param obj: 任意结构体实例或结构体指针
+// @param keys: 按 JSON 字段名选择列并按此排序，为空时返回所有导出列，未声明导出的字段忽略
+func ExportColumns(obj any, keys []string) []ExportColumn {
+	t := reflect.TypeOf(obj)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	all := getExportColumns(t)
+	if len(keys) == 0 {
+		return all
+	}
+	var columns []ExportColumn
+	for _, key := range keys {
+		for _, c := range all {
+			if c.Key == key {
+				columns = append(columns, c)
+				break
+			}
+		}
+	}
+	return columns
+}
+
+// ImportColumns 获取模型的导入列，即当前用户可写（seedgo:"writable"）的导出列
+// @param obj: 任意结构体实例或结构体指针
+// @param super: 是否为超级用户，超级用户可导入特权字段
+func ImportColumns(obj any, super bool) []ExportColumn {
+	writable := make(map[string]bool)
+	for _, name := range WritableFields(obj, super) {
+		writable[name] = true
+	}
+	var columns []ExportColumn
+	for _, c := range ExportColumns(obj, nil) {
+		if writable[c.name] {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
+
+// Format 获取字段的导出值：空指针为空字符串，时间格式化为 2006-01-02 15:04:05，布尔为 是/否，数值保留原类型
+// @param obj: 结构体实例或结构体指针
+func (c ExportColumn) Format(obj any) any {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	for _, i := range c.index {
+		v = v.Field(i)
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return ""
+			}
+			v = v.Elem()
+		}
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		return formatTime(t)
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	if v.CanAddr() {
+		// model.ID、model.DateTime 等类型的 String 定义在指针上
+		if s, ok := v.Addr().Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return "是"
+		}
+		return "否"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// Parse 把导入的单元格文本写入字段，是 Format 的逆操作
+// 空字符串保持零值，时间支持 2006-01-02 15:04:05 和 2006-01-02，布尔支持 是/否、true/false、1/0
+// @param obj: 结构体指针
+func (c ExportColumn) Parse(obj any, raw string) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("obj must be a non-nil pointer")
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	v = v.Elem()
+	for _, i := range c.index {
+		v = v.Field(i)
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+	}
+
+	invalid := fmt.Errorf("invalid value %q", raw)
+	// time.Time 以及 model.DateTime 等基于 time.Time 的类型
+	if v.Kind() == reflect.Struct && timeType.ConvertibleTo(v.Type()) {
+		t, err := parseTime(raw)
+		if err != nil {
+			return invalid
+		}
+		v.Set(reflect.ValueOf(t).Convert(v.Type()))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		switch strings.ToLower(raw) {
+		case "是", "true", "1":
+			v.SetBool(true)
+		case "否", "false", "0":
+			v.SetBool(false)
+		default:
+			return invalid
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || v.OverflowInt(n) {
+			return invalid
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || v.OverflowUint(n) {
+			return invalid
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v.OverflowFloat(n) {
+			return invalid
+		}
+		v.SetFloat(n)
+	case reflect.String:
+		v.SetString(raw)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func parseTime(raw string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", raw, time.Local); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", raw, time.Local)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,409 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Env 表达式的执行环境，顶层一般为 user、request、resource
+type Env map[string]any
+
+type node interface {
+	eval(env Env) (any, error)
+}
+
+type literalNode struct {
+	value any
+}
+
+func (n *literalNode) eval(Env) (any, error) {
+	return n.value, nil
+}
+
+type identNode struct {
+	name string
+}
+
+func (n *identNode) eval(env Env) (any, error) {
+	return normalize(env[n.name]), nil
+}
+
+type memberNode struct {
+	target node
+	name   string
+}
+
+func (n *memberNode) eval(env Env) (any, error) {
+	target, err := n.target.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, nil
+	}
+	m, ok := target.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("policy: cannot access field %q of %T", n.name, target)
+	}
+	return normalize(m[n.name]), nil
+}
+
+type arrayNode struct {
+	items []node
+}
+
+func (n *arrayNode) eval(env Env) (any, error) {
+	values := make([]any, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+type callNode struct {
+	name string
+	fn   func(args []any) (any, error)
+	args []node
+}
+
+func (n *callNode) eval(env Env) (any, error) {
+	args := make([]any, 0, len(n.args))
+	for _, arg := range n.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	v, err := n.fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("policy: %s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n *unaryNode) eval(env Env) (any, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "!":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("policy: operator ! expects bool, got %T", v)
+		}
+		return !b, nil
+	default:
+		f, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("policy: operator - expects number, got %T", v)
+		}
+		return -f, nil
+	}
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n *binaryNode) eval(env Env) (any, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// 逻辑运算短路
+	if n.op == "&&" || n.op == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("policy: operator %s expects bool, got %T", n.op, left)
+		}
+		if (n.op == "&&" && !l) || (n.op == "||" && l) {
+			return l, nil
+		}
+		right, err := n.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("policy: operator %s expects bool, got %T", n.op, right)
+		}
+		return r, nil
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		return contains(right, left)
+	case "<", "<=", ">", ">=":
+		c, err := compare(left, right)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	}
+
+	// 算术运算，+ 同时支持字符串拼接
+	if ls, ok := left.(string); ok && n.op == "+" {
+		if rs, ok := right.(string); ok {
+			return ls + rs, nil
+		}
+	}
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("policy: operator %s expects numbers, got %T and %T", n.op, left, right)
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("policy: division by zero")
+		}
+		return l / r, nil
+	default:
+		if r == 0 {
+			return nil, fmt.Errorf("policy: division by zero")
+		}
+		return math.Mod(l, r), nil
+	}
+}
+
+// normalize 统一值类型：数字（包括 model.ID 等自定义类型）转 float64，切片转 []any，map 转 map[string]any
+func normalize(v any) any {
+	if v == nil {
+		return nil
+	}
+	switch val := v.(type) {
+	case bool, string, float64, time.Time, []any, map[string]any:
+		return val
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		return normalize(rv.Elem().Interface())
+	}
+	if f, ok := toFloat(rv); ok {
+		return f
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Slice, reflect.Array:
+		values := make([]any, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			values[i] = normalize(rv.Index(i).Interface())
+		}
+		return values
+	case reflect.Map:
+		m := make(map[string]any, rv.Len())
+		for _, key := range rv.MapKeys() {
+			m[fmt.Sprint(key.Interface())] = rv.MapIndex(key).Interface()
+		}
+		return m
+	}
+	return v
+}
+
+func toFloat(rv reflect.Value) (float64, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func equal(a, b any) bool {
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Equal(tb)
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func compare(a, b any) (int, error) {
+	switch l := a.(type) {
+	case float64:
+		if r, ok := b.(float64); ok {
+			switch {
+			case l < r:
+				return -1, nil
+			case l > r:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if r, ok := b.(string); ok {
+			return strings.Compare(l, r), nil
+		}
+	case time.Time:
+		if r, ok := b.(time.Time); ok {
+			return l.Compare(r), nil
+		}
+	}
+	return 0, fmt.Errorf("policy: cannot compare %T and %T", a, b)
+}
+
+// contains 判断 collection 是否包含 item，collection 可以是数组或字符串
+func contains(collection, item any) (bool, error) {
+	switch c := collection.(type) {
+	case []any:
+		for _, v := range c {
+			if equal(normalize(v), item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case string:
+		s, ok := item.(string)
+		if !ok {
+			return false, fmt.Errorf("policy: cannot check %T in string", item)
+		}
+		return strings.Contains(c, s), nil
+	case nil:
+		return false, nil
+	}
+	return false, fmt.Errorf("policy: operator in expects array or string, got %T", collection)
+}
+
+// functions 表达式中可用的函数
+var functions = map[string]func(args []any) (any, error){
+	// hour(t) 小时 0-23
+	"hour": func(args []any) (any, error) {
+		t, err := timeArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		return float64(t.Hour()), nil
+	},
+	// weekday(t) 星期 0-6，0 为周日
+	"weekday": func(args []any) (any, error) {
+		t, err := timeArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		return float64(t.Weekday()), nil
+	},
+	// cidr(ip, "10.0.0.0/8", ...) IP 是否属于任一网段
+	"cidr": func(args []any) (any, error) {
+		if len(args) < 2 {
+			return nil, fmt.Errorf("expects ip and at least one cidr")
+		}
+		ipStr, ok := args[0].(string)
+		if !ok {
+			return false, nil
+		}
+		ip := net.ParseIP(ipStr)
+		if ip == nil {
+			return false, nil
+		}
+		for _, arg := range args[1:] {
+			block, ok := arg.(string)
+			if !ok {
+				return nil, fmt.Errorf("cidr must be string, got %T", arg)
+			}
+			_, network, err := net.ParseCIDR(block)
+			if err != nil {
+				return nil, err
+			}
+			if network.Contains(ip) {
+				return true, nil
+			}
+		}
+		return false, nil
+	},
+	// contains(collection, item) 同 item in collection
+	"contains": func(args []any) (any, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("expects 2 arguments")
+		}
+		return contains(args[0], args[1])
+	},
+	// startsWith(s, prefix)
+	"startsWith": func(args []any) (any, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("expects 2 arguments")
+		}
+		s, _ := args[0].(string)
+		prefix, _ := args[1].(string)
+		return strings.HasPrefix(s, prefix), nil
+	},
+	// len(x) 数组或字符串长度
+	"len": func(args []any) (any, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expects 1 argument")
+		}
+		switch v := args[0].(type) {
+		case []any:
+			return float64(len(v)), nil
+		case string:
+			return float64(len([]rune(v))), nil
+		case nil:
+			return float64(0), nil
+		}
+		return nil, fmt.Errorf("expects array or string, got %T", args[0])
+	},
+}
+
+// timeArg 取时间参数，支持 time.Time 和 RFC3339 字符串（方便测试数据）
+func timeArg(args []any, n int) (time.Time, error) {
+	if len(args) != n {
+		return time.Time{}, fmt.Errorf("expects %d argument", n)
+	}
+	switch v := args[0].(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		return time.Parse(time.RFC3339, v)
+	}
+	return time.Time{}, fmt.Errorf("expects time, got %T", args[0])
+}
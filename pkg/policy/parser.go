@@ -0,0 +1,276 @@
+// Package policy 内嵌的策略表达式引擎，用于在 RBAC 之上做基于属性的访问控制（ABAC）
+//
+// 表达式示例：
+//
+//	resource.amount < 10000 && "finance" in user.roles
+//	hour(request.time) >= 9 && hour(request.time) < 18 && cidr(request.ip, "10.0.0.0/8")
+//
+// 支持：
+//  1. 字面量：数字、字符串（单/双引号）、true、false、null、数组 [1, 2]
+//  2. 变量：user.id、request.ip、resource.amount，点号访问 map 的字段
+//  3. 运算符：|| && ! == != < <= > >= in + - * / %
+//  4. 函数：见 functions
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// lex 把表达式拆分为 token
+func lex(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, value: string(runes[start:i]), pos: start})
+		case r == '"' || r == '\'':
+			start := i
+			i++
+			var sb strings.Builder
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("policy: unterminated string at %d", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokString, value: sb.String(), pos: start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, value: string(runes[start:i]), pos: start})
+		default:
+			start := i
+			two := ""
+			if i+1 < len(runes) {
+				two = string(runes[i : i+2])
+			}
+			switch two {
+			case "&&", "||", "==", "!=", "<=", ">=":
+				tokens = append(tokens, token{kind: tokOp, value: two, pos: start})
+				i += 2
+				continue
+			}
+			if !strings.ContainsRune("!<>+-*/%().,[]", r) {
+				return nil, fmt.Errorf("policy: unexpected character %q at %d", r, start)
+			}
+			tokens = append(tokens, token{kind: tokOp, value: string(r), pos: start})
+			i++
+		}
+	}
+	tokens = append(tokens, token{kind: tokEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+// 二元运算符优先级，数值越大优先级越高
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4, "in": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(op string) error {
+	t := p.next()
+	if t.kind != tokOp || t.value != op {
+		return fmt.Errorf("policy: expected %q at %d", op, t.pos)
+	}
+	return nil
+}
+
+// binaryOp 当前 token 如果是二元运算符，返回运算符
+func (p *parser) binaryOp() (string, bool) {
+	t := p.peek()
+	if t.kind == tokOp || (t.kind == tokIdent && t.value == "in") {
+		if _, ok := precedence[t.value]; ok {
+			return t.value, true
+		}
+	}
+	return "", false
+}
+
+// parseExpr 优先级爬升解析二元表达式
+func (p *parser) parseExpr(minPrec int) (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.binaryOp()
+		if !ok || precedence[op] < minPrec {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseExpr(precedence[op] + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	t := p.peek()
+	if t.kind == tokOp && (t.value == "!" || t.value == "-") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: t.value, operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+// parsePostfix 解析成员访问 a.b
+func (p *parser) parsePostfix() (node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokOp || t.value != "." {
+			return n, nil
+		}
+		p.next()
+		name := p.next()
+		if name.kind != tokIdent {
+			return nil, fmt.Errorf("policy: expected field name at %d", name.pos)
+		}
+		n = &memberNode{target: n, name: name.value}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("policy: invalid number %q at %d", t.value, t.pos)
+		}
+		return &literalNode{value: f}, nil
+	case tokString:
+		return &literalNode{value: t.value}, nil
+	case tokIdent:
+		switch t.value {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		// 函数调用
+		if next := p.peek(); next.kind == tokOp && next.value == "(" {
+			fn, ok := functions[t.value]
+			if !ok {
+				return nil, fmt.Errorf("policy: unknown function %q at %d", t.value, t.pos)
+			}
+			p.next()
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			return &callNode{name: t.value, fn: fn, args: args}, nil
+		}
+		return &identNode{name: t.value}, nil
+	case tokOp:
+		switch t.value {
+		case "(":
+			n, err := p.parseExpr(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &arrayNode{items: items}, nil
+		}
+	case tokEOF:
+		return nil, fmt.Errorf("policy: unexpected end of expression")
+	}
+	return nil, fmt.Errorf("policy: unexpected %q at %d", t.value, t.pos)
+}
+
+// parseList 解析逗号分隔的表达式列表，直到结束符
+func (p *parser) parseList(end string) ([]node, error) {
+	var items []node
+	if t := p.peek(); t.kind == tokOp && t.value == end {
+		p.next()
+		return items, nil
+	}
+	for {
+		item, err := p.parseExpr(1)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		t := p.next()
+		if t.kind == tokOp && t.value == end {
+			return items, nil
+		}
+		if t.kind != tokOp || t.value != "," {
+			return nil, fmt.Errorf("policy: expected \",\" or %q at %d", end, t.pos)
+		}
+	}
+}
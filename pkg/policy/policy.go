@@ -0,0 +1,68 @@
+package policy
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Expression 编译后的表达式，可并发执行
+type Expression struct {
+	src  string
+	root node
+}
+
+// Compile 编译表达式
+func Compile(src string) (*Expression, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseExpr(1)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("policy: unexpected %q at %d", t.value, t.pos)
+	}
+	return &Expression{src: src, root: root}, nil
+}
+
+// String 原始表达式
+func (e *Expression) String() string {
+	return e.src
+}
+
+// Eval 执行表达式，返回任意类型结果
+func (e *Expression) Eval(env Env) (any, error) {
+	return e.root.eval(env)
+}
+
+// EvalBool 执行表达式，结果必须为 bool
+func (e *Expression) EvalBool(env Env) (bool, error) {
+	v, err := e.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("policy: expression %q returned %T, expected bool", e.src, v)
+	}
+	return b, nil
+}
+
+// compiled 缓存编译结果，同一表达式只编译一次
+var compiled = sync.Map{}
+
+// CompileCached 编译表达式并缓存结果
+func CompileCached(src string) (*Expression, error) {
+	if e, ok := compiled.Load(src); ok {
+		return e.(*Expression), nil
+	}
+	e, err := Compile(src)
+	if err != nil {
+		return nil, err
+	}
+	compiled.Store(src, e)
+	return e, nil
+}
@@ -0,0 +1,61 @@
+package policy
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fixture struct {
+	Env   Env `json:"env"`
+	Cases []struct {
+		Name  string `json:"name"`
+		Expr  string `json:"expr"`
+		Want  bool   `json:"want"`
+		Error bool   `json:"error"`
+	} `json:"cases"`
+}
+
+func TestFixtures(t *testing.T) {
+	data, err := os.ReadFile("testdata/fixtures.json")
+	assert.NoError(t, err)
+
+	var f fixture
+	assert.NoError(t, json.Unmarshal(data, &f))
+
+	for _, c := range f.Cases {
+		t.Run(c.Name, func(t *testing.T) {
+			expr, err := Compile(c.Expr)
+			if err == nil {
+				var got bool
+				got, err = expr.EvalBool(f.Env)
+				if !c.Error {
+					assert.Equal(t, c.Want, got)
+				}
+			}
+			if c.Error {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGoValues(t *testing.T) {
+	type ID int64
+	id := ID(3)
+	env := Env{
+		"user":    map[string]any{"id": &id, "roles": []string{"admin"}},
+		"request": map[string]any{"time": time.Date(2026, 1, 1, 20, 0, 0, 0, time.UTC)},
+	}
+
+	expr, err := CompileCached("user.id == 3 && 'admin' in user.roles && hour(request.time) == 20")
+	assert.NoError(t, err)
+	ok, err := expr.EvalBool(env)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
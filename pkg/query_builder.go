@@ -149,6 +149,13 @@ func BuildQueryScope(c *gin.Context, model any) func(*gorm.DB) *gorm.DB {
 			"pageSize":  true,
 			"sortBy":    true,
 			"sortOrder": true,
+			"cursor":    true,
+			"count":     true,
+			"fields":    true,
+			"expand":    true,
+			// 导出参数
+			"columns": true,
+			"async":   true,
 		}
 
 		// 获取所有查询参数
@@ -10,8 +10,20 @@ type QueryPage struct {
 
 	SortBy   *string `json:"sortBy"`
 	SortDesc *string `json:"sortOrder"`
+
+	// Cursor 游标分页的游标，为 nil 时按页码分页，空字符串表示第一页
+	Cursor *string `json:"cursor"`
+	// Count 总数的统计方式，见 CountExact、CountEstimate、CountNone
+	Count string `json:"count"`
 }
 
+// 总数的统计方式
+const (
+	CountExact    = "exact"    // COUNT(*) 精确统计
+	CountEstimate = "estimate" // 按数据库的执行计划估算，不支持时精确统计
+	CountNone     = "none"     // 不统计
+)
+
 // BindQuery 从 gin.Context 中解析 query 参数并填充到 QueryPage
 func BindQuery(c *gin.Context) *QueryPage {
 
@@ -34,6 +46,10 @@ func BindQuery(c *gin.Context) *QueryPage {
 		desc := "desc"
 		query.SortDesc = &desc
 	}
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		query.Cursor = &cursor
+	}
+	query.Count = c.Query("count")
 
 	return query
 }
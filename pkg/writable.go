@@ -0,0 +1,124 @@
+package pkg
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TagSeedgo 模型字段的 seedgo 标签名
+//
+//	seedgo:"writable"       接口可写字段
+//	seedgo:"writable,super" 仅超级用户可写的特权字段
+const TagSeedgo = "seedgo"
+
+// writableField 可写字段信息
+type writableField struct {
+	Name  string
+	Super bool
+}
+
+// writableCache 缓存模型的可写字段，避免重复反射
+var writableCache = sync.Map{}
+
+// getWritableFields 解析模型结构体中标记为 writable 的字段（包含内嵌结构体）
+func getWritableFields(t reflect.Type) []writableField {
+	if cache, ok := writableCache.Load(t); ok {
+		return cache.([]writableField)
+	}
+
+	var fields []writableField
+	parseWritable(t, &fields)
+
+	writableCache.Store(t, fields)
+	return fields
+}
+
+func parseWritable(t reflect.Type, fields *[]writableField) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			parseWritable(field.Type, fields)
+			continue
+		}
+
+		tag := field.Tag.Get(TagSeedgo)
+		if tag == "" {
+			continue
+		}
+		var writable, super bool
+		for _, opt := range strings.Split(tag, ",") {
+			switch strings.TrimSpace(opt) {
+			case "writable":
+				writable = true
+			case "super":
+				super = true
+			}
+		}
+		if writable {
+			*fields = append(*fields, writableField{Name: field.Name, Super: super})
+		}
+	}
+}
+
+// WritableFields 获取模型允许通过接口写入的字段名
+// @param obj: 任意结构体实例或结构体指针
+// @param super: 是否为超级用户，超级用户可写特权字段
+// @return: 字段名切片（结构体字段名）
+func WritableFields(obj any, super bool) []string {
+	t := reflect.TypeOf(obj)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return []string{}
+	}
+
+	var names []string
+	for _, f := range getWritableFields(t) {
+		if f.Super && !super {
+			continue
+		}
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// ResetUnwritable 将不可写字段重置为零值，防止请求参数批量赋值（mass assignment）
+// @param obj: 结构体指针
+// @param super: 是否为超级用户
+func ResetUnwritable(obj any, super bool) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	allowed := make(map[string]bool)
+	for _, name := range WritableFields(obj, super) {
+		allowed[name] = true
+	}
+	resetStruct(v, allowed)
+}
+
+func resetStruct(v reflect.Value, allowed map[string]bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			resetStruct(value, allowed)
+			continue
+		}
+
+		if allowed[field.Name] || !value.CanSet() {
+			continue
+		}
+		value.Set(reflect.Zero(field.Type))
+	}
+}